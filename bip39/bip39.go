@@ -45,11 +45,8 @@ var (
 		21: big.NewInt(2),
 	}
 
-	// wordList is the set of words to use.
-	wordList []string
-
-	// wordMap is a reverse lookup map for wordList.
-	wordMap map[string]int
+	// defaultList is the package-wide list set by SetWordList.
+	defaultList *Wordlist
 )
 
 var (
@@ -73,26 +70,21 @@ func init() {
 	SetWordList(English)
 }
 
-// SetWordList sets the list of words to use for mnemonics. Currently the list
-// that is set is used package-wide.
+// SetWordList sets the list of words to use for mnemonics. The list that is
+// set is used package-wide; services handling several languages at once
+// should use the WithList variants instead.
 func SetWordList(list []string) {
-	wordList = list
-	wordMap = map[string]int{}
-
-	for i, v := range wordList {
-		wordMap[v] = i
-	}
+	defaultList = newWordlist("", list)
 }
 
 // GetWordList gets the list of words to use for mnemonics.
 func GetWordList() []string {
-	return wordList
+	return defaultList.Words()
 }
 
-// GetWordIndex gets word index in wordMap.
+// GetWordIndex gets word index in the package-wide list.
 func GetWordIndex(word string) (int, bool) {
-	idx, ok := wordMap[word]
-	return idx, ok
+	return defaultList.Index(word)
 }
 
 // NewEntropy will create random entropy bytes
@@ -114,7 +106,13 @@ func NewEntropy(bitSize int) ([]byte, error) {
 // and returns the input entropy used to generate the given mnemonic.
 // An error is returned if the given mnemonic is invalid.
 func EntropyFromMnemonic(mnemonic string) ([]byte, error) {
-	mnemonicSlice, isValid := splitMnemonicWords(mnemonic)
+	return EntropyFromMnemonicWithList(mnemonic, defaultList)
+}
+
+// EntropyFromMnemonicWithList is EntropyFromMnemonic using the given list.
+// The mnemonic is NFKD normalized before lookup.
+func EntropyFromMnemonicWithList(mnemonic string, list *Wordlist) ([]byte, error) {
+	mnemonicSlice, isValid := splitMnemonicWords(normalize(mnemonic))
 	if !isValid {
 		return nil, ErrInvalidMnemonic
 	}
@@ -126,7 +124,7 @@ func EntropyFromMnemonic(mnemonic string) ([]byte, error) {
 	)
 
 	for _, v := range mnemonicSlice {
-		index, found := wordMap[v]
+		index, found := list.index[v]
 		if !found {
 			return nil, fmt.Errorf("word `%v` not found in reverse map", v)
 		}
@@ -169,6 +167,11 @@ func EntropyFromMnemonic(mnemonic string) ([]byte, error) {
 // the given entropy.
 // If the provide entropy is invalid, an error will be returned.
 func NewMnemonic(entropy []byte) (string, error) {
+	return NewMnemonicWithList(entropy, defaultList)
+}
+
+// NewMnemonicWithList is NewMnemonic using the given list.
+func NewMnemonicWithList(entropy []byte, list *Wordlist) (string, error) {
 	// Compute some lengths for convenience.
 	entropyBitLength := len(entropy) * 8
 	checksumBitLength := entropyBitLength / 32
@@ -206,10 +209,10 @@ func NewMnemonic(entropy []byte) (string, error) {
 		wordBytes := padByteSlice(word.Bytes(), 2)
 
 		// Convert bytes to an index and add that word to the list.
-		words[i] = wordList[binary.BigEndian.Uint16(wordBytes)]
+		words[i] = list.words[binary.BigEndian.Uint16(wordBytes)]
 	}
 
-	return strings.Join(words, " "), nil
+	return strings.Join(words, list.separator), nil
 }
 
 // MnemonicToByteArray takes a mnemonic string and turns it into a byte array
@@ -250,9 +253,10 @@ func NewSeedWithErrorChecking(mnemonic string, password string) ([]byte, error)
 }
 
 // NewSeed creates a hashed seed output given a provided string and password.
+// Both are NFKD normalized as required by the spec.
 // No checking is performed to validate that the string provided is a valid mnemonic.
 func NewSeed(mnemonic string, password string) []byte {
-	return pbkdf2.Key([]byte(mnemonic), []byte("mnemonic"+password), 2048, 64, sha512.New)
+	return pbkdf2.Key([]byte(normalize(mnemonic)), []byte(normalize("mnemonic"+password)), 2048, 64, sha512.New)
 }
 
 // IsMnemonicValid attempts to verify that the provided mnemonic is valid.
@@ -263,6 +267,12 @@ func IsMnemonicValid(mnemonic string) bool {
 	return err == nil
 }
 
+// IsMnemonicValidIn is IsMnemonicValid using the given list.
+func IsMnemonicValidIn(mnemonic string, list *Wordlist) bool {
+	_, err := EntropyFromMnemonicWithList(mnemonic, list)
+	return err == nil
+}
+
 // Appends to data the first (len(data) / 32)bits of the result of sha256(data)
 // Currently only supports data up to 32 bytes.
 func addChecksum(data []byte) []byte {
@@ -0,0 +1,211 @@
+package bip39
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// uniquePrefixLen is the prefix length that identifies a word in the English
+// list, as recommended by the spec.
+const uniquePrefixLen = 4
+
+var (
+	// ErrTooManyErrors is returned by RepairMnemonic when more than one word
+	// is unknown, or a word is both missing and misspelled.
+	ErrTooManyErrors = errors.New("Mnemonic has more than one unknown word")
+)
+
+// Candidate is a corrected mnemonic with a valid checksum.
+type Candidate struct {
+	Mnemonic string
+	// Position is the index of the replaced or inserted word, -1 when the
+	// input only needed prefix completion.
+	Position int
+	Word     string
+	// Distance is the edit distance between the replaced and the new word,
+	// 0 for an inserted word.
+	Distance int
+}
+
+// WordsWithPrefix returns the words starting with prefix in list order.
+func (w *Wordlist) WordsWithPrefix(prefix string) []string {
+	prefix = normalize(prefix)
+	var out []string
+	for _, word := range w.words {
+		if strings.HasPrefix(normalize(word), prefix) {
+			out = append(out, word)
+		}
+	}
+	return out
+}
+
+// Complete returns the word a prefix stands for. It succeeds when the prefix
+// matches exactly one word, or when its first four letters match the first
+// four letters of exactly one word, so "aban" and "abandn" both give
+// "abandon" in the English list.
+func (w *Wordlist) Complete(prefix string) (string, bool) {
+	prefix = normalize(prefix)
+	if idx, ok := w.index[prefix]; ok {
+		return w.words[idx], true
+	}
+	if matches := w.WordsWithPrefix(prefix); len(matches) == 1 {
+		return matches[0], true
+	}
+	if utf8.RuneCountInString(prefix) < uniquePrefixLen {
+		return "", false
+	}
+	head := string([]rune(prefix)[:uniquePrefixLen])
+	if matches := w.WordsWithPrefix(head); len(matches) == 1 {
+		return matches[0], true
+	}
+	return "", false
+}
+
+// Suggest returns up to n words closest to word by Levenshtein distance,
+// nearest first.
+func (w *Wordlist) Suggest(word string, n int) []string {
+	word = normalize(word)
+	type scored struct {
+		index, distance int
+	}
+	scores := make([]scored, len(w.words))
+	for i, v := range w.words {
+		scores[i] = scored{i, levenshtein(word, normalize(v))}
+	}
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].distance < scores[j].distance
+	})
+	if n > len(scores) {
+		n = len(scores)
+	}
+	out := make([]string, n)
+	for i := range out {
+		out[i] = w.words[scores[i].index]
+	}
+	return out
+}
+
+// RepairMnemonic searches for valid mnemonics near the given one. Prefixes
+// are completed first. Then, depending on what is left:
+//
+//   - one unknown word: every word of the list is tried at its position;
+//   - one word short: every word is tried at every position;
+//   - all words known but the checksum fails: every single word replacement
+//     is tried.
+//
+// Candidates are ordered by edit distance to the replaced word. The checksum
+// only has 4 to 8 bits, so more than one candidate is normal and the user has
+// to pick; a mnemonic that is already valid is returned as the only candidate.
+func RepairMnemonic(mnemonic string, list *Wordlist) ([]Candidate, error) {
+	fields := strings.Fields(normalize(mnemonic))
+	words := make([]string, len(fields))
+	unknown := -1
+	for i, f := range fields {
+		word, ok := list.Complete(f)
+		if !ok {
+			if unknown >= 0 {
+				return nil, ErrTooManyErrors
+			}
+			unknown = i
+			word = f
+		}
+		words[i] = word
+	}
+
+	switch n := len(words); {
+	case validWordCount(n) && unknown >= 0:
+		return tryPosition(words, unknown, fields[unknown], list), nil
+	case validWordCount(n):
+		joined := strings.Join(words, list.separator)
+		if IsMnemonicValidIn(joined, list) {
+			return []Candidate{{Mnemonic: joined, Position: -1}}, nil
+		}
+		var out []Candidate
+		for i := range words {
+			out = append(out, tryPosition(words, i, words[i], list)...)
+		}
+		sortCandidates(out)
+		return out, nil
+	case validWordCount(n + 1):
+		if unknown >= 0 {
+			return nil, ErrTooManyErrors
+		}
+		var out []Candidate
+		for i := 0; i <= n; i++ {
+			inserted := make([]string, 0, n+1)
+			inserted = append(inserted, words[:i]...)
+			inserted = append(inserted, "")
+			inserted = append(inserted, words[i:]...)
+			out = append(out, tryPosition(inserted, i, "", list)...)
+		}
+		return out, nil
+	}
+	return nil, ErrInvalidMnemonic
+}
+
+// tryPosition returns the checksum valid mnemonics with words[pos] replaced.
+func tryPosition(words []string, pos int, original string, list *Wordlist) []Candidate {
+	trial := append([]string{}, words...)
+	var out []Candidate
+	for _, word := range list.words {
+		if word == words[pos] && original != "" {
+			continue
+		}
+		trial[pos] = word
+		joined := strings.Join(trial, list.separator)
+		if !IsMnemonicValidIn(joined, list) {
+			continue
+		}
+		c := Candidate{Mnemonic: joined, Position: pos, Word: word}
+		if original != "" {
+			c.Distance = levenshtein(normalize(original), normalize(word))
+		}
+		out = append(out, c)
+	}
+	sortCandidates(out)
+	return out
+}
+
+func sortCandidates(c []Candidate) {
+	sort.SliceStable(c, func(i, j int) bool {
+		return c[i].Distance < c[j].Distance
+	})
+}
+
+func validWordCount(n int) bool {
+	return n%3 == 0 && n >= 12 && n <= 24
+}
+
+// levenshtein is the edit distance between a and b in runes.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
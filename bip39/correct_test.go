@@ -0,0 +1,201 @@
+package bip39
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const validMnemonic = "legal winner thank year wave sausage worth useful legal winner thank yellow"
+
+func TestLevenshtein(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"abandon", "abandn", 1},
+		{"thank", "thnak", 2},
+		// Runes, not bytes.
+		{"ábaco", "abaco", 1},
+		{"あいこくしん", "あいこくし", 1},
+	}
+	for _, c := range cases {
+		if got := levenshtein(c.a, c.b); got != c.want {
+			t.Fatalf("%q %q: got %d want %d", c.a, c.b, got, c.want)
+		}
+		if got := levenshtein(c.b, c.a); got != c.want {
+			t.Fatalf("%q %q: not symmetric", c.b, c.a)
+		}
+	}
+}
+
+func TestWordsWithPrefix(t *testing.T) {
+	if got := EnglishWordlist.WordsWithPrefix("aban"); !reflect.DeepEqual(got, []string{"abandon"}) {
+		t.Fatalf("aban: %v", got)
+	}
+	if got := EnglishWordlist.WordsWithPrefix("hol"); !reflect.DeepEqual(got, []string{"hold", "hole", "holiday", "hollow"}) {
+		t.Fatalf("hol: %v", got)
+	}
+	if got := EnglishWordlist.WordsWithPrefix("zzz"); len(got) != 0 {
+		t.Fatalf("zzz: %v", got)
+	}
+	// Composed input matches the decomposed list.
+	if got := SpanishWordlist.WordsWithPrefix("ába"); len(got) != 1 || normalize(got[0]) != normalize("ábaco") {
+		t.Fatalf("ába: %v", got)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	cases := []struct {
+		prefix string
+		want   string
+		ok     bool
+	}{
+		{"abandon", "abandon", true},
+		{"aban", "abandon", true},
+		{"abandn", "abandon", true},
+		{"ABAN", "", false},
+		// "act" is a word and a prefix of other words.
+		{"act", "act", true},
+		{"acti", "action", true},
+		{"actoz", "actor", true},
+		// Ambiguous prefixes; four letters are always unique in English.
+		{"ab", "", false},
+		{"hol", "", false},
+		{"", "", false},
+		{"zzzz", "", false},
+	}
+	for _, c := range cases {
+		got, ok := EnglishWordlist.Complete(c.prefix)
+		if got != c.want || ok != c.ok {
+			t.Fatalf("%q: got %q %v", c.prefix, got, ok)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	got := EnglishWordlist.Suggest("abandn", 3)
+	if len(got) != 3 || got[0] != "abandon" {
+		t.Fatalf("abandn: %v", got)
+	}
+	if got := EnglishWordlist.Suggest("thank", 1); !reflect.DeepEqual(got, []string{"thank"}) {
+		t.Fatalf("thank: %v", got)
+	}
+	if got := EnglishWordlist.Suggest("x", 5000); len(got) != 2048 {
+		t.Fatalf("n larger than list: %d", len(got))
+	}
+	if got := EnglishWordlist.Suggest("x", 0); len(got) != 0 {
+		t.Fatalf("n zero: %v", got)
+	}
+}
+
+func checkCandidates(t *testing.T, candidates []Candidate, position int) {
+	t.Helper()
+	found := false
+	for i, c := range candidates {
+		if !IsMnemonicValidIn(c.Mnemonic, EnglishWordlist) {
+			t.Fatalf("invalid candidate %s", c.Mnemonic)
+		}
+		if i > 0 && c.Distance < candidates[i-1].Distance {
+			t.Fatal("candidates not sorted by distance")
+		}
+		if c.Mnemonic == validMnemonic && c.Position == position {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("original not among %d candidates", len(candidates))
+	}
+}
+
+func TestRepairMnemonicTypo(t *testing.T) {
+	words := strings.Fields(validMnemonic)
+	words[2] = "thxnk"
+	candidates, err := RepairMnemonic(strings.Join(words, " "), EnglishWordlist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCandidates(t, candidates, 2)
+	if c := candidates[0]; c.Mnemonic != validMnemonic || c.Word != "thank" || c.Distance != 1 {
+		t.Fatalf("best candidate %+v", c)
+	}
+}
+
+func TestRepairMnemonicPrefixes(t *testing.T) {
+	// Four letter prefixes only need completion.
+	short := "lega winn than year wave saus wort usef lega winn than yell"
+	candidates, err := RepairMnemonic(short, EnglishWordlist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != 1 || candidates[0].Mnemonic != validMnemonic || candidates[0].Position != -1 {
+		t.Fatalf("got %+v", candidates)
+	}
+
+	// An ambiguous prefix is repaired like an unknown word.
+	words := strings.Fields(validMnemonic)
+	words[0] = "le"
+	candidates, err = RepairMnemonic(strings.Join(words, " "), EnglishWordlist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCandidates(t, candidates, 0)
+}
+
+func TestRepairMnemonicMissingWord(t *testing.T) {
+	words := strings.Fields(validMnemonic)
+	missing := append(append([]string{}, words[:5]...), words[6:]...)
+	candidates, err := RepairMnemonic(strings.Join(missing, " "), EnglishWordlist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCandidates(t, candidates, 5)
+	for _, c := range candidates {
+		if c.Distance != 0 {
+			t.Fatalf("inserted word with distance %+v", c)
+		}
+	}
+}
+
+func TestRepairMnemonicChecksum(t *testing.T) {
+	// All words are known but the wrong one was written down.
+	words := strings.Fields(validMnemonic)
+	words[4] = "warm"
+	candidates, err := RepairMnemonic(strings.Join(words, " "), EnglishWordlist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCandidates(t, candidates, 4)
+	for _, c := range candidates {
+		if c.Distance == 0 {
+			t.Fatalf("replacement with distance 0 %+v", c)
+		}
+	}
+}
+
+func TestRepairMnemonicErrors(t *testing.T) {
+	words := strings.Fields(validMnemonic)
+
+	twoUnknown := append([]string{}, words...)
+	twoUnknown[1], twoUnknown[7] = "xxxx", "yyyy"
+	if _, err := RepairMnemonic(strings.Join(twoUnknown, " "), EnglishWordlist); err != ErrTooManyErrors {
+		t.Fatalf("two unknown: %v", err)
+	}
+
+	// Missing and misspelled at once.
+	shortUnknown := append([]string{}, words[1:]...)
+	shortUnknown[3] = "xxxx"
+	if _, err := RepairMnemonic(strings.Join(shortUnknown, " "), EnglishWordlist); err != ErrTooManyErrors {
+		t.Fatalf("short and unknown: %v", err)
+	}
+
+	for _, mnemonic := range []string{"", strings.Join(words[:10], " "), validMnemonic + " " + strings.Join(words[:4], " ")} {
+		if _, err := RepairMnemonic(mnemonic, EnglishWordlist); err != ErrInvalidMnemonic {
+			t.Fatalf("%q: %v", mnemonic, err)
+		}
+	}
+}
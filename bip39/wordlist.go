@@ -0,0 +1,149 @@
+package bip39
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Wordlist is an immutable BIP39 word list with its reverse lookup. Unlike
+// SetWordList it can be passed per call, so different languages can be used
+// concurrently.
+type Wordlist struct {
+	Language string
+
+	words []string
+	index map[string]int
+	// separator joins generated words; Japanese uses the ideographic space.
+	separator string
+}
+
+var (
+	// ErrWordlistInvalid is returned by NewWordlist for lists that are not
+	// 2048 unique words.
+	ErrWordlistInvalid = errors.New("Word list must contain 2048 unique words")
+
+	// ErrUnknownLanguage is returned when no candidate list contains every
+	// word of the mnemonic.
+	ErrUnknownLanguage = errors.New("Mnemonic language could not be detected")
+)
+
+// The bundled word lists. Portuguese is not bundled; load it with NewWordlist
+// and pass it to DetectLanguageIn.
+var (
+	EnglishWordlist            = newWordlist("english", English)
+	JapaneseWordlist           = newWordlist("japanese", Japanese)
+	KoreanWordlist             = newWordlist("korean", Korean)
+	SpanishWordlist            = newWordlist("spanish", Spanish)
+	FrenchWordlist             = newWordlist("french", French)
+	ItalianWordlist            = newWordlist("italian", Italian)
+	CzechWordlist              = newWordlist("czech", Czech)
+	ChineseSimplifiedWordlist  = newWordlist("chinese_simplified", ChineseSimplified)
+	ChineseTraditionalWordlist = newWordlist("chinese_traditional", ChineseTraditional)
+
+	// bundled is the order in which DetectLanguage tries the bundled lists.
+	bundled = []*Wordlist{
+		EnglishWordlist,
+		JapaneseWordlist,
+		KoreanWordlist,
+		SpanishWordlist,
+		FrenchWordlist,
+		ItalianWordlist,
+		CzechWordlist,
+		ChineseSimplifiedWordlist,
+		ChineseTraditionalWordlist,
+	}
+)
+
+func init() {
+	JapaneseWordlist.separator = "\u3000"
+}
+
+// NewWordlist builds a Wordlist from 2048 unique words.
+func NewWordlist(language string, words []string) (*Wordlist, error) {
+	w := newWordlist(language, words)
+	if len(w.words) != 2048 || len(w.index) != 2048 {
+		return nil, ErrWordlistInvalid
+	}
+	return w, nil
+}
+
+func newWordlist(language string, words []string) *Wordlist {
+	w := &Wordlist{
+		Language:  language,
+		words:     words,
+		index:     make(map[string]int, len(words)),
+		separator: " ",
+	}
+	for i, v := range words {
+		w.index[normalize(v)] = i
+	}
+	return w
+}
+
+// Words returns the words of the list.
+func (w *Wordlist) Words() []string {
+	return w.words
+}
+
+// Index returns the index of word. The word is NFKD normalized first, so
+// composed and decomposed accents both match.
+func (w *Wordlist) Index(word string) (int, bool) {
+	idx, ok := w.index[normalize(word)]
+	return idx, ok
+}
+
+// Wordlists returns the bundled lists in the order DetectLanguage tries them.
+// The slice is a copy, so callers can append their own lists to it for
+// DetectLanguageIn.
+func Wordlists() []*Wordlist {
+	return append([]*Wordlist(nil), bundled...)
+}
+
+// DetectLanguage returns the bundled list that contains every word of the
+// mnemonic. When several lists match (English and French share words, the
+// two Chinese lists share most characters) a list whose checksum validates is
+// preferred, then the order of Wordlists.
+func DetectLanguage(mnemonic string) (*Wordlist, error) {
+	return DetectLanguageIn(mnemonic, bundled...)
+}
+
+// DetectLanguageIn is DetectLanguage over the given candidate lists, tried in
+// order.
+func DetectLanguageIn(mnemonic string, lists ...*Wordlist) (*Wordlist, error) {
+	words := strings.Fields(normalize(mnemonic))
+	if len(words) == 0 {
+		return nil, ErrInvalidMnemonic
+	}
+	var first *Wordlist
+	for _, list := range lists {
+		if !list.containsAll(words) {
+			continue
+		}
+		if IsMnemonicValidIn(mnemonic, list) {
+			return list, nil
+		}
+		if first == nil {
+			first = list
+		}
+	}
+	if first == nil {
+		return nil, ErrUnknownLanguage
+	}
+	return first, nil
+}
+
+func (w *Wordlist) containsAll(words []string) bool {
+	for _, word := range words {
+		if _, ok := w.index[word]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// normalize applies the NFKD normalization required by the spec.
+func normalize(s string) string {
+	return norm.NFKD.String(s)
+}
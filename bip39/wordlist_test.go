@@ -0,0 +1,241 @@
+package bip39
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+type vector struct {
+	entropy    string
+	mnemonic   string
+	passphrase string
+	seed       string
+}
+
+// Japanese vectors from the bip32JP test set used by the trezor reference
+// implementation.
+var japaneseVectors = []vector{
+	{
+		"00000000000000000000000000000000",
+		"あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あおぞら",
+		"㍍ガバヴァぱばぐゞちぢ十人十色",
+		"a262d6fb6122ecf45be09c50492b31f92e9beb7d9a845987a02cefda57a15f9c467a17872029a9e92299b5cbdf306e3a0ee620245cbd508959b6cb7ca637bd55",
+	},
+	{
+		"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+		"そつう　れきだい　ほんやく　わかす　りくつ　ばいか　ろせん　やちん　そつう　れきだい　ほんやく　わかめ",
+		"㍍ガバヴァぱばぐゞちぢ十人十色",
+		"aee025cbe6ca256862f889e48110a6a382365142f7d16f2b9545285b3af64e542143a577e9c144e101a6bdca18f8d97ec3366ebf5b088b1c1af9bc31346e60d9",
+	},
+	{
+		"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		"われる　われる　われる　われる　われる　われる　われる　われる　われる　われる　われる　われる　われる　われる　われる　われる　われる　われる　われる　われる　われる　われる　われる　らいう",
+		"㍍ガバヴァぱばぐゞちぢ十人十色",
+		"a44ba7054ac2f9226929d56505a51e13acdaa8a9097923ca07ea465c4c7e294c038f3f4e7e4b373726ba0057191aced6e48ac8d183f3a11569c426f0de414623",
+	},
+}
+
+// Spanish vectors in the trezor layout (passphrase "TREZOR").
+var spanishVectors = []vector{
+	{
+		"00000000000000000000000000000000",
+		"ábaco ábaco ábaco ábaco ábaco ábaco ábaco ábaco ábaco ábaco ábaco abierto",
+		"TREZOR",
+		"29a2ee16de47d07025de37e7d9c596869439f9bcd26a702d2bae64db2bf0f68383841c5444b5b3bd39dd720d2ebe59969e110e5955c8e6d32c6c3294fd87439b",
+	},
+	{
+		"80808080808080808080808080808080",
+		"lino admitir bolero abrir álbum dejar acelga aprender lino admitir bolero abogado",
+		"TREZOR",
+		"a89366f7f9c4bd98afca8edf1242507506562b8eb8a3a60468cafcb6f3037aba1e4d9a7497f6d49fa94aca87c95703873741441a719325af371f8eda9b59dc83",
+	},
+	{
+		"ffffffffffffffffffffffffffffffff",
+		"zurdo zurdo zurdo zurdo zurdo zurdo zurdo zurdo zurdo zurdo zurdo yodo",
+		"TREZOR",
+		"a9d1f751178872cc53fc5433e9b2a97526448adc4b824cedeadd8a127c2416481345dfbef2bfc78275f3498e40b4e8e2e00560100e543aba3f324e752f032bc9",
+	},
+}
+
+func testVectors(t *testing.T, list *Wordlist, vectors []vector) {
+	t.Helper()
+	for _, v := range vectors {
+		entropy, _ := hex.DecodeString(v.entropy)
+		mnemonic, err := NewMnemonicWithList(entropy, list)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if normalize(mnemonic) != normalize(v.mnemonic) {
+			t.Fatalf("%s: got mnemonic %s", v.entropy, mnemonic)
+		}
+		got, err := EntropyFromMnemonicWithList(v.mnemonic, list)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, entropy) {
+			t.Fatalf("%s: got entropy %x", v.entropy, got)
+		}
+		if !IsMnemonicValidIn(v.mnemonic, list) {
+			t.Fatalf("%s: mnemonic not valid", v.entropy)
+		}
+		if seed := hex.EncodeToString(NewSeed(v.mnemonic, v.passphrase)); seed != v.seed {
+			t.Fatalf("%s: got seed %s", v.entropy, seed)
+		}
+		detected, err := DetectLanguage(v.mnemonic)
+		if err != nil || detected != list {
+			t.Fatalf("%s: detected %v %v", v.entropy, detected, err)
+		}
+	}
+}
+
+func TestJapaneseVectors(t *testing.T) {
+	testVectors(t, JapaneseWordlist, japaneseVectors)
+
+	// Words separated by ASCII spaces are accepted as well.
+	v := japaneseVectors[1]
+	if _, err := EntropyFromMnemonicWithList(strings.ReplaceAll(v.mnemonic, "　", " "), JapaneseWordlist); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSpanishVectors(t *testing.T) {
+	testVectors(t, SpanishWordlist, spanishVectors)
+}
+
+func TestNewSeedNormalizes(t *testing.T) {
+	// The same mnemonic and passphrase with composed accents and with
+	// compatibility characters must derive the vector seed.
+	v := spanishVectors[1]
+	composed := strings.ReplaceAll(v.mnemonic, "á", "á")
+	if composed == v.mnemonic {
+		t.Fatal("mnemonic has no accents")
+	}
+	if seed := hex.EncodeToString(NewSeed(composed, v.passphrase)); seed != v.seed {
+		t.Fatalf("composed mnemonic: got seed %s", seed)
+	}
+	if !IsMnemonicValidIn(composed, SpanishWordlist) {
+		t.Fatal("composed mnemonic not valid")
+	}
+
+	// "㍍" is the compatibility form of "メートル".
+	jv := japaneseVectors[0]
+	passphrase := strings.Replace(jv.passphrase, "㍍", "メートル", 1)
+	if seed := hex.EncodeToString(NewSeed(jv.mnemonic, passphrase)); seed != jv.seed {
+		t.Fatalf("decomposed passphrase: got seed %s", seed)
+	}
+}
+
+func TestWordlistLookups(t *testing.T) {
+	if idx, ok := SpanishWordlist.Index("ábaco"); !ok || idx != 0 {
+		t.Fatalf("composed index %d %v", idx, ok)
+	}
+	if _, ok := EnglishWordlist.Index("zzz"); ok {
+		t.Fatal("unexpected word")
+	}
+	if _, err := NewWordlist("short", English[:2047]); err != ErrWordlistInvalid {
+		t.Fatalf("short list: %v", err)
+	}
+	dup := append(append([]string{}, English[:2047]...), English[0])
+	if _, err := NewWordlist("dup", dup); err != ErrWordlistInvalid {
+		t.Fatalf("duplicate list: %v", err)
+	}
+	if _, err := NewMnemonicWithList(make([]byte, 15), EnglishWordlist); err != ErrEntropyLengthInvalid {
+		t.Fatalf("entropy length: %v", err)
+	}
+}
+
+// sharedMnemonic returns a 12 word mnemonic made only of words found in both
+// lists which has a valid checksum in valid and an invalid one in other.
+func sharedMnemonic(t *testing.T, valid, other *Wordlist) string {
+	t.Helper()
+	shared := make([]string, 0)
+	for _, w := range valid.Words() {
+		if _, ok := other.Index(w); ok {
+			shared = append(shared, w)
+		}
+	}
+	if len(shared) < 12 {
+		t.Fatalf("%s and %s share %d words", valid.Language, other.Language, len(shared))
+	}
+	for _, last := range shared {
+		mnemonic := strings.Join(append(append([]string{}, shared[:11]...), last), " ")
+		if IsMnemonicValidIn(mnemonic, valid) && !IsMnemonicValidIn(mnemonic, other) {
+			return mnemonic
+		}
+	}
+	t.Fatalf("no shared mnemonic for %s", valid.Language)
+	return ""
+}
+
+func TestDetectLanguageAmbiguous(t *testing.T) {
+	// Every word is both English and French; the list whose checksum
+	// validates wins even though it comes later in Wordlists.
+	mnemonic := sharedMnemonic(t, FrenchWordlist, EnglishWordlist)
+	got, err := DetectLanguage(mnemonic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != FrenchWordlist {
+		t.Fatalf("%s: detected %s", mnemonic, got.Language)
+	}
+
+	// No list validates: the first list containing every word is returned.
+	fields := strings.Fields(mnemonic)
+	for _, last := range FrenchWordlist.Words() {
+		if _, ok := EnglishWordlist.Index(last); !ok {
+			continue
+		}
+		fields[11] = last
+		mnemonic := strings.Join(fields, " ")
+		if IsMnemonicValidIn(mnemonic, FrenchWordlist) || IsMnemonicValidIn(mnemonic, EnglishWordlist) {
+			continue
+		}
+		got, err := DetectLanguage(mnemonic)
+		if err != nil || got != EnglishWordlist {
+			t.Fatalf("%s: detected %v %v", mnemonic, got, err)
+		}
+		break
+	}
+
+	for _, mnemonic := range []string{"", "   ", "abandon abandon zzz", "abandon あいこくしん"} {
+		if _, err := DetectLanguage(mnemonic); err == nil {
+			t.Fatalf("%q: expected error", mnemonic)
+		}
+	}
+}
+
+func TestDetectLanguageIn(t *testing.T) {
+	// A list loaded with NewWordlist is only tried when passed in.
+	reversed := make([]string, len(English))
+	for i, w := range English {
+		reversed[len(English)-1-i] = w + "x"
+	}
+	custom, err := NewWordlist("custom", reversed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mnemonic, err := NewMnemonicWithList(make([]byte, 16), custom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DetectLanguage(mnemonic); err != ErrUnknownLanguage {
+		t.Fatalf("bundled: %v", err)
+	}
+	got, err := DetectLanguageIn(mnemonic, append(Wordlists(), custom)...)
+	if err != nil || got != custom {
+		t.Fatalf("custom: %v %v", got, err)
+	}
+	// Only the candidates are tried.
+	if _, err := DetectLanguageIn(validMnemonic, FrenchWordlist); err != ErrUnknownLanguage {
+		t.Fatalf("candidates: %v", err)
+	}
+
+	// Changing the returned slice does not change DetectLanguage.
+	lists := Wordlists()
+	lists[0] = custom
+	if got, err := DetectLanguage(validMnemonic); err != nil || got != EnglishWordlist {
+		t.Fatalf("after change: %v %v", got, err)
+	}
+}
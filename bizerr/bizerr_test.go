@@ -0,0 +1,36 @@
+package bizerr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestLocalize(t *testing.T) {
+	err := ErrMobileExists.With("13800000000")
+	if !errors.Is(fmt.Errorf("register: %w", err), ErrMobileExists) {
+		t.Fatal("errors.Is failed")
+	}
+	if errors.Is(err, ErrEmailExists) {
+		t.Fatal("different errors compared equal")
+	}
+	tests := []struct {
+		lang, want string
+	}{
+		{"", "手机号已经存在：13800000000"},
+		{"zh-CN", "手机号已经存在：13800000000"},
+		{"en-US,en;q=0.9", "mobile number already exists: 13800000000"},
+		{"fr", "手机号已经存在：13800000000"},
+	}
+	for _, test := range tests {
+		if got := err.Localize(test.lang); got != test.want {
+			t.Fatalf("%q: got %q", test.lang, got)
+		}
+	}
+	if e, ok := As(fmt.Errorf("wrap: %w", err)); !ok || e.Code() != 7 {
+		t.Fatal("As failed")
+	}
+	if Message("en", "unknown_key") != "unknown_key" {
+		t.Fatal("unknown key should fall back to itself")
+	}
+}
@@ -0,0 +1,198 @@
+package bizerr
+
+import "golang.org/x/text/language"
+
+// 通用错误码 消息中的 %s 由 With 填充
+var (
+	ErrInvalidArgument    = New(1, "invalid_argument")
+	ErrPermissionDenied   = New(2, "permission_denied")
+	ErrAlreadyExist       = New(3, "already_exist")
+	ErrNotExist           = New(4, "not_exist")
+	ErrUnAuthed           = New(5, "unauthed")
+	ErrBrokenPasswordRule = New(6, "broken_password_rule")
+	ErrMsgDecode          = New(9, "msg_decode")
+	ErrMsgDecrypt         = New(9, "msg_decrypt")
+
+	ErrDB    = New(11, "db")
+	ErrNoRec = New(11, "no_rec")
+	ErrRDB   = New(12, "rdb")
+
+	ErrOldLogin = New(-1107, "old_login")
+
+	ErrCryptoRand        = New(13, "crypto_rand")
+	ErrCryptoAesCipher   = New(13, "crypto_aes_cipher")
+	ErrCryptoAesGcm      = New(13, "crypto_aes_gcm")
+	ErrDeCryptoAesCipher = New(13, "decrypto_aes_cipher")
+	ErrDeCryptoAesGcm    = New(13, "decrypto_aes_gcm")
+	ErrDeCryptoAesDec    = New(13, "decrypto_aes_dec")
+	ErrAesSize           = New(13, "aes_size")
+
+	ErrTokenGen     = New(15, "token_gen")
+	ErrTokenDec     = New(15, "token_dec")
+	ErrTokenInvalid = New(15, "token_invalid")
+	ErrTokenAlg     = New(15, "token_alg")
+
+	ErrMobileNo      = New(7, "mobile_no")
+	ErrMobileFirst   = New(7, "mobile_first")
+	ErrMobileFormat  = New(7, "mobile_format")
+	ErrMobileExists  = New(7, "mobile_exists")
+	ErrMobileNotEq   = New(7, "mobile_not_eq")
+	ErrMobileAlready = New(7, "mobile_already")
+	ErrMobileCode    = New(7, "mobile_code")
+
+	ErrEmailNo      = New(8, "email_no")
+	ErrEmailFirst   = New(8, "email_first")
+	ErrEmailFormat  = New(8, "email_format")
+	ErrEmailExists  = New(8, "email_exists")
+	ErrEmailNotEq   = New(8, "email_not_eq")
+	ErrEmailAlready = New(8, "email_already")
+	ErrEmailCode    = New(8, "email_code")
+
+	ErrGaFirst   = New(16, "ga_first")
+	ErrGaGen     = New(16, "ga_gen")
+	ErrGaInvalid = New(16, "ga_invalid")
+	ErrGaNew     = New(16, "ga_new")
+
+	ErrBcryptHash = New(17, "bcrypt_hash")
+	ErrBcryptComp = New(17, "bcrypt_comp")
+
+	ErrWalletSvr = New(18, "wallet_svr")
+
+	ErrEmailByGa     = New(19, "email_by_ga")
+	ErrMobileByGa    = New(19, "mobile_by_ga")
+	ErrEmailByMobile = New(19, "email_by_mobile")
+	ErrMobileByEmail = New(19, "mobile_by_email")
+
+	ErrEmailGaNo     = New(20, "email_ga_no")
+	ErrMobileGaNo    = New(20, "mobile_ga_no")
+	ErrEmailMobileNo = New(20, "email_mobile_no")
+
+	ErrEmailSend = New(21, "email_send")
+
+	ErrAddr = New(22, "addr")
+
+	ErrUserBan = New(23, "user_ban")
+
+	Err2FaExpire = New(24, "2fa_expire")
+
+	ErrNickExists = New(25, "nick_exists")
+)
+
+func init() {
+	Register(language.SimplifiedChinese, map[string]string{
+		"invalid_argument":     "输入参数有误",
+		"permission_denied":    "权限不足",
+		"already_exist":        "资源已经存在",
+		"not_exist":            "资源不存在",
+		"unauthed":             "未授权的访问",
+		"broken_password_rule": "不满足密码规则",
+		"msg_decode":           "解码错❌",
+		"msg_decrypt":          "密文解码错误",
+		"db":                   "db内部错误，请稍后重试或联系管理员",
+		"no_rec":               "该账户未注册",
+		"rdb":                  "rdb内部错误，请稍后重试或联系管理员",
+		"old_login":            "登录无效，您被新登录踢出",
+		"crypto_rand":          "加密随机数生成错误",
+		"crypto_aes_cipher":    "加密密钥处理错误",
+		"crypto_aes_gcm":       "加密过程处理错误",
+		"decrypto_aes_cipher":  "解密密钥处理错误",
+		"decrypto_aes_gcm":     "解密过程处理错误",
+		"decrypto_aes_dec":     "解密过程处理错误",
+		"aes_size":             "密文过短",
+		"token_gen":            "签发令牌出错",
+		"token_dec":            "令牌解析出错",
+		"token_invalid":        "令牌非法",
+		"token_alg":            "令牌算法不支持: %s",
+		"mobile_no":            "手机无效",
+		"mobile_first":         "您必须先校验手机📱",
+		"mobile_format":        "不是一个正确的手机号码: %s",
+		"mobile_exists":        "手机号已经存在：%s",
+		"mobile_not_eq":        "手机与已验证的不一致：%s!=%s",
+		"mobile_already":       "您已经有验证过的手机: %s",
+		"mobile_code":          "短信验证码错误，请确认 %s",
+		"email_no":             "邮箱📮无效",
+		"email_first":          "您必须先校验邮箱",
+		"email_format":         "不是一个正确的邮箱格式: %s",
+		"email_exists":         "邮箱已经存在：%s",
+		"email_not_eq":         "邮箱与已验证的不一致：%s!=%s",
+		"email_already":        "您已经有验证过的邮箱: %s",
+		"email_code":           "邮箱验证码错误，请确认 %s",
+		"ga_first":             "您必须先校验谷歌验证",
+		"ga_gen":               "谷歌验证生成错误",
+		"ga_invalid":           "谷歌验证错误",
+		"ga_new":               "您需要重新生成谷歌验证",
+		"bcrypt_hash":          "加密出错",
+		"bcrypt_comp":          "密码错误",
+		"wallet_svr":           "钱包服务器出错",
+		"email_by_ga":          "您可以通过谷歌验证来修改邮箱",
+		"mobile_by_ga":         "您可以通过谷歌验证来修改手机",
+		"email_by_mobile":      "您可以通过认证过的手机来复位邮箱-%s",
+		"mobile_by_email":      "您可以通过认证过的邮箱来复位手机-%s",
+		"email_ga_no":          "您尚未认证邮箱和谷歌验证",
+		"mobile_ga_no":         "您尚未认证手机和谷歌认证",
+		"email_mobile_no":      "您没有认证的邮箱和手机",
+		"email_send":           "邮件发送出错",
+		"addr":                 "不是一个合法链地址",
+		"user_ban":             "用户被管理员禁用",
+		"2fa_expire":           "二次验证时间过久",
+		"nick_exists":          "昵称被占用: %s",
+	})
+	Register(language.English, map[string]string{
+		"invalid_argument":     "invalid argument",
+		"permission_denied":    "permission denied",
+		"already_exist":        "resource already exists",
+		"not_exist":            "resource does not exist",
+		"unauthed":             "unauthorized access",
+		"broken_password_rule": "password does not meet the rules",
+		"msg_decode":           "decode error",
+		"msg_decrypt":          "ciphertext decode error",
+		"db":                   "internal db error, please retry later or contact the administrator",
+		"no_rec":               "account not registered",
+		"rdb":                  "internal rdb error, please retry later or contact the administrator",
+		"old_login":            "login expired, signed out by a new login",
+		"crypto_rand":          "encryption random number error",
+		"crypto_aes_cipher":    "encryption key error",
+		"crypto_aes_gcm":       "encryption error",
+		"decrypto_aes_cipher":  "decryption key error",
+		"decrypto_aes_gcm":     "decryption error",
+		"decrypto_aes_dec":     "decryption error",
+		"aes_size":             "ciphertext too short",
+		"token_gen":            "failed to issue token",
+		"token_dec":            "failed to parse token",
+		"token_invalid":        "invalid token",
+		"token_alg":            "unsupported token algorithm: %s",
+		"mobile_no":            "invalid mobile number",
+		"mobile_first":         "please verify your mobile number first",
+		"mobile_format":        "not a valid mobile number: %s",
+		"mobile_exists":        "mobile number already exists: %s",
+		"mobile_not_eq":        "mobile number differs from the verified one: %s!=%s",
+		"mobile_already":       "you already have a verified mobile number: %s",
+		"mobile_code":          "wrong SMS code, please check %s",
+		"email_no":             "invalid email",
+		"email_first":          "please verify your email first",
+		"email_format":         "not a valid email address: %s",
+		"email_exists":         "email already exists: %s",
+		"email_not_eq":         "email differs from the verified one: %s!=%s",
+		"email_already":        "you already have a verified email: %s",
+		"email_code":           "wrong email code, please check %s",
+		"ga_first":             "please pass Google Authenticator verification first",
+		"ga_gen":               "failed to generate Google Authenticator secret",
+		"ga_invalid":           "wrong Google Authenticator code",
+		"ga_new":               "please regenerate your Google Authenticator secret",
+		"bcrypt_hash":          "failed to hash password",
+		"bcrypt_comp":          "wrong password",
+		"wallet_svr":           "wallet server error",
+		"email_by_ga":          "you can change your email with Google Authenticator",
+		"mobile_by_ga":         "you can change your mobile number with Google Authenticator",
+		"email_by_mobile":      "you can reset your email with your verified mobile number-%s",
+		"mobile_by_email":      "you can reset your mobile number with your verified email-%s",
+		"email_ga_no":          "neither email nor Google Authenticator is verified",
+		"mobile_ga_no":         "neither mobile number nor Google Authenticator is verified",
+		"email_mobile_no":      "you have no verified email or mobile number",
+		"email_send":           "failed to send email",
+		"addr":                 "not a valid chain address",
+		"user_ban":             "account disabled by the administrator",
+		"2fa_expire":           "two-factor verification expired",
+		"nick_exists":          "nickname already taken: %s",
+	})
+}
@@ -0,0 +1,88 @@
+package ecies
+
+// This file contains the versioned ciphertext format used by AEAD params:
+//
+//	aeadVersion (1) | suite (1) | ephemeral public key R | nonce | sealed
+//
+// Legacy CTR ciphertexts start with the 0x02-0x04 prefix of R, so the first
+// byte tells the two formats apart.
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"io"
+)
+
+const aeadVersion = 0x11
+
+var ErrEccUnknownSuite = errors.New("椭圆曲线: 不支持的加密套件")
+
+// aeadHeader is the part of the ciphertext before the nonce, which is also
+// authenticated as additional data.
+func aeadHeader(params *Params, rb []byte) []byte {
+	return append([]byte{aeadVersion, params.Suite}, rb...)
+}
+
+func encryptAEAD(rand io.Reader, pub *PublicKey, params *Params, m, s1, s2 []byte) ([]byte, error) {
+	R, er := GenerateKey(rand, pub.Curve, params)
+	if er != nil {
+		return nil, er
+	}
+	// The full shared x coordinate is fed into the KDF, the AEAD needs no
+	// separate MAC key.
+	z, er := R.GenerateShared(pub, MaxSharedKeyLength(pub), 0)
+	if er != nil {
+		return nil, er
+	}
+	Ke := concatKDF(params.Hash(), z, s1, params.KeyLen)
+	aead, err := params.AEAD(Ke)
+	if err != nil {
+		return nil, ErrEccKeySize
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand, nonce); err != nil {
+		return nil, ErrEccIVGen
+	}
+	header := aeadHeader(params, elliptic.Marshal(pub.Curve, R.PublicKey.X, R.PublicKey.Y))
+	ct := append(header, nonce...)
+	return aead.Seal(ct, nonce, m, append(append([]byte{}, header...), s2...)), nil
+}
+
+func (pk *PrivateKey) decryptAEAD(c, s1, s2 []byte) ([]byte, error) {
+	if len(c) < 2 {
+		return nil, ErrEccInvalidMessage
+	}
+	params, ok := aeadSuites[c[1]]
+	if !ok {
+		return nil, ErrEccUnknownSuite
+	}
+	rLen := 1 + 2*((pk.PublicKey.Curve.Params().BitSize+7)/8)
+	if len(c) < 2+rLen {
+		return nil, ErrEccInvalidMessage
+	}
+	R := new(PublicKey)
+	R.Curve = pk.PublicKey.Curve
+	R.X, R.Y = elliptic.Unmarshal(R.Curve, c[2:2+rLen])
+	if R.X == nil {
+		return nil, ErrEccInvalidPublicKey
+	}
+	z, er := pk.GenerateShared(R, MaxSharedKeyLength(R), 0)
+	if er != nil {
+		return nil, er
+	}
+	Ke := concatKDF(params.Hash(), z, s1, params.KeyLen)
+	aead, err := params.AEAD(Ke)
+	if err != nil {
+		return nil, ErrEccKeySize
+	}
+	header := c[:2+rLen]
+	rest := c[2+rLen:]
+	if len(rest) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrEccInvalidMessage
+	}
+	m, err := aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], append(append([]byte{}, header...), s2...))
+	if err != nil {
+		return nil, ErrEccInvalidMessage
+	}
+	return m, nil
+}
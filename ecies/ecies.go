@@ -87,7 +87,7 @@ func ImportECDSA(pk *ecdsa.PrivateKey) *PrivateKey {
 
 // GenerateKey Generate an elliptic curve public / private keypair. If params is nil,
 // the recommended default parameters for the key will be chosen.
-func GenerateKey(rand io.Reader, curve elliptic.Curve, params *Params) (prv *PrivateKey, er Err) {
+func GenerateKey(rand io.Reader, curve elliptic.Curve, params *Params) (prv *PrivateKey, er error) {
 	pb, x, y, err := elliptic.GenerateKey(curve, rand)
 	if err != nil {
 		er = ErrEccGenErr
@@ -112,7 +112,7 @@ func MaxSharedKeyLength(pub *PublicKey) int {
 }
 
 // GenerateShared ECDH key agreement method used to establish secret keys for encryption.
-func (pk *PrivateKey) GenerateShared(pub *PublicKey, skLen, macLen int) (sk []byte, err Err) {
+func (pk *PrivateKey) GenerateShared(pub *PublicKey, skLen, macLen int) (sk []byte, err error) {
 	if pk.PublicKey.Curve != pub.Curve {
 		return nil, ErrEccInvalidCurve
 	}
@@ -173,7 +173,7 @@ func messageTag(hash func() hash.Hash, km, msg, shared []byte) []byte {
 }
 
 // Generate an initialisation vector for CTR mode.
-func generateIV(params *Params, rand io.Reader) (iv []byte, er Err) {
+func generateIV(params *Params, rand io.Reader) (iv []byte, er error) {
 	iv = make([]byte, params.BlockSize)
 	_, err := io.ReadFull(rand, iv)
 	if err != nil {
@@ -183,7 +183,7 @@ func generateIV(params *Params, rand io.Reader) (iv []byte, er Err) {
 }
 
 // symEncrypt carries out CTR encryption using the block cipher specified in the
-func symEncrypt(rand io.Reader, params *Params, key, m []byte) (ct []byte, er Err) {
+func symEncrypt(rand io.Reader, params *Params, key, m []byte) (ct []byte, er error) {
 	c, err := params.Cipher(key)
 	if err != nil {
 		er = ErrEccKeySize
@@ -204,7 +204,7 @@ func symEncrypt(rand io.Reader, params *Params, key, m []byte) (ct []byte, er Er
 
 // symDecrypt carries out CTR decryption using the block cipher specified in
 // the parameters
-func symDecrypt(params *Params, key, ct []byte) (m []byte, er Err) {
+func symDecrypt(params *Params, key, ct []byte) (m []byte, er error) {
 	c, err := params.Cipher(key)
 	if err != nil {
 		er = ErrEccKeySize
@@ -223,11 +223,14 @@ func symDecrypt(params *Params, key, ct []byte) (m []byte, er Err) {
 // s1 and s2 contain shared information that is not part of the resulting
 // ciphertext. s1 is fed into key derivation, s2 is fed into the MAC. If the
 // shared information parameters aren't being used, they should be nil.
-func Encrypt(rand io.Reader, pub *PublicKey, m, s1, s2 []byte) (ct []byte, er Err) {
+func Encrypt(rand io.Reader, pub *PublicKey, m, s1, s2 []byte) (ct []byte, er error) {
 	params, err := pubKeyParams(pub)
 	if err != nil {
 		return nil, err
 	}
+	if params.AEAD != nil {
+		return encryptAEAD(rand, pub, params, m, s1, s2)
+	}
 
 	R, er := GenerateKey(rand, pub.Curve, params)
 	if er != nil {
@@ -258,14 +261,23 @@ func Encrypt(rand io.Reader, pub *PublicKey, m, s1, s2 []byte) (ct []byte, er Er
 }
 
 // Decrypt decrypts an ECIES ciphertext.
-func (pk *PrivateKey) Decrypt(c, s1, s2 []byte) (m []byte, err Err) {
+func (pk *PrivateKey) Decrypt(c, s1, s2 []byte) (m []byte, err error) {
 	if len(c) == 0 {
 		return nil, ErrEccInvalidMessage
 	}
+	if c[0] == aeadVersion {
+		return pk.decryptAEAD(c, s1, s2)
+	}
 	params, err := pubKeyParams(&pk.PublicKey)
 	if err != nil {
 		return nil, err
 	}
+	if params.AEAD != nil {
+		// Unversioned ciphertexts were written with CTR params.
+		if params = ctrParamsFromCurve[pk.PublicKey.Curve]; params == nil {
+			return nil, ErrEccUnsupportedECIESParameters
+		}
+	}
 
 	hashed := params.Hash()
 
@@ -310,7 +322,7 @@ func (pk *PrivateKey) Decrypt(c, s1, s2 []byte) (m []byte, err Err) {
 	return symDecrypt(params, Ke, c[mStart:mEnd])
 }
 
-func PrivateFromString(hexKey string) (*PrivateKey, Err) {
+func PrivateFromString(hexKey string) (*PrivateKey, error) {
 	pk, err := hex.DecodeString(hexKey)
 	if err != nil {
 		return nil, ErrPrvKeyDecode
@@ -329,7 +341,7 @@ func PrivateFromString(hexKey string) (*PrivateKey, Err) {
 	}, nil
 }
 
-func PublicFromString(hexKey string) (*PublicKey, Err) {
+func PublicFromString(hexKey string) (*PublicKey, error) {
 	pb, err := hex.DecodeString(hexKey)
 	if err != nil {
 		return nil, ErrPubKeyDecode
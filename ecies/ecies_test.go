@@ -7,6 +7,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"math/big"
 	"testing"
 )
@@ -469,3 +470,97 @@ func TestJavaKey(t *testing.T) {
 	pubStr := pk.PublicKey.String()
 	fmt.Println(pubStr)
 }
+
+func TestStream(t *testing.T) {
+	prv, err := GenerateKey(rand.Reader, DefaultCurve, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range []int{0, 1, StreamChunkSize, 3*StreamChunkSize + 17} {
+		message := make([]byte, size)
+		rand.Read(message)
+
+		var buf bytes.Buffer
+		w, err := NewEncryptWriter(rand.Reader, &buf, &prv.PublicKey, nil, []byte("s2"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(message); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		ct := buf.Bytes()
+
+		r, err := prv.NewDecryptReader(bytes.NewReader(ct), nil, []byte("s2"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pt, rerr := io.ReadAll(r)
+		if rerr != nil {
+			t.Fatal(rerr)
+		}
+		if !bytes.Equal(pt, message) {
+			t.Fatalf("ecies: stream of %d bytes doesn't match", size)
+		}
+
+		// Dropping the final chunk or flipping a bit must fail.
+		chunkLen := StreamChunkSize + sha256.Size
+		if size > StreamChunkSize {
+			r, _ := prv.NewDecryptReader(bytes.NewReader(ct[:len(ct)-(len(ct)-70)%chunkLen]), nil, []byte("s2"))
+			if _, err := io.ReadAll(r); err == nil {
+				t.Fatal("ecies: truncated stream decrypted")
+			}
+		}
+		tampered := append([]byte{}, ct...)
+		tampered[len(tampered)-1] ^= 1
+		r, _ = prv.NewDecryptReader(bytes.NewReader(tampered), nil, []byte("s2"))
+		if _, err := io.ReadAll(r); err == nil {
+			t.Fatal("ecies: tampered stream decrypted")
+		}
+	}
+}
+
+func TestAEADParams(t *testing.T) {
+	for _, params := range []*Params{Aes128Gcm, Aes256Gcm, ChaCha20Poly1305} {
+		prv, err := GenerateKey(rand.Reader, DefaultCurve, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		message := []byte("Hello, world.")
+		ct, err := Encrypt(rand.Reader, &prv.PublicKey, message, []byte("s1"), []byte("s2"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ct[0] != aeadVersion || ct[1] != params.Suite {
+			t.Fatal("ecies: AEAD ciphertext is not versioned")
+		}
+		// The suite comes from the ciphertext, so a key with default
+		// params still decrypts it.
+		legacy := &PrivateKey{PublicKey: PublicKey{X: prv.X, Y: prv.Y, Curve: prv.Curve}, D: prv.D}
+		pt, err := legacy.Decrypt(ct, []byte("s1"), []byte("s2"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(pt, message) {
+			t.Fatal("ecies: plaintext doesn't match message")
+		}
+		if _, err := prv.Decrypt(ct, []byte("s1"), []byte("other")); err == nil {
+			t.Fatal("ecies: decrypted with wrong shared info")
+		}
+	}
+
+	// CTR ciphertexts keep working after switching the curve default.
+	prv, _ := GenerateKey(rand.Reader, DefaultCurve, nil)
+	ct, err := Encrypt(rand.Reader, &prv.PublicKey, []byte("legacy"), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	AddParamsForCurve(DefaultCurve, Aes256Gcm)
+	defer AddParamsForCurve(DefaultCurve, Aes128Sha256)
+	prv.PublicKey.Params = nil
+	if pt, err := prv.Decrypt(ct, nil, nil); err != nil || string(pt) != "legacy" {
+		t.Fatal("ecies: CTR ciphertext no longer decrypts")
+	}
+}
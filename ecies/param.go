@@ -40,6 +40,8 @@ import (
 	"crypto/sha256"
 	"crypto/sha512"
 	"hash"
+
+	"golang.org/x/crypto/chacha20poly1305"
 )
 
 var (
@@ -57,6 +59,11 @@ type Params struct {
 	Cipher    func([]byte) (cipher.Block, error) // symmetric cipher
 	BlockSize int                                // block size of symmetric cipher
 	KeyLen    int                                // length of symmetric key
+
+	// AEAD, when set, replaces CTR mode and the HMAC tag. Such params are
+	// written in the versioned format and identified by Suite.
+	AEAD  func([]byte) (cipher.AEAD, error)
+	Suite byte
 }
 
 // Standard ECIES parameters:
@@ -107,14 +114,82 @@ var (
 	}
 )
 
+// AEAD cipher suites:
+// * ECIES using AES128-GCM, key derivation with SHA-256
+// * ECIES using AES256-GCM, key derivation with SHA-256
+// * ECIES using ChaCha20-Poly1305, key derivation with SHA-256
+
+var (
+	Aes128Gcm = &Params{
+		Hash:      sha256.New,
+		hashAlgo:  crypto.SHA256,
+		Cipher:    aes.NewCipher,
+		BlockSize: aes.BlockSize,
+		KeyLen:    16,
+		AEAD:      newGCM,
+		Suite:     1,
+	}
+
+	Aes256Gcm = &Params{
+		Hash:      sha256.New,
+		hashAlgo:  crypto.SHA256,
+		Cipher:    aes.NewCipher,
+		BlockSize: aes.BlockSize,
+		KeyLen:    32,
+		AEAD:      newGCM,
+		Suite:     2,
+	}
+
+	ChaCha20Poly1305 = &Params{
+		Hash:     sha256.New,
+		hashAlgo: crypto.SHA256,
+		KeyLen:   chacha20poly1305.KeySize,
+		AEAD:     chacha20poly1305.New,
+		Suite:    3,
+	}
+)
+
+// aeadSuites maps the suite byte of a versioned ciphertext to its params.
+var aeadSuites = map[byte]*Params{
+	Aes128Gcm.Suite:        Aes128Gcm,
+	Aes256Gcm.Suite:        Aes256Gcm,
+	ChaCha20Poly1305.Suite: ChaCha20Poly1305,
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
 var paramsFromCurve = map[elliptic.Curve]*Params{
 	elliptic.P256(): Aes128Sha256,
 	elliptic.P384(): Aes192Sha384,
 	elliptic.P521(): Aes256Sha512,
 }
 
+// ctrParamsFromCurve keeps the last CTR params of each curve, used to
+// decrypt unversioned ciphertexts after the curve switched to AEAD params.
+var ctrParamsFromCurve = map[elliptic.Curve]*Params{
+	elliptic.P256(): Aes128Sha256,
+	elliptic.P384(): Aes192Sha384,
+	elliptic.P521(): Aes256Sha512,
+}
+
+// AddParamsForCurve sets the default params of curve, e.g. Aes256Gcm to
+// write AEAD ciphertexts. AEAD params with a new Suite byte are also
+// registered for decryption.
 func AddParamsForCurve(curve elliptic.Curve, params *Params) {
 	paramsFromCurve[curve] = params
+	if params.AEAD == nil {
+		ctrParamsFromCurve[curve] = params
+		return
+	}
+	if _, ok := aeadSuites[params.Suite]; !ok {
+		aeadSuites[params.Suite] = params
+	}
 }
 
 // ParamsFromCurve selects parameters optimal for the selected elliptic curve.
@@ -123,7 +198,7 @@ func ParamsFromCurve(curve elliptic.Curve) (params *Params) {
 	return paramsFromCurve[curve]
 }
 
-func pubKeyParams(key *PublicKey) (*Params, Err) {
+func pubKeyParams(key *PublicKey) (*Params, error) {
 	params := key.Params
 	if params == nil {
 		if params = ParamsFromCurve(key.Curve); params == nil {
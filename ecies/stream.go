@@ -0,0 +1,188 @@
+package ecies
+
+// This file contains a chunked streaming format for payloads too large to
+// encrypt in memory. The stream starts with a header
+//
+//	version (1) | chunk size (4, big endian) | ephemeral public key R
+//
+// followed by chunks of chunk size plaintext bytes, the last one shorter or
+// empty. Each chunk is AES-CTR encrypted and carries its own HMAC over the
+// chunk index and a final flag, so reordered, modified or dropped chunks and
+// a stream cut at a chunk boundary are all detected.
+
+import (
+	"crypto/cipher"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"io"
+
+	"github.com/suiguo/hwlib/internal/chunked"
+)
+
+const (
+	streamVersion = 1
+
+	// StreamChunkSize is the plaintext size of every chunk but the last.
+	StreamChunkSize = 64 * 1024
+	// maxStreamChunkSize bounds the buffer a reader allocates for a header.
+	maxStreamChunkSize = 16 * 1024 * 1024
+)
+
+var (
+	ErrEccStreamHeader    = errors.New("椭圆曲线: 密文流头部不合法")
+	ErrEccStreamChunk     = errors.New("椭圆曲线: 密文块校验失败")
+	ErrEccStreamTruncated = errors.New("椭圆曲线: 密文流被截断")
+	ErrEccStreamClosed    = errors.New("椭圆曲线: 密文流已关闭")
+
+	streamErrors = chunked.Errors{
+		Closed:    ErrEccStreamClosed,
+		Chunk:     ErrEccStreamChunk,
+		Truncated: ErrEccStreamTruncated,
+	}
+)
+
+// streamCipher holds the per stream keys shared by writer and reader.
+type streamCipher struct {
+	block  cipher.Block
+	mac    hash.Hash
+	tagLen int
+	s2     []byte
+	index  uint64
+}
+
+// streamParams returns the CTR params of the key. Streams authenticate each
+// chunk with HMAC, so a curve switched to AEAD params streams with the CTR
+// params it had before.
+func streamParams(key *PublicKey) (*Params, error) {
+	params, err := pubKeyParams(key)
+	if err != nil {
+		return nil, err
+	}
+	if params.AEAD != nil {
+		if params = ctrParamsFromCurve[key.Curve]; params == nil {
+			return nil, ErrEccUnsupportedECIESParameters
+		}
+	}
+	return params, nil
+}
+
+func newStreamCipher(params *Params, z, header, s1, s2 []byte) (*streamCipher, error) {
+	Ke, Km := deriveKeys(params.Hash(), z, append(append([]byte{}, header...), s1...), params.KeyLen)
+	block, err := params.Cipher(Ke)
+	if err != nil {
+		return nil, ErrEccKeySize
+	}
+	mac := hmac.New(params.Hash, Km)
+	return &streamCipher{block: block, mac: mac, tagLen: mac.Size(), s2: s2}, nil
+}
+
+// xor encrypts or decrypts chunk index in place. Every chunk starts its CTR
+// counter at index << 64, so keystreams of different chunks never overlap.
+func (s *streamCipher) xor(buf []byte) {
+	iv := make([]byte, s.block.BlockSize())
+	binary.BigEndian.PutUint64(iv, s.index)
+	cipher.NewCTR(s.block, iv).XORKeyStream(buf, buf)
+}
+
+func (s *streamCipher) tag(ct []byte, final bool) []byte {
+	var meta [9]byte
+	binary.BigEndian.PutUint64(meta[:8], s.index)
+	if final {
+		meta[8] = 1
+	}
+	s.mac.Reset()
+	s.mac.Write(meta[:])
+	s.mac.Write(ct)
+	s.mac.Write(s.s2)
+	return s.mac.Sum(nil)
+}
+
+// seal encrypts chunk pt in place and appends its tag.
+func (s *streamCipher) seal(pt []byte, final bool) ([]byte, error) {
+	s.xor(pt)
+	tag := s.tag(pt, final)
+	s.index++
+	return append(pt, tag...), nil
+}
+
+// open checks the tag of chunk and decrypts it in place.
+func (s *streamCipher) open(chunk []byte, final bool) ([]byte, error) {
+	ct, tag := chunk[:len(chunk)-s.tagLen], chunk[len(chunk)-s.tagLen:]
+	if !hmac.Equal(tag, s.tag(ct, final)) {
+		return nil, ErrEccStreamChunk
+	}
+	s.xor(ct)
+	s.index++
+	return ct, nil
+}
+
+// NewEncryptWriter returns a writer that encrypts everything written to it
+// for pub and writes the stream to w. Close must be called to write the
+// final chunk; it does not close w. s1 and s2 are used as in Encrypt.
+func NewEncryptWriter(rand io.Reader, w io.Writer, pub *PublicKey, s1, s2 []byte) (io.WriteCloser, error) {
+	params, err := streamParams(pub)
+	if err != nil {
+		return nil, err
+	}
+	R, err := GenerateKey(rand, pub.Curve, params)
+	if err != nil {
+		return nil, err
+	}
+	z, err := R.GenerateShared(pub, params.KeyLen, params.KeyLen)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 5)
+	header[0] = streamVersion
+	binary.BigEndian.PutUint32(header[1:], StreamChunkSize)
+	header = append(header, elliptic.Marshal(pub.Curve, R.PublicKey.X, R.PublicKey.Y)...)
+
+	sc, err := newStreamCipher(params, z, header, s1, s2)
+	if err != nil {
+		return nil, err
+	}
+	if _, e := w.Write(header); e != nil {
+		return nil, e
+	}
+	return chunked.NewWriter(w, StreamChunkSize, sc.seal, streamErrors), nil
+}
+
+// NewDecryptReader reads the header of a stream written by NewEncryptWriter
+// and returns a reader of the plaintext. Reads fail once a chunk does not
+// authenticate or the stream ends before its final chunk, so callers must
+// not act on the data before reading to io.EOF.
+func (pk *PrivateKey) NewDecryptReader(r io.Reader, s1, s2 []byte) (io.Reader, error) {
+	params, err := streamParams(&pk.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	rLen := 1 + 2*((pk.PublicKey.Curve.Params().BitSize+7)/8)
+	header := make([]byte, 5+rLen)
+	if _, e := io.ReadFull(r, header); e != nil {
+		return nil, ErrEccStreamHeader
+	}
+	size := int(binary.BigEndian.Uint32(header[1:5]))
+	if header[0] != streamVersion || size == 0 || size > maxStreamChunkSize {
+		return nil, ErrEccStreamHeader
+	}
+
+	R := new(PublicKey)
+	R.Curve = pk.PublicKey.Curve
+	R.X, R.Y = elliptic.Unmarshal(R.Curve, header[5:])
+	if R.X == nil {
+		return nil, ErrEccInvalidPublicKey
+	}
+	z, err := pk.GenerateShared(R, params.KeyLen, params.KeyLen)
+	if err != nil {
+		return nil, err
+	}
+	sc, err := newStreamCipher(params, z, header, s1, s2)
+	if err != nil {
+		return nil, err
+	}
+	return chunked.NewReader(r, size, sc.tagLen, sc.open, streamErrors), nil
+}
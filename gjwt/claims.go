@@ -0,0 +1,209 @@
+package gjwt
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// ClaimOption 签名时设置标准字段
+type ClaimOption func(*gojwt.RegisteredClaims)
+
+// WithIssuer 设置iss
+func WithIssuer(iss string) ClaimOption {
+	return func(c *gojwt.RegisteredClaims) {
+		c.Issuer = iss
+	}
+}
+
+// WithAudience 设置aud
+func WithAudience(aud ...string) ClaimOption {
+	return func(c *gojwt.RegisteredClaims) {
+		c.Audience = aud
+	}
+}
+
+// WithSubject 设置sub 一般为用户id
+func WithSubject(sub string) ClaimOption {
+	return func(c *gojwt.RegisteredClaims) {
+		c.Subject = sub
+	}
+}
+
+// WithNotBefore 设置nbf 在此之前令牌无效
+func WithNotBefore(t time.Time) ClaimOption {
+	return func(c *gojwt.RegisteredClaims) {
+		c.NotBefore = gojwt.NewNumericDate(t)
+	}
+}
+
+// WithID 设置jti
+func WithID(jti string) ClaimOption {
+	return func(c *gojwt.RegisteredClaims) {
+		c.ID = jti
+	}
+}
+
+// WithRandomID 设置随机uuid作为jti 撤销令牌时需要
+func WithRandomID() ClaimOption {
+	return func(c *gojwt.RegisteredClaims) {
+		c.ID = uuid.NewString()
+	}
+}
+
+// newClaims iat固定为当前时间
+func newClaims(data interface{}, expires time.Duration, opts []ClaimOption) *CommonClaims {
+	now := time.Now()
+	registered := &gojwt.RegisteredClaims{
+		ExpiresAt: gojwt.NewNumericDate(now.Add(expires)),
+		IssuedAt:  gojwt.NewNumericDate(now),
+	}
+	for _, opt := range opts {
+		opt(registered)
+	}
+	return &CommonClaims{
+		Data:             data,
+		RegisteredClaims: registered,
+	}
+}
+
+type verifyOptions struct {
+	parser    []gojwt.ParserOption
+	requireID bool
+	idCheck   func(jti string) error
+}
+
+// VerifyOption 验证时校验标准字段 exp和nbf存在时总会校验
+type VerifyOption func(*verifyOptions)
+
+// RequireIssuer iss必须等于iss
+func RequireIssuer(iss string) VerifyOption {
+	return func(o *verifyOptions) {
+		o.parser = append(o.parser, gojwt.WithIssuer(iss))
+	}
+}
+
+// RequireAudience aud必须包含aud
+func RequireAudience(aud string) VerifyOption {
+	return func(o *verifyOptions) {
+		o.parser = append(o.parser, gojwt.WithAudience(aud))
+	}
+}
+
+// RequireSubject sub必须等于sub
+func RequireSubject(sub string) VerifyOption {
+	return func(o *verifyOptions) {
+		o.parser = append(o.parser, gojwt.WithSubject(sub))
+	}
+}
+
+// RequireExpiration 令牌必须带exp
+func RequireExpiration() VerifyOption {
+	return func(o *verifyOptions) {
+		o.parser = append(o.parser, gojwt.WithExpirationRequired())
+	}
+}
+
+// RequireIssuedAt 校验iat不能晚于当前时间
+func RequireIssuedAt() VerifyOption {
+	return func(o *verifyOptions) {
+		o.parser = append(o.parser, gojwt.WithIssuedAt())
+	}
+}
+
+// WithLeeway 校验exp/nbf/iat时允许的时钟误差
+func WithLeeway(d time.Duration) VerifyOption {
+	return func(o *verifyOptions) {
+		o.parser = append(o.parser, gojwt.WithLeeway(d))
+	}
+}
+
+// WithMethods 只接受这些签名算法
+func WithMethods(methods ...MethodSigning) VerifyOption {
+	return func(o *verifyOptions) {
+		algs := make([]string, 0, len(methods))
+		for _, m := range methods {
+			if sm, ok := signFunc[m]; ok {
+				algs = append(algs, sm.Alg())
+			}
+		}
+		o.parser = append(o.parser, gojwt.WithValidMethods(algs))
+	}
+}
+
+// RequireID 令牌必须带jti
+func RequireID() VerifyOption {
+	return func(o *verifyOptions) {
+		o.requireID = true
+	}
+}
+
+// WithIDCheck 签名和其他字段校验通过后检查jti 例如查询撤销列表
+func WithIDCheck(check func(jti string) error) VerifyOption {
+	return func(o *verifyOptions) {
+		o.idCheck = check
+	}
+}
+
+// ParseClaims 验证令牌并解析到自定义claims
+func (u *JwtTool) ParseClaims(token string, claims gojwt.Claims, opts ...VerifyOption) (parseerr error) {
+	defer func() {
+		err := recover()
+		if err != nil {
+			parseerr = err.(error)
+		}
+	}()
+	_, err := u.parse(token, claims, opts)
+	return err
+}
+
+func (u *JwtTool) parse(token string, claims gojwt.Claims, opts []VerifyOption) (*gojwt.Token, error) {
+	o := &verifyOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	t, err := gojwt.ParseWithClaims(token, claims, u.keyFunc, o.parser...)
+	if err != nil {
+		return nil, err
+	}
+	if o.requireID || o.idCheck != nil {
+		jti, err := tokenID(t)
+		if err != nil {
+			return nil, err
+		}
+		if jti == "" {
+			if o.requireID {
+				return nil, fmt.Errorf("token has no jti")
+			}
+			return t, nil
+		}
+		if o.idCheck != nil {
+			if err := o.idCheck(jti); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return t, nil
+}
+
+// tokenID 从载荷中读取jti 与claims的具体类型无关
+func tokenID(t *gojwt.Token) (string, error) {
+	parts := strings.Split(t.Raw, ".")
+	if len(parts) != 3 {
+		return "", gojwt.ErrTokenMalformed
+	}
+	payload, err := gojwt.NewParser().DecodeSegment(parts[1])
+	if err != nil {
+		return "", err
+	}
+	var claims struct {
+		ID string `json:"jti"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", err
+	}
+	return claims.ID, nil
+}
@@ -0,0 +1,80 @@
+package gjwt
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+)
+
+type userClaims struct {
+	UserID int64    `json:"uid"`
+	Roles  []string `json:"roles"`
+	gojwt.RegisteredClaims
+}
+
+func TestRegisteredClaims(t *testing.T) {
+	tool := NewJwtTool()
+	tool.SetSecretPub(MethodHMAC256, []byte("secret"))
+	tool.SetSecretPriv(MethodHMAC256, []byte("secret"))
+
+	token, err := tool.SignData(MethodHMAC256, "1234", time.Minute,
+		WithIssuer("auth"), WithAudience("api", "admin"), WithSubject("42"), WithRandomID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, data, err := tool.Verify(token, RequireIssuer("auth"), RequireAudience("admin"), RequireSubject("42"),
+		RequireIssuedAt(), RequireExpiration(), RequireID(), WithMethods(MethodHMAC256))
+	if !ok || err != nil || data != "1234" {
+		t.Fatalf("verify failed: %v", err)
+	}
+	for _, opt := range []VerifyOption{RequireIssuer("other"), RequireAudience("web"), RequireSubject("1"), WithMethods(MethodRSA256)} {
+		if ok, _, _ := tool.Verify(token, opt); ok {
+			t.Fatal("claim mismatch accepted")
+		}
+	}
+	revoked := errors.New("revoked")
+	if _, _, err := tool.Verify(token, WithIDCheck(func(string) error { return revoked })); !errors.Is(err, revoked) {
+		t.Fatalf("jti check not applied: %v", err)
+	}
+	noID, _ := tool.SignData(MethodHMAC256, "1234", time.Minute)
+	if ok, _, _ := tool.Verify(noID, RequireID()); ok {
+		t.Fatal("missing jti accepted")
+	}
+
+	future, _ := tool.SignData(MethodHMAC256, "1234", time.Minute, WithNotBefore(time.Now().Add(30*time.Second)))
+	if ok, _, _ := tool.Verify(future); ok {
+		t.Fatal("nbf not validated")
+	}
+	if ok, _, err := tool.Verify(future, WithLeeway(time.Minute)); !ok {
+		t.Fatalf("leeway not applied: %v", err)
+	}
+}
+
+func TestCustomClaims(t *testing.T) {
+	tool := NewJwtTool()
+	tool.SetSecretPub(MethodHMAC256, []byte("secret"))
+	tool.SetSecretPriv(MethodHMAC256, []byte("secret"))
+	token, err := tool.SignClaims(MethodHMAC256, &userClaims{
+		UserID: 7,
+		Roles:  []string{"admin"},
+		RegisteredClaims: gojwt.RegisteredClaims{
+			Issuer:    "auth",
+			ExpiresAt: gojwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := &userClaims{}
+	if err := tool.ParseClaims(token, claims, RequireIssuer("auth")); err != nil {
+		t.Fatal(err)
+	}
+	if claims.UserID != 7 || len(claims.Roles) != 1 || claims.Roles[0] != "admin" {
+		t.Fatalf("claims mismatch: %+v", claims)
+	}
+	if err := tool.ParseClaims(token, &userClaims{}, RequireIssuer("other")); err == nil {
+		t.Fatal("issuer mismatch accepted")
+	}
+}
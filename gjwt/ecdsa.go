@@ -10,6 +10,8 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math/big"
+
+	"github.com/suiguo/hwlib/keycodec"
 )
 
 type CurveType string
@@ -76,18 +78,36 @@ func generateEcdsa(ctype CurveType) (ecdspub string, ecdspri string, ecderr erro
 	return base64.StdEncoding.EncodeToString(pubbytes), base64.StdEncoding.EncodeToString(pri), nil
 }
 
+// 私钥可以是generateEcdsa生成的base64 也可以是keycodec支持的任意格式
 func parseEcdsaPri(base64pri string) (*ecdsa.PrivateKey, error) {
-	pri, err := base64.StdEncoding.DecodeString(base64pri)
+	key, err := keycodec.ParsePrivateKey([]byte(base64pri))
 	if err != nil {
 		return nil, err
 	}
-	ecpri, err := x509.ParseECPrivateKey(pri)
+	ecpri, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("not ecdsa key")
+	}
+	return ecpri, nil
+}
+
+// 公钥先按generateEcdsa的格式解析 失败再尝试keycodec支持的格式
+func parseEcdsaPub(pub string) (*ecdsa.PublicKey, error) {
+	if key, err := parseEcdsaPubBase64(pub); err == nil {
+		return key, nil
+	}
+	key, err := keycodec.ParsePublicKey([]byte(pub))
 	if err != nil {
 		return nil, err
 	}
-	return ecpri, err
+	ecpub, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("not ecdsa key")
+	}
+	return ecpub, nil
 }
-func parseEcdsaPub(base64pub string) (*ecdsa.PublicKey, error) {
+
+func parseEcdsaPubBase64(base64pub string) (*ecdsa.PublicKey, error) {
 	pub, err := base64.StdEncoding.DecodeString(base64pub)
 	if err != nil {
 		return nil, err
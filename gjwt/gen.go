@@ -5,10 +5,9 @@ import (
 	"crypto/ed25519"
 	"crypto/rand"
 	grsa "crypto/rsa"
-	"crypto/x509"
-	"encoding/pem"
 	"fmt"
 
+	"github.com/suiguo/hwlib/keycodec"
 	"github.com/suiguo/hwlib/rsa"
 )
 
@@ -23,37 +22,47 @@ type ParseEcdsaPubFunc func(base64pub string) (*ecdsa.PublicKey, error)
 type ParseEcdsaPrivFunc func(base64priv string) (*ecdsa.PrivateKey, error)
 type ParseRsaPubFunc func(pub string) (*grsa.PublicKey, error)
 type ParseRsaPrivFunc func(priv string) (*grsa.PrivateKey, error)
+type ParseEd25519PubFunc func(pub []byte) (ed25519.PublicKey, error)
+type ParseEd25519PrivFunc func(priv []byte) (ed25519.PrivateKey, error)
 
+// rsa密钥支持PKCS#8/PKCS#1/PKIX PEM、DER、base64、OpenSSH和JWK
 func parseRsaPub(pub string) (*grsa.PublicKey, error) {
-	block, _ := pem.Decode([]byte(pub))
-	if block == nil {
-		return nil, fmt.Errorf("block is nil")
+	return rsa.ParsePublicKey([]byte(pub))
+}
+
+func parseRsaPriv(priv string) (*grsa.PrivateKey, error) {
+	return rsa.ParsePrivateKey([]byte(priv), nil)
+}
+
+// ed25519密钥可以是原始字节 也可以是keycodec支持的任意格式
+func parseEd25519Pub(pub []byte) (ed25519.PublicKey, error) {
+	if len(pub) == ed25519.PublicKeySize {
+		return ed25519.PublicKey(pub), nil
 	}
-	pkcs8key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	key, err := keycodec.ParsePublicKey(pub)
 	if err != nil {
 		return nil, err
 	}
-	k, ok := pkcs8key.(*grsa.PublicKey)
-	if ok {
-		return k, nil
+	k, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("not ed25519 key")
 	}
-	return nil, fmt.Errorf("parse error")
+	return k, nil
 }
 
-func parseRsaPriv(priv string) (*grsa.PrivateKey, error) {
-	block, _ := pem.Decode([]byte(priv))
-	if block == nil {
-		return nil, fmt.Errorf("block is nil")
+func parseEd25519Priv(priv []byte) (ed25519.PrivateKey, error) {
+	if len(priv) == ed25519.PrivateKeySize {
+		return ed25519.PrivateKey(priv), nil
 	}
-	pkcs8key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	key, err := keycodec.ParsePrivateKey(priv)
 	if err != nil {
 		return nil, err
 	}
-	k, ok := pkcs8key.(*grsa.PrivateKey)
-	if ok {
-		return k, nil
+	k, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("not ed25519 key")
 	}
-	return nil, fmt.Errorf("parse error")
+	return k, nil
 }
 
 func hmac(bits int) ([]byte, error) {
@@ -81,13 +90,17 @@ var Gen = struct {
 }
 
 var Parse = struct {
-	ParseEcdsaPub  ParseEcdsaPubFunc
-	ParseEcdsaPriv ParseEcdsaPrivFunc
-	ParseRsaPub    ParseRsaPubFunc
-	ParseRsaPriv   ParseRsaPrivFunc
+	ParseEcdsaPub    ParseEcdsaPubFunc
+	ParseEcdsaPriv   ParseEcdsaPrivFunc
+	ParseRsaPub      ParseRsaPubFunc
+	ParseRsaPriv     ParseRsaPrivFunc
+	ParseEd25519Pub  ParseEd25519PubFunc
+	ParseEd25519Priv ParseEd25519PrivFunc
 }{
-	ParseEcdsaPub:  parseEcdsaPub,
-	ParseEcdsaPriv: parseEcdsaPri,
-	ParseRsaPub:    parseRsaPub,
-	ParseRsaPriv:   parseRsaPriv,
+	ParseEcdsaPub:    parseEcdsaPub,
+	ParseEcdsaPriv:   parseEcdsaPri,
+	ParseRsaPub:      parseRsaPub,
+	ParseRsaPriv:     parseRsaPriv,
+	ParseEd25519Pub:  parseEd25519Pub,
+	ParseEd25519Priv: parseEd25519Priv,
 }
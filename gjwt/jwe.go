@@ -0,0 +1,410 @@
+package gjwt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	grsa "crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/suiguo/hwlib/keycodec"
+)
+
+// JWE算法 密钥管理用RSA-OAEP-256或ECDH-ES+A256KW 内容加密固定A256GCM
+const (
+	AlgRSAOAEP256   = "RSA-OAEP-256"
+	AlgECDHESA256KW = "ECDH-ES+A256KW"
+	EncA256GCM      = "A256GCM"
+	ContentTypeJWT  = "JWT"
+	jweCEKSize      = 32
+	jweIVSize       = 12
+	jweTagSize      = 16
+)
+
+var (
+	ErrJWEFormat    = errors.New("jwe: malformed token")
+	ErrJWEAlg       = errors.New("jwe: unsupported alg or enc")
+	ErrJWEKey       = errors.New("jwe: no key for alg")
+	ErrJWEDecrypt   = errors.New("jwe: decryption failed")
+	ErrJWENotNested = errors.New("jwe: payload is not a jwt")
+)
+
+var jweB64 = base64.RawURLEncoding
+
+// JWEHeader JWE受保护头部
+type JWEHeader struct {
+	Alg string        `json:"alg"`
+	Enc string        `json:"enc"`
+	Cty string        `json:"cty,omitempty"`
+	Epk *keycodec.JWK `json:"epk,omitempty"`
+	Apu string        `json:"apu,omitempty"`
+	Apv string        `json:"apv,omitempty"`
+}
+
+// jweAlg 密钥对应的JWE算法 rsa用RSA-OAEP-256 ecdsa用ECDH-ES+A256KW
+func jweAlg(method MethodSigning) (string, bool) {
+	switch method {
+	case MethodRSA256, MethodRSA384, MethodRSA512, MethodRSAPSS256, MethodRSAPSS384, MethodRSAPSS512:
+		return AlgRSAOAEP256, true
+	case MethodECDSA256, MethodECDSA384, MethodECDSA512:
+		return AlgECDHESA256KW, true
+	}
+	return "", false
+}
+
+var jweMethods = map[string][]MethodSigning{
+	AlgRSAOAEP256:   {MethodRSA256, MethodRSA384, MethodRSA512, MethodRSAPSS256, MethodRSAPSS384, MethodRSAPSS512},
+	AlgECDHESA256KW: {MethodECDSA256, MethodECDSA384, MethodECDSA512},
+}
+
+// encryptKey SetSecretPub设置的公钥 解析后缓存
+func (u *JwtTool) encryptKey(method MethodSigning) (interface{}, error) {
+	v, ok := u.secretMap.Load(method)
+	if !ok {
+		return nil, ErrJWEKey
+	}
+	b, ok := v.([]byte)
+	if !ok {
+		return v, nil
+	}
+	var key interface{}
+	var err error
+	if alg, _ := jweAlg(method); alg == AlgRSAOAEP256 {
+		key, err = Parse.ParseRsaPub(string(b))
+	} else {
+		key, err = Parse.ParseEcdsaPub(string(b))
+	}
+	if err != nil {
+		return nil, err
+	}
+	u.secretMap.Store(method, key)
+	return key, nil
+}
+
+// decryptKey SetSecretPriv设置的私钥 解析后缓存
+func (u *JwtTool) decryptKey(method MethodSigning) (interface{}, error) {
+	v, ok := u.privMap.Load(method)
+	if !ok {
+		return nil, ErrJWEKey
+	}
+	b, ok := v.([]byte)
+	if !ok {
+		return v, nil
+	}
+	var key interface{}
+	var err error
+	if alg, _ := jweAlg(method); alg == AlgRSAOAEP256 {
+		key, err = Parse.ParseRsaPriv(string(b))
+	} else {
+		key, err = Parse.ParseEcdsaPriv(string(b))
+	}
+	if err != nil {
+		return nil, err
+	}
+	u.privMap.Store(method, key)
+	return key, nil
+}
+
+// Encrypt 用keyMethod对应的公钥加密payload 输出JWE compact格式
+// 嵌套令牌cty为JWT
+func (u *JwtTool) Encrypt(keyMethod MethodSigning, payload []byte, cty string) (string, error) {
+	alg, ok := jweAlg(keyMethod)
+	if !ok {
+		return "", ErrJWEAlg
+	}
+	key, err := u.encryptKey(keyMethod)
+	if err != nil {
+		return "", err
+	}
+	header := &JWEHeader{Alg: alg, Enc: EncA256GCM, Cty: cty}
+	cek := make([]byte, jweCEKSize)
+	if _, err := io.ReadFull(rand.Reader, cek); err != nil {
+		return "", err
+	}
+	var encKey []byte
+	switch k := key.(type) {
+	case *grsa.PublicKey:
+		encKey, err = grsa.EncryptOAEP(sha256.New(), rand.Reader, k, cek, nil)
+	case *ecdsa.PublicKey:
+		encKey, err = ecdhWrap(header, k, cek)
+	default:
+		err = ErrJWEKey
+	}
+	if err != nil {
+		return "", err
+	}
+	h, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	protected := jweB64.EncodeToString(h)
+	gcm, err := newJWEGCM(cek)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, jweIVSize)
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nil, iv, payload, []byte(protected))
+	ct, tag := sealed[:len(sealed)-jweTagSize], sealed[len(sealed)-jweTagSize:]
+	return strings.Join([]string{
+		protected,
+		jweB64.EncodeToString(encKey),
+		jweB64.EncodeToString(iv),
+		jweB64.EncodeToString(ct),
+		jweB64.EncodeToString(tag),
+	}, "."), nil
+}
+
+// Decrypt 解密JWE 依次尝试alg对应的已设置私钥
+func (u *JwtTool) Decrypt(token string) ([]byte, *JWEHeader, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 5 {
+		return nil, nil, ErrJWEFormat
+	}
+	seg := make([][]byte, 5)
+	for i, p := range parts {
+		b, err := jweB64.DecodeString(p)
+		if err != nil {
+			return nil, nil, ErrJWEFormat
+		}
+		seg[i] = b
+	}
+	header := &JWEHeader{}
+	if err := json.Unmarshal(seg[0], header); err != nil {
+		return nil, nil, ErrJWEFormat
+	}
+	methods, ok := jweMethods[header.Alg]
+	if !ok || header.Enc != EncA256GCM {
+		return nil, nil, ErrJWEAlg
+	}
+	if len(seg[2]) != jweIVSize || len(seg[4]) != jweTagSize {
+		return nil, nil, ErrJWEFormat
+	}
+	found := false
+	for _, m := range methods {
+		key, err := u.decryptKey(m)
+		if err != nil {
+			continue
+		}
+		found = true
+		var cek []byte
+		switch k := key.(type) {
+		case *grsa.PrivateKey:
+			cek, err = grsa.DecryptOAEP(sha256.New(), nil, k, seg[1], nil)
+		case *ecdsa.PrivateKey:
+			cek, err = ecdhUnwrap(header, k, seg[1])
+		default:
+			continue
+		}
+		if err != nil || len(cek) != jweCEKSize {
+			continue
+		}
+		gcm, err := newJWEGCM(cek)
+		if err != nil {
+			continue
+		}
+		payload, err := gcm.Open(nil, seg[2], append(seg[3], seg[4]...), []byte(parts[0]))
+		if err != nil {
+			continue
+		}
+		return payload, header, nil
+	}
+	if !found {
+		return nil, nil, ErrJWEKey
+	}
+	return nil, nil, ErrJWEDecrypt
+}
+
+// SignAndEncrypt 先用signtype签名 再用keyMethod的公钥加密 (嵌套JWT)
+func (u *JwtTool) SignAndEncrypt(signtype MethodSigning, keyMethod MethodSigning, data interface{}, expires time.Duration, opts ...ClaimOption) (string, error) {
+	return u.EncryptClaims(signtype, keyMethod, newClaims(data, expires, opts))
+}
+
+func (u *JwtTool) EncryptClaims(signtype MethodSigning, keyMethod MethodSigning, claims gojwt.Claims) (string, error) {
+	token, err := u.SignClaims(signtype, claims)
+	if err != nil {
+		return "", err
+	}
+	return u.Encrypt(keyMethod, []byte(token), ContentTypeJWT)
+}
+
+// decryptNested 解密后取出内层签名令牌
+func (u *JwtTool) decryptNested(token string) (string, error) {
+	payload, header, err := u.Decrypt(token)
+	if err != nil {
+		return "", err
+	}
+	if !strings.EqualFold(header.Cty, ContentTypeJWT) {
+		return "", ErrJWENotNested
+	}
+	return string(payload), nil
+}
+
+// DecryptAndVerify 解密并验证嵌套JWT 与VerifyAndMarshal相同
+func (u *JwtTool) DecryptAndVerify(token string, jwtdata interface{}, opts ...VerifyOption) (bool, error) {
+	inner, err := u.decryptNested(token)
+	if err != nil {
+		return false, err
+	}
+	return u.VerifyAndMarshal(inner, jwtdata, opts...)
+}
+
+// DecryptClaims 解密并验证嵌套JWT 解析到自定义claims
+func (u *JwtTool) DecryptClaims(token string, claims gojwt.Claims, opts ...VerifyOption) error {
+	inner, err := u.decryptNested(token)
+	if err != nil {
+		return err
+	}
+	return u.ParseClaims(inner, claims, opts...)
+}
+
+func newJWEGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// ecdhWrap 生成临时密钥 ECDH后Concat KDF得到KEK 用AES Key Wrap加密cek
+func ecdhWrap(header *JWEHeader, pub *ecdsa.PublicKey, cek []byte) ([]byte, error) {
+	eph, err := ecdsa.GenerateKey(pub.Curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	epk, err := keycodec.NewJWK(&eph.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	header.Epk = epk
+	kek, err := ecdhKEK(header, eph, pub)
+	if err != nil {
+		return nil, err
+	}
+	return aesKeyWrap(kek, cek)
+}
+
+func ecdhUnwrap(header *JWEHeader, priv *ecdsa.PrivateKey, encKey []byte) ([]byte, error) {
+	if header.Epk == nil || header.Epk.IsPrivate() {
+		return nil, ErrJWEFormat
+	}
+	// ecKey会检查点在曲线上
+	key, err := header.Epk.Key()
+	if err != nil {
+		return nil, err
+	}
+	epk, ok := key.(*ecdsa.PublicKey)
+	if !ok || epk.Curve != priv.Curve {
+		return nil, ErrJWEKey
+	}
+	kek, err := ecdhKEK(header, priv, epk)
+	if err != nil {
+		return nil, err
+	}
+	return aesKeyUnwrap(kek, encKey)
+}
+
+// ecdhKEK RFC 7518 4.6.2 Concat KDF
+func ecdhKEK(header *JWEHeader, priv *ecdsa.PrivateKey, pub *ecdsa.PublicKey) ([]byte, error) {
+	if !priv.Curve.IsOnCurve(pub.X, pub.Y) {
+		return nil, ErrJWEKey
+	}
+	x, _ := priv.Curve.ScalarMult(pub.X, pub.Y, priv.D.Bytes())
+	z := make([]byte, (priv.Curve.Params().BitSize+7)/8)
+	x.FillBytes(z)
+	apu, err := jweB64.DecodeString(header.Apu)
+	if err != nil {
+		return nil, ErrJWEFormat
+	}
+	apv, err := jweB64.DecodeString(header.Apv)
+	if err != nil {
+		return nil, ErrJWEFormat
+	}
+	return concatKDF(z, header.Alg, apu, apv, jweCEKSize), nil
+}
+
+func concatKDF(z []byte, alg string, apu, apv []byte, size int) []byte {
+	var info []byte
+	for _, v := range [][]byte{[]byte(alg), apu, apv} {
+		info = binary.BigEndian.AppendUint32(info, uint32(len(v)))
+		info = append(info, v...)
+	}
+	info = binary.BigEndian.AppendUint32(info, uint32(size*8))
+	var out []byte
+	for counter := uint32(1); len(out) < size; counter++ {
+		h := sha256.New()
+		binary.Write(h, binary.BigEndian, counter)
+		h.Write(z)
+		h.Write(info)
+		out = h.Sum(out)
+	}
+	return out[:size]
+}
+
+var keyWrapIV = []byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+
+// aesKeyWrap RFC 3394
+func aesKeyWrap(kek, key []byte) ([]byte, error) {
+	if len(key)%8 != 0 || len(key) < 16 {
+		return nil, ErrJWEFormat
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	n := len(key) / 8
+	out := make([]byte, 8+len(key))
+	copy(out, keyWrapIV)
+	copy(out[8:], key)
+	buf := make([]byte, 16)
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(buf, out[:8])
+			copy(buf[8:], out[i*8:i*8+8])
+			block.Encrypt(buf, buf)
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(out[:8], binary.BigEndian.Uint64(buf[:8])^t)
+			copy(out[i*8:], buf[8:])
+		}
+	}
+	return out, nil
+}
+
+func aesKeyUnwrap(kek, wrapped []byte) ([]byte, error) {
+	if len(wrapped)%8 != 0 || len(wrapped) < 24 {
+		return nil, ErrJWEDecrypt
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	n := len(wrapped)/8 - 1
+	out := make([]byte, len(wrapped))
+	copy(out, wrapped)
+	buf := make([]byte, 16)
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(buf[:8], binary.BigEndian.Uint64(out[:8])^t)
+			copy(buf[8:], out[i*8:i*8+8])
+			block.Decrypt(buf, buf)
+			copy(out[:8], buf[:8])
+			copy(out[i*8:], buf[8:])
+		}
+	}
+	if subtle.ConstantTimeCompare(out[:8], keyWrapIV) != 1 {
+		return nil, ErrJWEDecrypt
+	}
+	return out[8:], nil
+}
@@ -0,0 +1,120 @@
+package gjwt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/suiguo/hwlib/keycodec"
+)
+
+// RFC 3394 4.6
+func TestAESKeyWrap(t *testing.T) {
+	kek, _ := hex.DecodeString("000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F")
+	key, _ := hex.DecodeString("00112233445566778899AABBCCDDEEFF000102030405060708090A0B0C0D0E0F")
+	want, _ := hex.DecodeString("28C9F404C4B810F4CBCCB35CFB87F8263F5786E2D80ED326CBC7F0E71A99F43BFB988B9B7A02DD21")
+	wrapped, err := aesKeyWrap(kek, key)
+	if err != nil || !bytes.Equal(wrapped, want) {
+		t.Fatalf("wrap %x %v", wrapped, err)
+	}
+	unwrapped, err := aesKeyUnwrap(kek, wrapped)
+	if err != nil || !bytes.Equal(unwrapped, key) {
+		t.Fatalf("unwrap %x %v", unwrapped, err)
+	}
+	wrapped[5] ^= 1
+	if _, err := aesKeyUnwrap(kek, wrapped); !errors.Is(err, ErrJWEDecrypt) {
+		t.Fatal("tampered key unwrapped")
+	}
+}
+
+// RFC 7518 Appendix C
+func TestConcatKDF(t *testing.T) {
+	alice, _ := keycodec.ParseJWK([]byte(`{"kty":"EC","crv":"P-256","x":"gI0GAILBdu7T53akrFmMyGcsF3n5dO7MmwNBHKW5SV0","y":"SLW_xSffzlPWrHEVI30DHM_4egVwt3NQqeUD7nMFpps","d":"0_NxaRPUMQoAJt50Gz8YiTr8gRTwyEaCumd-MToTmIo"}`))
+	bob, _ := keycodec.ParseJWK([]byte(`{"kty":"EC","crv":"P-256","x":"weNJy2HscCSM6AEDTDg04biOvhFhyyWvOHQfeF_PxMQ","y":"e8lnCO-AlStT-NJVX-crhB7QRYhiix03illJOVAOyck","d":"VEmDZpDXXK8p8N0Cndsxs924q6nS1RXFASRl6BfUqdw"}`))
+	a, err := alice.Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := bob.Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, pub := a.(*ecdsa.PrivateKey), &b.(*ecdsa.PrivateKey).PublicKey
+	x, _ := priv.Curve.ScalarMult(pub.X, pub.Y, priv.D.Bytes())
+	z := make([]byte, 32)
+	x.FillBytes(z)
+	key := concatKDF(z, "A128GCM", []byte("Alice"), []byte("Bob"), 16)
+	if got := jweB64.EncodeToString(key); got != "VqqN6vgjbSBcIijNcacQGg" {
+		t.Fatalf("derived key %s", got)
+	}
+}
+
+func TestJWE(t *testing.T) {
+	rsaPub, rsaPri, err := Gen.GenRsa(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecPub, ecPri, err := Gen.GenEcdsa(CurveTypeP384)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tool := NewJwtTool()
+	tool.SetSecretPub(MethodRSA256, rsaPub)
+	tool.SetSecretPriv(MethodRSA256, rsaPri)
+	tool.SetSecretPub(MethodECDSA384, []byte(ecPub))
+	tool.SetSecretPriv(MethodECDSA384, []byte(ecPri))
+
+	type pii struct {
+		Name  string `json:"name"`
+		Phone string `json:"phone"`
+	}
+	for _, keyMethod := range []MethodSigning{MethodRSA256, MethodECDSA384} {
+		token, err := tool.SignAndEncrypt(MethodECDSA384, keyMethod, &pii{"alice", "13800000000"}, time.Minute, WithSubject("42"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Count(token, ".") != 4 || strings.Contains(token, "13800000000") {
+			t.Fatalf("%s: not a jwe", keyMethod)
+		}
+		var got pii
+		ok, err := tool.DecryptAndVerify(token, &got, RequireSubject("42"))
+		if !ok || err != nil || got.Phone != "13800000000" {
+			t.Fatalf("%s: %v %+v", keyMethod, err, got)
+		}
+		if ok, _ := tool.DecryptAndVerify(token, &got, RequireSubject("1")); ok {
+			t.Fatalf("%s: inner claims not verified", keyMethod)
+		}
+
+		parts := strings.Split(token, ".")
+		for i := range parts {
+			tampered := append([]string{}, parts...)
+			b, _ := jweB64.DecodeString(tampered[i])
+			if len(b) == 0 {
+				continue
+			}
+			b[len(b)-1] ^= 1
+			tampered[i] = jweB64.EncodeToString(b)
+			if _, _, err := tool.Decrypt(strings.Join(tampered, ".")); err == nil {
+				t.Fatalf("%s: tampered part %d accepted", keyMethod, i)
+			}
+		}
+	}
+
+	raw, err := tool.Encrypt(MethodRSA256, []byte("hello"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload, header, err := tool.Decrypt(raw); err != nil || string(payload) != "hello" || header.Alg != AlgRSAOAEP256 {
+		t.Fatalf("raw payload: %v", err)
+	}
+	if ok, err := tool.DecryptAndVerify(raw, nil); ok || !errors.Is(err, ErrJWENotNested) {
+		t.Fatalf("raw payload accepted as jwt: %v", err)
+	}
+	if _, err := tool.Encrypt(MethodHMAC256, []byte("hello"), ""); !errors.Is(err, ErrJWEAlg) {
+		t.Fatal("hmac key accepted")
+	}
+}
@@ -0,0 +1,332 @@
+package gjwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/suiguo/hwlib/keycodec"
+)
+
+// kidKey 带kid的密钥 hmac只有priv 只用于验证的密钥priv为nil
+type kidKey struct {
+	method MethodSigning
+	priv   interface{}
+	pub    interface{}
+}
+
+// JWKSet JWKS文档
+type JWKSet struct {
+	Keys []*keycodec.JWK `json:"keys"`
+}
+
+// parseKidKey 按签名算法解析密钥 priv和pub至少传一个 pub为空时由priv推导
+func parseKidKey(method MethodSigning, priv, pub []byte) (*kidKey, error) {
+	if _, ok := signFunc[method]; !ok {
+		return nil, fmt.Errorf("not support sign type")
+	}
+	k := &kidKey{method: method}
+	var err error
+	switch method {
+	case MethodHMAC256, MethodHMAC384, MethodHMAC512:
+		if len(priv) == 0 {
+			return nil, fmt.Errorf("hmac key is empty")
+		}
+		k.priv = priv
+		return k, nil
+	case MethodEd25519:
+		if priv != nil {
+			k.priv, err = Parse.ParseEd25519Priv(priv)
+		}
+		if err == nil && pub != nil {
+			k.pub, err = Parse.ParseEd25519Pub(pub)
+		}
+	case MethodECDSA256, MethodECDSA384, MethodECDSA512:
+		if priv != nil {
+			k.priv, err = Parse.ParseEcdsaPriv(string(priv))
+		}
+		if err == nil && pub != nil {
+			k.pub, err = Parse.ParseEcdsaPub(string(pub))
+		}
+	default:
+		if priv != nil {
+			k.priv, err = Parse.ParseRsaPriv(string(priv))
+		}
+		if err == nil && pub != nil {
+			k.pub, err = Parse.ParseRsaPub(string(pub))
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if k.pub == nil {
+		signer, ok := k.priv.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("key is empty")
+		}
+		k.pub = signer.Public()
+	}
+	return k, nil
+}
+
+// AddKey 添加一个kid密钥 同一kid会被覆盖
+// 轮换密钥时先添加新kid并用它签名 旧kid保留到已签发的令牌过期后再RemoveKey
+func (u *JwtTool) AddKey(kid string, method MethodSigning, priv []byte, pub []byte) error {
+	if kid == "" {
+		return fmt.Errorf("kid is empty")
+	}
+	k, err := parseKidKey(method, priv, pub)
+	if err != nil {
+		return err
+	}
+	u.kidMap.Store(kid, k)
+	return nil
+}
+
+func (u *JwtTool) RemoveKey(kid string) {
+	u.kidMap.Delete(kid)
+}
+
+// SignDataWithKid 用指定kid的密钥签名 令牌头部带上kid
+func (u *JwtTool) SignDataWithKid(kid string, data interface{}, expires time.Duration, opts ...ClaimOption) (string, error) {
+	return u.SignClaimsWithKid(kid, newClaims(data, expires, opts))
+}
+
+func (u *JwtTool) SignClaimsWithKid(kid string, claims gojwt.Claims) (string, error) {
+	v, ok := u.kidMap.Load(kid)
+	if !ok {
+		return "", fmt.Errorf("kid not found: %s", kid)
+	}
+	k := v.(*kidKey)
+	if k.priv == nil {
+		return "", fmt.Errorf("kid has no priv key: %s", kid)
+	}
+	token := gojwt.NewWithClaims(signFunc[k.method], claims)
+	token.Header["kid"] = kid
+	return token.SignedString(k.priv)
+}
+
+// JWKS 所有非hmac kid密钥的公钥
+func (u *JwtTool) JWKS() *JWKSet {
+	set := &JWKSet{Keys: []*keycodec.JWK{}}
+	u.kidMap.Range(func(key, value any) bool {
+		k := value.(*kidKey)
+		if _, ok := k.priv.([]byte); ok {
+			return true
+		}
+		j, err := keycodec.NewJWK(k.pub)
+		if err != nil {
+			return true
+		}
+		j.Kid = key.(string)
+		j.Use = "sig"
+		j.Alg = signFunc[k.method].Alg()
+		set.Keys = append(set.Keys, j)
+		return true
+	})
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].Kid < set.Keys[j].Kid
+	})
+	return set
+}
+
+// JWKSHandler 发布JWKS文档 一般挂在 /.well-known/jwks.json
+func (u *JwtTool) JWKSHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := json.Marshal(u.JWKS())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		w.Write(data)
+	})
+}
+
+// AddRemoteJWKS 验证时也从远端JWKS查找kid 可以添加多个签发方
+func (u *JwtTool) AddRemoteJWKS(remote *RemoteJWKS) {
+	u.remoteMu.Lock()
+	defer u.remoteMu.Unlock()
+	u.remotes = append(u.remotes, remote)
+}
+
+// kidKeyFunc 按令牌头部的kid查找验证公钥 找不到时返回nil
+func (u *JwtTool) kidKeyFunc(t *gojwt.Token, kid string) (interface{}, error) {
+	if v, ok := u.kidMap.Load(kid); ok {
+		k := v.(*kidKey)
+		if signFunc[k.method].Alg() != t.Method.Alg() {
+			return nil, fmt.Errorf("kid %s does not use %s", kid, t.Method.Alg())
+		}
+		if _, ok := k.priv.([]byte); ok {
+			return k.priv, nil
+		}
+		return k.pub, nil
+	}
+	u.remoteMu.RLock()
+	remotes := u.remotes
+	u.remoteMu.RUnlock()
+	for _, r := range remotes {
+		key, alg, err := r.Key(kid)
+		if err != nil {
+			continue
+		}
+		// 没有alg的远端密钥按密钥类型限定算法
+		if (alg != "" && alg != t.Method.Alg()) || !methodMatchesKey(t.Method, key) {
+			return nil, fmt.Errorf("kid %s does not use %s", kid, t.Method.Alg())
+		}
+		return key, nil
+	}
+	return nil, nil
+}
+
+// methodMatchesKey 签名算法与公钥类型一致 ecdsa还要求曲线一致
+func methodMatchesKey(method gojwt.SigningMethod, key interface{}) bool {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		switch method.(type) {
+		case *gojwt.SigningMethodRSA, *gojwt.SigningMethodRSAPSS:
+			return true
+		}
+	case *ecdsa.PublicKey:
+		m, ok := method.(*gojwt.SigningMethodECDSA)
+		return ok && m.CurveBits == k.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		_, ok := method.(*gojwt.SigningMethodEd25519)
+		return ok
+	}
+	return false
+}
+
+type remoteKey struct {
+	alg string
+	key interface{}
+}
+
+// RemoteJWKS 远端JWKS 按需拉取并缓存
+type RemoteJWKS struct {
+	url         string
+	client      *resty.Client
+	ttl         time.Duration
+	minInterval time.Duration
+
+	mu        sync.RWMutex
+	keys      map[string]remoteKey
+	fetchedAt time.Time
+
+	fetchMu   sync.Mutex
+	lastFetch time.Time
+}
+
+type RemoteOption func(*RemoteJWKS)
+
+// WithRefreshInterval 缓存有效期 过期后下次验证时重新拉取 默认10分钟
+func WithRefreshInterval(d time.Duration) RemoteOption {
+	return func(r *RemoteJWKS) {
+		r.ttl = d
+	}
+}
+
+// WithMinRefreshInterval 遇到未知kid时重新拉取的最小间隔 防止伪造kid刷爆远端 默认1分钟
+func WithMinRefreshInterval(d time.Duration) RemoteOption {
+	return func(r *RemoteJWKS) {
+		r.minInterval = d
+	}
+}
+
+func WithRestyClient(c *resty.Client) RemoteOption {
+	return func(r *RemoteJWKS) {
+		r.client = c
+	}
+}
+
+func NewRemoteJWKS(url string, opts ...RemoteOption) *RemoteJWKS {
+	r := &RemoteJWKS{
+		url:         url,
+		ttl:         10 * time.Minute,
+		minInterval: time.Minute,
+		keys:        map[string]remoteKey{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.client == nil {
+		r.client = resty.New().SetTimeout(3 * time.Second).SetRetryCount(2)
+	}
+	return r
+}
+
+// Refresh 立即拉取远端JWKS 失败时保留旧的缓存
+func (r *RemoteJWKS) Refresh() error {
+	r.fetchMu.Lock()
+	defer r.fetchMu.Unlock()
+	return r.fetch()
+}
+
+func (r *RemoteJWKS) fetch() error {
+	r.lastFetch = time.Now()
+	resp, err := r.client.R().Get(r.url)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("jwks %s: status %d", r.url, resp.StatusCode())
+	}
+	var set JWKSet
+	if err := json.Unmarshal(resp.Body(), &set); err != nil {
+		return err
+	}
+	keys := make(map[string]remoteKey, len(set.Keys))
+	for _, j := range set.Keys {
+		if j == nil || j.Kid == "" || (j.Use != "" && j.Use != "sig") {
+			continue
+		}
+		key, err := j.Public().Key()
+		if err != nil {
+			continue
+		}
+		keys[j.Kid] = remoteKey{alg: j.Alg, key: key}
+	}
+	r.mu.Lock()
+	r.keys = keys
+	r.fetchedAt = r.lastFetch
+	r.mu.Unlock()
+	return nil
+}
+
+// Key 查找kid对应的公钥和算法 缓存过期或kid未知时重新拉取
+func (r *RemoteJWKS) Key(kid string) (interface{}, string, error) {
+	r.mu.RLock()
+	k, ok := r.keys[kid]
+	stale := time.Since(r.fetchedAt) > r.ttl
+	r.mu.RUnlock()
+	if ok && !stale {
+		return k.key, k.alg, nil
+	}
+
+	r.fetchMu.Lock()
+	// 等锁期间可能已经被其他请求刷新
+	r.mu.RLock()
+	k, ok = r.keys[kid]
+	stale = time.Since(r.fetchedAt) > r.ttl
+	r.mu.RUnlock()
+	if (stale || !ok) && time.Since(r.lastFetch) >= r.minInterval {
+		r.fetch()
+		r.mu.RLock()
+		k, ok = r.keys[kid]
+		r.mu.RUnlock()
+	}
+	r.fetchMu.Unlock()
+	if !ok {
+		return nil, "", fmt.Errorf("kid not found: %s", kid)
+	}
+	return k.key, k.alg, nil
+}
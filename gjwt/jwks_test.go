@@ -0,0 +1,134 @@
+package gjwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+)
+
+func TestKidRotation(t *testing.T) {
+	issuer := NewJwtTool()
+	_, rsaPri, err := Gen.GenRsa(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, ecPri, err := Gen.GenEcdsa(CurveTypeP256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := issuer.AddKey("k1", MethodRSA256, rsaPri, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := issuer.AddKey("k2", MethodECDSA256, []byte(ecPri), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := issuer.AddKey("h1", MethodHMAC256, []byte("secret"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(issuer.JWKS().Keys); n != 2 {
+		t.Fatalf("jwks should hold 2 keys, got %d", n)
+	}
+	old, err := issuer.SignDataWithKid("k1", "1234", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := issuer.SignDataWithKid("k2", "1234", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, token := range []string{old, rotated} {
+		if ok, data, err := issuer.Verify(token); !ok || err != nil || data != "1234" {
+			t.Fatalf("local verify failed: %v", err)
+		}
+	}
+
+	srv := httptest.NewServer(issuer.JWKSHandler())
+	defer srv.Close()
+	verifier := NewJwtTool()
+	verifier.AddRemoteJWKS(NewRemoteJWKS(srv.URL, WithMinRefreshInterval(0)))
+	for _, token := range []string{old, rotated} {
+		if ok, _, err := verifier.Verify(token); !ok || err != nil {
+			t.Fatalf("remote verify failed: %v", err)
+		}
+	}
+	hmacToken, _ := issuer.SignDataWithKid("h1", "1234", time.Minute)
+	if ok, _, _ := verifier.Verify(hmacToken); ok {
+		t.Fatal("hmac key must not be published")
+	}
+
+	// 新密钥上线后远端未知kid触发刷新 旧密钥下线后验证失败
+	_, ecPri2, _ := Gen.GenEcdsa(CurveTypeP384)
+	issuer.AddKey("k3", MethodECDSA384, []byte(ecPri2), nil)
+	issuer.RemoveKey("k1")
+	newest, _ := issuer.SignDataWithKid("k3", "1234", time.Minute)
+	if ok, _, err := verifier.Verify(newest); !ok || err != nil {
+		t.Fatalf("rotated key not fetched: %v", err)
+	}
+	if ok, _, _ := verifier.Verify(old); ok {
+		t.Fatal("removed key still verifies")
+	}
+}
+
+func TestRemoteKeyWithoutAlg(t *testing.T) {
+	issuer := NewJwtTool()
+	_, ecPri, _ := Gen.GenEcdsa(CurveTypeP256)
+	_, rsaPri, _ := Gen.GenRsa(2048)
+	issuer.AddKey("ec", MethodECDSA256, []byte(ecPri), nil)
+	// 与ec同名kid的rsa密钥 模拟用其他类型密钥伪造
+	forger := NewJwtTool()
+	forger.AddKey("ec", MethodRSA256, rsaPri, nil)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		set := issuer.JWKS()
+		for _, k := range set.Keys {
+			k.Alg = ""
+		}
+		json.NewEncoder(w).Encode(set)
+	}))
+	defer srv.Close()
+	verifier := NewJwtTool()
+	verifier.AddRemoteJWKS(NewRemoteJWKS(srv.URL, WithMinRefreshInterval(0)))
+
+	good, _ := issuer.SignDataWithKid("ec", "1234", time.Minute)
+	if ok, _, err := verifier.Verify(good); !ok || err != nil {
+		t.Fatalf("remote verify failed: %v", err)
+	}
+	forged, _ := forger.SignDataWithKid("ec", "1234", time.Minute)
+	if ok, _, err := verifier.Verify(forged); ok || err == nil {
+		t.Fatal("rsa token accepted for ec key")
+	}
+}
+
+func TestMethodMatchesKey(t *testing.T) {
+	ec, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	rk, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ed, _, _ := ed25519.GenerateKey(rand.Reader)
+	cases := []struct {
+		method gojwt.SigningMethod
+		key    interface{}
+		want   bool
+	}{
+		{gojwt.SigningMethodES384, &ec.PublicKey, true},
+		{gojwt.SigningMethodES256, &ec.PublicKey, false},
+		{gojwt.SigningMethodRS256, &ec.PublicKey, false},
+		{gojwt.SigningMethodRS512, &rk.PublicKey, true},
+		{gojwt.SigningMethodPS256, &rk.PublicKey, true},
+		{gojwt.SigningMethodHS256, &rk.PublicKey, false},
+		{gojwt.SigningMethodEdDSA, ed, true},
+		{gojwt.SigningMethodES256, ed, false},
+		{gojwt.SigningMethodHS256, []byte("secret"), false},
+	}
+	for _, c := range cases {
+		if got := methodMatchesKey(c.method, c.key); got != c.want {
+			t.Fatalf("%s %T: got %v", c.method.Alg(), c.key, got)
+		}
+	}
+}
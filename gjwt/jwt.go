@@ -4,6 +4,7 @@ import (
 	"crypto"
 	"crypto/ed25519"
 	"fmt"
+	"net/http"
 	"sync"
 	"time"
 
@@ -47,30 +48,73 @@ var signFunc = map[MethodSigning]gojwt.SigningMethod{
 	MethodHMAC512:   gojwt.SigningMethodHS512,
 }
 
+// JwtUitls 包含ClaimsUtils 可直接用于自定义claims的签发和解析
 type JwtUitls interface {
-	VerifyAndMarshal(token string, jwtdata interface{}) (bool, error)
-	Verify(token string) (bool, any, error)
+	ClaimsUtils
+	VerifyAndMarshal(token string, jwtdata interface{}, opts ...VerifyOption) (bool, error)
+	Verify(token string, opts ...VerifyOption) (bool, any, error)
 	SetSecretPub(method MethodSigning, key []byte)
 	SetSecretPriv(method MethodSigning, key []byte)
-	SignData(signtype MethodSigning, data interface{}, expires time.Duration) (string, error)
+	SignData(signtype MethodSigning, data interface{}, expires time.Duration, opts ...ClaimOption) (string, error)
+}
+
+// ClaimsUtils 自定义claims 结构体嵌入 gojwt.RegisteredClaims 即可
+type ClaimsUtils interface {
+	SignClaims(signtype MethodSigning, claims gojwt.Claims) (string, error)
+	ParseClaims(token string, claims gojwt.Claims, opts ...VerifyOption) error
+}
+
+// KeySetUtils kid密钥集 支持多密钥并存和轮换
+type KeySetUtils interface {
+	AddKey(kid string, method MethodSigning, priv []byte, pub []byte) error
+	RemoveKey(kid string)
+	SignDataWithKid(kid string, data interface{}, expires time.Duration, opts ...ClaimOption) (string, error)
+	SignClaimsWithKid(kid string, claims gojwt.Claims) (string, error)
+	JWKS() *JWKSet
+	JWKSHandler() http.Handler
+	AddRemoteJWKS(remote *RemoteJWKS)
+}
+
+// JWEUtils JWE加密令牌 keyMethod为已设置的rsa/ecdsa密钥 加密用公钥 解密用私钥
+type JWEUtils interface {
+	Encrypt(keyMethod MethodSigning, payload []byte, cty string) (string, error)
+	Decrypt(token string) ([]byte, *JWEHeader, error)
+	SignAndEncrypt(signtype MethodSigning, keyMethod MethodSigning, data interface{}, expires time.Duration, opts ...ClaimOption) (string, error)
+	EncryptClaims(signtype MethodSigning, keyMethod MethodSigning, claims gojwt.Claims) (string, error)
+	DecryptAndVerify(token string, jwtdata interface{}, opts ...VerifyOption) (bool, error)
+	DecryptClaims(token string, claims gojwt.Claims, opts ...VerifyOption) error
 }
 
 func NewJWT() JwtUitls {
-	return &utils{}
+	return NewJwtTool()
+}
+
+// NewJwtTool 实现JwtUitls ClaimsUtils KeySetUtils JWEUtils
+func NewJwtTool() *JwtTool {
+	return &JwtTool{}
 }
 
-type utils struct {
-	JwtUitls
+type JwtTool struct {
 	secretMap sync.Map
 	privMap   sync.Map
+	kidMap    sync.Map
+
+	remoteMu sync.RWMutex
+	remotes  []*RemoteJWKS
 }
+
 type CommonClaims struct {
 	Data interface{} `json:"data"`
 	*gojwt.RegisteredClaims
 }
 
-// data 签名数据 expires多长时间过期
-func (u *utils) SignData(signtype MethodSigning, data interface{}, expires time.Duration) (signrs string, signerr error) {
+// data 签名数据 expires多长时间过期 opts设置iss/aud/sub/nbf/jti等标准字段
+func (u *JwtTool) SignData(signtype MethodSigning, data interface{}, expires time.Duration, opts ...ClaimOption) (string, error) {
+	return u.SignClaims(signtype, newClaims(data, expires, opts))
+}
+
+// SignClaims 签名任意claims
+func (u *JwtTool) SignClaims(signtype MethodSigning, claims gojwt.Claims) (signrs string, signerr error) {
 	defer func() {
 		err := recover()
 		if err != nil {
@@ -78,17 +122,11 @@ func (u *utils) SignData(signtype MethodSigning, data interface{}, expires time.
 			signerr = err.(error)
 		}
 	}()
-	signdata := &CommonClaims{
-		Data: data,
-		RegisteredClaims: &gojwt.RegisteredClaims{
-			ExpiresAt: gojwt.NewNumericDate(time.Now().Add(expires)),
-		},
-	}
 	signMethod, ok := signFunc[signtype]
 	if !ok {
 		return "", fmt.Errorf("not support sign type")
 	}
-	token := gojwt.NewWithClaims(signMethod, signdata)
+	token := gojwt.NewWithClaims(signMethod, claims)
 	priv, ok := u.privMap.Load(signtype)
 	if !ok {
 		return "", fmt.Errorf("not set priv key")
@@ -98,7 +136,12 @@ func (u *utils) SignData(signtype MethodSigning, data interface{}, expires time.
 	switch signtype {
 	case MethodEd25519:
 		if p, ok := priv.([]byte); ok {
-			key = ed25519.PrivateKey(p)
+			tmp, err := Parse.ParseEd25519Priv(p)
+			if err != nil {
+				return "", err
+			}
+			key = tmp
+			u.privMap.Store(signtype, tmp)
 		} else {
 			key = priv
 		}
@@ -139,7 +182,7 @@ func (u *utils) SignData(signtype MethodSigning, data interface{}, expires time.
 
 	return token.SignedString(key)
 }
-func (u *utils) getMethodEd25519Key() (ed25519.PublicKey, error) {
+func (u *JwtTool) getMethodEd25519Key() (ed25519.PublicKey, error) {
 	data, ok := u.secretMap.Load(MethodEd25519)
 	if !ok {
 		return nil, fmt.Errorf("not set MethodEd25519 SecretKey")
@@ -152,11 +195,15 @@ func (u *utils) getMethodEd25519Key() (ed25519.PublicKey, error) {
 	if !ok {
 		return nil, fmt.Errorf("not set Ed25519 SecretKey")
 	}
-	u.secretMap.Store(MethodEd25519, ed25519.PublicKey(tmp))
-	return ed25519.PublicKey(tmp), nil
+	key, err := Parse.ParseEd25519Pub(tmp)
+	if err != nil {
+		return nil, err
+	}
+	u.secretMap.Store(MethodEd25519, key)
+	return key, nil
 }
 
-func (u *utils) VerifyAndMarshal(token string, jwtdata interface{}) (verifyrs bool, verifyerr error) {
+func (u *JwtTool) VerifyAndMarshal(token string, jwtdata interface{}, opts ...VerifyOption) (verifyrs bool, verifyerr error) {
 	defer func() {
 		err := recover()
 		if err != nil {
@@ -164,22 +211,7 @@ func (u *utils) VerifyAndMarshal(token string, jwtdata interface{}) (verifyrs bo
 			verifyerr = err.(error)
 		}
 	}()
-	t, err := gojwt.Parse(token, func(t *gojwt.Token) (interface{}, error) {
-		switch method := t.Method.(type) {
-		case *gojwt.SigningMethodRSA:
-			return u.getRSAKey(method)
-		case *gojwt.SigningMethodEd25519:
-			return u.getMethodEd25519Key()
-		case *gojwt.SigningMethodECDSA:
-			return u.getECDSAKey(method)
-		case *gojwt.SigningMethodHMAC:
-			return u.getHMAC(method)
-		case *gojwt.SigningMethodRSAPSS:
-			return u.getRSAPASSKey(method)
-		default:
-			return nil, fmt.Errorf("not support")
-		}
-	})
+	t, err := u.parse(token, gojwt.MapClaims{}, opts)
 	if err != nil {
 		return false, err
 	}
@@ -205,7 +237,7 @@ func (u *utils) VerifyAndMarshal(token string, jwtdata interface{}) (verifyrs bo
 }
 
 // 验证jwttoken 并返回原始数据
-func (u *utils) Verify(token string) (verifyrs bool, data any, verifyerr error) {
+func (u *JwtTool) Verify(token string, opts ...VerifyOption) (verifyrs bool, data any, verifyerr error) {
 	defer func() {
 		err := recover()
 		if err != nil {
@@ -213,22 +245,7 @@ func (u *utils) Verify(token string) (verifyrs bool, data any, verifyerr error)
 			verifyerr = err.(error)
 		}
 	}()
-	t, err := gojwt.Parse(token, func(t *gojwt.Token) (interface{}, error) {
-		switch method := t.Method.(type) {
-		case *gojwt.SigningMethodRSA:
-			return u.getRSAKey(method)
-		case *gojwt.SigningMethodEd25519:
-			return u.getMethodEd25519Key()
-		case *gojwt.SigningMethodECDSA:
-			return u.getECDSAKey(method)
-		case *gojwt.SigningMethodHMAC:
-			return u.getHMAC(method)
-		case *gojwt.SigningMethodRSAPSS:
-			return u.getRSAPASSKey(method)
-		default:
-			return nil, fmt.Errorf("not support")
-		}
-	})
+	t, err := u.parse(token, gojwt.MapClaims{}, opts)
 	if err != nil {
 		return false, nil, err
 	}
@@ -241,14 +258,39 @@ func (u *utils) Verify(token string) (verifyrs bool, data any, verifyerr error)
 	}
 	return false, nil, fmt.Errorf("verify fail")
 }
-func (u *utils) SetSecretPub(method MethodSigning, key []byte) {
+
+// keyFunc 头部有kid时优先按kid查找 找不到再用算法对应的密钥
+func (u *JwtTool) keyFunc(t *gojwt.Token) (interface{}, error) {
+	if kid, ok := t.Header["kid"].(string); ok && kid != "" {
+		key, err := u.kidKeyFunc(t, kid)
+		if err != nil || key != nil {
+			return key, err
+		}
+	}
+	switch method := t.Method.(type) {
+	case *gojwt.SigningMethodRSA:
+		return u.getRSAKey(method)
+	case *gojwt.SigningMethodEd25519:
+		return u.getMethodEd25519Key()
+	case *gojwt.SigningMethodECDSA:
+		return u.getECDSAKey(method)
+	case *gojwt.SigningMethodHMAC:
+		return u.getHMAC(method)
+	case *gojwt.SigningMethodRSAPSS:
+		return u.getRSAPASSKey(method)
+	default:
+		return nil, fmt.Errorf("not support")
+	}
+}
+
+func (u *JwtTool) SetSecretPub(method MethodSigning, key []byte) {
 	u.secretMap.Store(method, key)
 }
 
-func (u *utils) SetSecretPriv(method MethodSigning, key []byte) {
+func (u *JwtTool) SetSecretPriv(method MethodSigning, key []byte) {
 	u.privMap.Store(method, key)
 }
-func (u *utils) getRSAKey(method *gojwt.SigningMethodRSA) (interface{}, error) {
+func (u *JwtTool) getRSAKey(method *gojwt.SigningMethodRSA) (interface{}, error) {
 	var data interface{}
 	var ok bool
 	var methodname MethodSigning
@@ -278,7 +320,7 @@ func (u *utils) getRSAKey(method *gojwt.SigningMethodRSA) (interface{}, error) {
 	}
 	return data, nil
 }
-func (u *utils) getECDSAKey(method *gojwt.SigningMethodECDSA) (interface{}, error) {
+func (u *JwtTool) getECDSAKey(method *gojwt.SigningMethodECDSA) (interface{}, error) {
 	var pub interface{}
 	var ok bool
 	var methodname MethodSigning
@@ -308,7 +350,7 @@ func (u *utils) getECDSAKey(method *gojwt.SigningMethodECDSA) (interface{}, erro
 	}
 	return pub, nil
 }
-func (u *utils) getRSAPASSKey(method *gojwt.SigningMethodRSAPSS) (interface{}, error) {
+func (u *JwtTool) getRSAPASSKey(method *gojwt.SigningMethodRSAPSS) (interface{}, error) {
 	var data interface{}
 	var ok bool
 	var methodname MethodSigning
@@ -338,7 +380,7 @@ func (u *utils) getRSAPASSKey(method *gojwt.SigningMethodRSAPSS) (interface{}, e
 	}
 	return data, nil
 }
-func (u *utils) getHMAC(method *gojwt.SigningMethodHMAC) (interface{}, error) {
+func (u *JwtTool) getHMAC(method *gojwt.SigningMethodHMAC) (interface{}, error) {
 	var data interface{}
 	var ok bool
 	switch method.Hash {
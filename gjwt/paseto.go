@@ -0,0 +1,420 @@
+package gjwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/chacha20"
+)
+
+// PASETO v4 https://github.com/paseto-standard/paseto-spec
+// 版本和算法固定在头部 不存在JWT的算法混淆问题
+// local: XChaCha20 + BLAKE2b-MAC 对称加密 public: Ed25519 签名
+
+type PasetoPurpose string
+
+const (
+	PasetoLocal  PasetoPurpose = "local"
+	PasetoPublic PasetoPurpose = "public"
+
+	pasetoLocalHeader  = "v4.local."
+	pasetoPublicHeader = "v4.public."
+	pasetoKeySize      = 32
+	pasetoNonceSize    = 32
+	pasetoMacSize      = 32
+)
+
+var (
+	ErrPasetoFormat  = errors.New("paseto: malformed token")
+	ErrPasetoKey     = errors.New("paseto: key not set")
+	ErrPasetoVerify  = errors.New("paseto: invalid token")
+	ErrPasetoFooter  = errors.New("paseto: footer mismatch")
+	ErrPasetoExpired = errors.New("paseto: token expired")
+	ErrPasetoNotYet  = errors.New("paseto: token not valid yet")
+)
+
+var pasetoB64 = base64.RawURLEncoding
+
+// PasetoUtils 与JwtUitls并列的PASETO v4工具
+type PasetoUtils interface {
+	// SetLocalKey v4.local的32字节密钥 可以用 Gen.GenHmac(32) 生成
+	SetLocalKey(key []byte) error
+	// v4.public的Ed25519密钥 用 Gen.GenED25519 生成 也支持keycodec的格式
+	SetSecretPub(key []byte) error
+	SetSecretPriv(key []byte) error
+
+	// Sign 把claims序列化为json后加密(local)或签名(public)
+	Sign(purpose PasetoPurpose, claims interface{}, opts ...PasetoOption) (string, error)
+	// SignData 与JwtUitls.SignData相同的载荷 时间字段为RFC 3339字符串
+	SignData(purpose PasetoPurpose, data interface{}, expires time.Duration, opts ...PasetoOption) (string, error)
+	// Verify 解密或验签后解析到claims 返回footer 不校验exp等字段
+	Verify(token string, claims interface{}, opts ...PasetoOption) ([]byte, error)
+	// VerifyData 验证SignData签发的令牌 校验exp/nbf后解析data
+	VerifyData(token string, data interface{}, opts ...PasetoOption) ([]byte, error)
+	// Footer 验证前读取footer 例如按footer中的kid选择密钥 内容不可信
+	Footer(token string) ([]byte, error)
+}
+
+type pasetoOptions struct {
+	footer      []byte
+	checkFooter bool
+	implicit    []byte
+	claims      []ClaimOption
+	leeway      time.Duration
+}
+
+type PasetoOption func(*pasetoOptions)
+
+// WithFooter 签发时附加footer(明文 但受认证) 验证时要求footer相同
+func WithFooter(footer []byte) PasetoOption {
+	return func(o *pasetoOptions) {
+		o.footer = footer
+		o.checkFooter = true
+	}
+}
+
+// WithImplicit 隐式断言 参与认证但不出现在令牌中 签发和验证必须一致
+func WithImplicit(implicit []byte) PasetoOption {
+	return func(o *pasetoOptions) {
+		o.implicit = implicit
+	}
+}
+
+// WithPasetoClaims SignData时设置iss/sub/aud/jti/nbf 多个aud只取第一个
+func WithPasetoClaims(opts ...ClaimOption) PasetoOption {
+	return func(o *pasetoOptions) {
+		o.claims = append(o.claims, opts...)
+	}
+}
+
+// WithPasetoLeeway VerifyData校验exp/nbf时允许的时钟误差
+func WithPasetoLeeway(d time.Duration) PasetoOption {
+	return func(o *pasetoOptions) {
+		o.leeway = d
+	}
+}
+
+func newPasetoOptions(opts []PasetoOption) *pasetoOptions {
+	o := &pasetoOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// PasetoClaims SignData的载荷 PASETO的时间字段为RFC 3339字符串
+type PasetoClaims struct {
+	Data       interface{} `json:"data,omitempty"`
+	Issuer     string      `json:"iss,omitempty"`
+	Subject    string      `json:"sub,omitempty"`
+	Audience   string      `json:"aud,omitempty"`
+	Expiration string      `json:"exp,omitempty"`
+	NotBefore  string      `json:"nbf,omitempty"`
+	IssuedAt   string      `json:"iat,omitempty"`
+	TokenID    string      `json:"jti,omitempty"`
+}
+
+func NewPaseto() PasetoUtils {
+	return &pasetoUtils{}
+}
+
+type pasetoUtils struct {
+	mu    sync.RWMutex
+	local []byte
+	pub   ed25519.PublicKey
+	priv  ed25519.PrivateKey
+}
+
+func (p *pasetoUtils) SetLocalKey(key []byte) error {
+	if len(key) != pasetoKeySize {
+		return errors.New("paseto: local key must be 32 bytes")
+	}
+	p.mu.Lock()
+	p.local = append([]byte{}, key...)
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *pasetoUtils) SetSecretPub(key []byte) error {
+	pub, err := Parse.ParseEd25519Pub(key)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.pub = pub
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *pasetoUtils) SetSecretPriv(key []byte) error {
+	priv, err := Parse.ParseEd25519Priv(key)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.priv = priv
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *pasetoUtils) Sign(purpose PasetoPurpose, claims interface{}, opts ...PasetoOption) (string, error) {
+	msg, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	o := newPasetoOptions(opts)
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	switch purpose {
+	case PasetoLocal:
+		if p.local == nil {
+			return "", ErrPasetoKey
+		}
+		return pasetoEncrypt(p.local, msg, o.footer, o.implicit, rand.Reader)
+	case PasetoPublic:
+		if p.priv == nil {
+			return "", ErrPasetoKey
+		}
+		return pasetoSign(p.priv, msg, o.footer, o.implicit), nil
+	}
+	return "", ErrPasetoFormat
+}
+
+func (p *pasetoUtils) SignData(purpose PasetoPurpose, data interface{}, expires time.Duration, opts ...PasetoOption) (string, error) {
+	o := newPasetoOptions(opts)
+	r := newClaims(data, expires, o.claims).RegisteredClaims
+	c := &PasetoClaims{
+		Data:       data,
+		Issuer:     r.Issuer,
+		Subject:    r.Subject,
+		Expiration: pasetoTime(r.ExpiresAt),
+		NotBefore:  pasetoTime(r.NotBefore),
+		IssuedAt:   pasetoTime(r.IssuedAt),
+		TokenID:    r.ID,
+	}
+	if len(r.Audience) > 0 {
+		c.Audience = r.Audience[0]
+	}
+	return p.Sign(purpose, c, opts...)
+}
+
+func pasetoTime(t *gojwt.NumericDate) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func (p *pasetoUtils) Verify(token string, claims interface{}, opts ...PasetoOption) ([]byte, error) {
+	o := newPasetoOptions(opts)
+	p.mu.RLock()
+	local, pub := p.local, p.pub
+	p.mu.RUnlock()
+	var msg, footer []byte
+	var err error
+	switch {
+	case strings.HasPrefix(token, pasetoLocalHeader):
+		if local == nil {
+			return nil, ErrPasetoKey
+		}
+		msg, footer, err = pasetoDecrypt(local, token, o.implicit)
+	case strings.HasPrefix(token, pasetoPublicHeader):
+		if pub == nil {
+			return nil, ErrPasetoKey
+		}
+		msg, footer, err = pasetoVerify(pub, token, o.implicit)
+	default:
+		return nil, ErrPasetoFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	if o.checkFooter && subtle.ConstantTimeCompare(footer, o.footer) != 1 {
+		return nil, ErrPasetoFooter
+	}
+	if claims != nil {
+		if err := json.Unmarshal(msg, claims); err != nil {
+			return nil, err
+		}
+	}
+	return footer, nil
+}
+
+func (p *pasetoUtils) VerifyData(token string, data interface{}, opts ...PasetoOption) ([]byte, error) {
+	c := &PasetoClaims{}
+	footer, err := p.Verify(token, c, opts...)
+	if err != nil {
+		return nil, err
+	}
+	o := newPasetoOptions(opts)
+	now := time.Now()
+	if c.Expiration != "" {
+		exp, err := time.Parse(time.RFC3339, c.Expiration)
+		if err != nil {
+			return nil, ErrPasetoFormat
+		}
+		if now.After(exp.Add(o.leeway)) {
+			return nil, ErrPasetoExpired
+		}
+	}
+	if c.NotBefore != "" {
+		nbf, err := time.Parse(time.RFC3339, c.NotBefore)
+		if err != nil {
+			return nil, ErrPasetoFormat
+		}
+		if now.Add(o.leeway).Before(nbf) {
+			return nil, ErrPasetoNotYet
+		}
+	}
+	if data != nil && c.Data != nil {
+		d, err := json.Marshal(c.Data)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(d, data); err != nil {
+			return nil, err
+		}
+	}
+	return footer, nil
+}
+
+func (p *pasetoUtils) Footer(token string) ([]byte, error) {
+	var body string
+	switch {
+	case strings.HasPrefix(token, pasetoLocalHeader):
+		body = token[len(pasetoLocalHeader):]
+	case strings.HasPrefix(token, pasetoPublicHeader):
+		body = token[len(pasetoPublicHeader):]
+	default:
+		return nil, ErrPasetoFormat
+	}
+	_, footer, err := pasetoSplit(body)
+	return footer, err
+}
+
+// pae Pre-Authentication Encoding
+func pae(pieces ...[]byte) []byte {
+	out := binary.LittleEndian.AppendUint64(nil, uint64(len(pieces))&(1<<63-1))
+	for _, p := range pieces {
+		out = binary.LittleEndian.AppendUint64(out, uint64(len(p))&(1<<63-1))
+		out = append(out, p...)
+	}
+	return out
+}
+
+// pasetoSplit 拆出载荷和footer
+func pasetoSplit(body string) ([]byte, []byte, error) {
+	payload, footer, hasFooter := strings.Cut(body, ".")
+	if strings.Contains(footer, ".") {
+		return nil, nil, ErrPasetoFormat
+	}
+	m, err := pasetoB64.DecodeString(payload)
+	if err != nil {
+		return nil, nil, ErrPasetoFormat
+	}
+	var f []byte
+	if hasFooter {
+		if f, err = pasetoB64.DecodeString(footer); err != nil {
+			return nil, nil, ErrPasetoFormat
+		}
+	}
+	return m, f, nil
+}
+
+func pasetoJoin(header string, payload, footer []byte) string {
+	token := header + pasetoB64.EncodeToString(payload)
+	if len(footer) > 0 {
+		token += "." + pasetoB64.EncodeToString(footer)
+	}
+	return token
+}
+
+// pasetoLocalKeys 由密钥和nonce派生加密密钥 nonce和认证密钥
+func pasetoLocalKeys(key, nonce []byte) (ek, n2, ak []byte) {
+	h, _ := blake2b.New(56, key)
+	h.Write([]byte("paseto-encryption-key"))
+	h.Write(nonce)
+	tmp := h.Sum(nil)
+	h, _ = blake2b.New(32, key)
+	h.Write([]byte("paseto-auth-key-for-aead"))
+	h.Write(nonce)
+	return tmp[:32], tmp[32:], h.Sum(nil)
+}
+
+func pasetoMac(ak []byte, pieces ...[]byte) []byte {
+	h, _ := blake2b.New(pasetoMacSize, ak)
+	h.Write(pae(pieces...))
+	return h.Sum(nil)
+}
+
+func pasetoEncrypt(key, msg, footer, implicit []byte, random io.Reader) (string, error) {
+	nonce := make([]byte, pasetoNonceSize)
+	if _, err := io.ReadFull(random, nonce); err != nil {
+		return "", err
+	}
+	ek, n2, ak := pasetoLocalKeys(key, nonce)
+	c, err := chacha20.NewUnauthenticatedCipher(ek, n2)
+	if err != nil {
+		return "", err
+	}
+	ct := make([]byte, len(msg))
+	c.XORKeyStream(ct, msg)
+	tag := pasetoMac(ak, []byte(pasetoLocalHeader), nonce, ct, footer, implicit)
+	payload := append(append(nonce, ct...), tag...)
+	return pasetoJoin(pasetoLocalHeader, payload, footer), nil
+}
+
+func pasetoDecrypt(key []byte, token string, implicit []byte) ([]byte, []byte, error) {
+	payload, footer, err := pasetoSplit(token[len(pasetoLocalHeader):])
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(payload) < pasetoNonceSize+pasetoMacSize {
+		return nil, nil, ErrPasetoFormat
+	}
+	nonce := payload[:pasetoNonceSize]
+	ct := payload[pasetoNonceSize : len(payload)-pasetoMacSize]
+	tag := payload[len(payload)-pasetoMacSize:]
+	ek, n2, ak := pasetoLocalKeys(key, nonce)
+	if subtle.ConstantTimeCompare(tag, pasetoMac(ak, []byte(pasetoLocalHeader), nonce, ct, footer, implicit)) != 1 {
+		return nil, nil, ErrPasetoVerify
+	}
+	c, err := chacha20.NewUnauthenticatedCipher(ek, n2)
+	if err != nil {
+		return nil, nil, err
+	}
+	msg := make([]byte, len(ct))
+	c.XORKeyStream(msg, ct)
+	return msg, footer, nil
+}
+
+func pasetoSign(priv ed25519.PrivateKey, msg, footer, implicit []byte) string {
+	sig := ed25519.Sign(priv, pae([]byte(pasetoPublicHeader), msg, footer, implicit))
+	return pasetoJoin(pasetoPublicHeader, append(append([]byte{}, msg...), sig...), footer)
+}
+
+func pasetoVerify(pub ed25519.PublicKey, token string, implicit []byte) ([]byte, []byte, error) {
+	payload, footer, err := pasetoSplit(token[len(pasetoPublicHeader):])
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(payload) < ed25519.SignatureSize {
+		return nil, nil, ErrPasetoFormat
+	}
+	msg := payload[:len(payload)-ed25519.SignatureSize]
+	sig := payload[len(payload)-ed25519.SignatureSize:]
+	if !ed25519.Verify(pub, pae([]byte(pasetoPublicHeader), msg, footer, implicit), sig) {
+		return nil, nil, ErrPasetoVerify
+	}
+	return msg, footer, nil
+}
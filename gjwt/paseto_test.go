@@ -0,0 +1,94 @@
+package gjwt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// PASETO v4 官方测试向量 4-E-1 4-S-1
+func TestPasetoVectors(t *testing.T) {
+	key, _ := hex.DecodeString("707172737475767778797a7b7c7d7e7f808182838485868788898a8b8c8d8e8f")
+	msg := []byte(`{"data":"this is a secret message","exp":"2022-01-01T00:00:00+00:00"}`)
+	want := "v4.local.AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAQAr68PS4AXe7If_ZgesdkUMvSwscFlAl1pk5HC0e8kApeaqMfGo_7OpBnwJOAbY9V7WU6abu74MmcUE8YWAiaArVI8XJ5hOb_4v9RmDkneN0S92dx0OW4pgy7omxgf3S8c3LlQg"
+	token, err := pasetoEncrypt(key, msg, nil, nil, bytes.NewReader(make([]byte, pasetoNonceSize)))
+	if err != nil || token != want {
+		t.Fatalf("4-E-1: %s %v", token, err)
+	}
+	if got, _, err := pasetoDecrypt(key, want, nil); err != nil || !bytes.Equal(got, msg) {
+		t.Fatalf("4-E-1 decrypt: %v", err)
+	}
+
+	sk, _ := hex.DecodeString("b4cbfb43df4ce210727d953e4a713307fa19bb7d9f85041438d9e11b942a37741eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2")
+	msg = []byte(`{"data":"this is a signed message","exp":"2022-01-01T00:00:00+00:00"}`)
+	want = "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9bg_XBBzds8lTZShVlwwKSgeKpLT3yukTw6JUz3W4h_ExsQV-P0V54zemZDcAxFaSeef1QlXEFtkqxT1ciiQEDA"
+	if token := pasetoSign(sk, msg, nil, nil); token != want {
+		t.Fatalf("4-S-1: %s", token)
+	}
+	if got, _, err := pasetoVerify(sk[32:], want, nil); err != nil || !bytes.Equal(got, msg) {
+		t.Fatalf("4-S-1 verify: %v", err)
+	}
+}
+
+func TestPaseto(t *testing.T) {
+	pub, pri, err := Gen.GenED25519()
+	if err != nil {
+		t.Fatal(err)
+	}
+	local, err := Gen.GenHmac(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tool := NewPaseto()
+	if err := tool.SetLocalKey(local); err != nil {
+		t.Fatal(err)
+	}
+	tool.SetSecretPub(pub)
+	tool.SetSecretPriv(pri)
+
+	footer := []byte(`{"kid":"k1"}`)
+	implicit := []byte("tenant-7")
+	for _, purpose := range []PasetoPurpose{PasetoLocal, PasetoPublic} {
+		token, err := tool.SignData(purpose, map[string]string{"uid": "42"}, time.Minute,
+			WithFooter(footer), WithImplicit(implicit), WithPasetoClaims(WithSubject("42"), WithAudience("api")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(token, "v4."+string(purpose)+".") {
+			t.Fatalf("%s: header %s", purpose, token)
+		}
+		if f, err := tool.Footer(token); err != nil || !bytes.Equal(f, footer) {
+			t.Fatalf("%s: footer %v", purpose, err)
+		}
+		var data map[string]string
+		if _, err := tool.VerifyData(token, &data, WithFooter(footer), WithImplicit(implicit)); err != nil || data["uid"] != "42" {
+			t.Fatalf("%s: %v", purpose, err)
+		}
+		claims := &PasetoClaims{}
+		if _, err := tool.Verify(token, claims, WithImplicit(implicit)); err != nil || claims.Subject != "42" || claims.Audience != "api" {
+			t.Fatalf("%s: claims %v %+v", purpose, err, claims)
+		}
+		if _, err := tool.VerifyData(token, nil, WithImplicit([]byte("tenant-8"))); !errors.Is(err, ErrPasetoVerify) {
+			t.Fatalf("%s: implicit not bound: %v", purpose, err)
+		}
+		if _, err := tool.VerifyData(token, nil, WithImplicit(implicit), WithFooter([]byte("other"))); !errors.Is(err, ErrPasetoFooter) {
+			t.Fatalf("%s: footer not checked: %v", purpose, err)
+		}
+		head, body, _ := strings.Cut(token[3:], ".")
+		tampered := token[:3] + head + "." + body[:10] + string(body[10]^1) + body[11:]
+		if _, err := tool.Verify(tampered, nil, WithImplicit(implicit)); err == nil {
+			t.Fatalf("%s: tampered token accepted", purpose)
+		}
+
+		expired, _ := tool.SignData(purpose, nil, -time.Minute)
+		if _, err := tool.VerifyData(expired, nil); !errors.Is(err, ErrPasetoExpired) {
+			t.Fatalf("%s: exp not checked: %v", purpose, err)
+		}
+		if _, err := tool.VerifyData(expired, nil, WithPasetoLeeway(2*time.Minute)); err != nil {
+			t.Fatalf("%s: leeway not applied: %v", purpose, err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package hdwallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/fbsobreira/gotron-sdk/pkg/address"
+	"github.com/shengdoushi/base58"
+)
+
+// BtcNet holds the address prefixes of a bitcoin network.
+type BtcNet struct {
+	PubKeyHashAddrID byte
+	ScriptHashAddrID byte
+	Bech32HRP        string
+}
+
+var (
+	BtcMainnet = BtcNet{PubKeyHashAddrID: 0x00, ScriptHashAddrID: 0x05, Bech32HRP: "bc"}
+	BtcTestnet = BtcNet{PubKeyHashAddrID: 0x6f, ScriptHashAddrID: 0xc4, Bech32HRP: "tb"}
+)
+
+// BtcAddressType selects the bitcoin output script.
+type BtcAddressType string
+
+const (
+	P2PKH      BtcAddressType = "p2pkh"       // 1... legacy, BIP44
+	P2SHP2WPKH BtcAddressType = "p2sh-p2wpkh" // 3... nested segwit, BIP49
+	P2WPKH     BtcAddressType = "p2wpkh"      // bc1q... native segwit, BIP84
+	P2TR       BtcAddressType = "p2tr"        // bc1p... taproot key path, BIP86
+)
+
+// ErrUnknownAddressType is returned for unsupported BtcAddressType values.
+var ErrUnknownAddressType = errors.New("Unknown address type")
+
+// EthAddress returns the EIP-55 checksummed address used by Ethereum, BSC
+// and Arbitrum.
+func EthAddress(pub *ecdsa.PublicKey) string {
+	return crypto.PubkeyToAddress(*pub).Hex()
+}
+
+// TronAddress returns the base58check TRON address (T...).
+func TronAddress(pub *ecdsa.PublicKey) string {
+	return address.PubkeyToAddress(*pub).String()
+}
+
+// BtcAddress encodes the compressed public key as the given address type.
+func BtcAddress(pub *ecdsa.PublicKey, addrType BtcAddressType, net BtcNet) (string, error) {
+	compressed := crypto.CompressPubkey(pub)
+	switch addrType {
+	case P2PKH:
+		return base58Check(net.PubKeyHashAddrID, hash160(compressed)), nil
+	case P2SHP2WPKH:
+		redeemScript := append([]byte{0x00, 0x14}, hash160(compressed)...)
+		return base58Check(net.ScriptHashAddrID, hash160(redeemScript)), nil
+	case P2WPKH:
+		return encodeSegwit(net.Bech32HRP, 0, hash160(compressed))
+	case P2TR:
+		return encodeSegwit(net.Bech32HRP, 1, taprootOutputKey(pub))
+	}
+	return "", ErrUnknownAddressType
+}
+
+func base58Check(version byte, payload []byte) string {
+	buf := append([]byte{version}, payload...)
+	buf = append(buf, checksum(buf)...)
+	return base58.Encode(buf, base58.BitcoinAlphabet)
+}
+
+// taprootOutputKey tweaks the internal key without a script tree as in
+// BIP86 and returns the 32 byte x-only output key.
+func taprootOutputKey(pub *ecdsa.PublicKey) []byte {
+	x, y := pub.X, pub.Y
+	// BIP340 keys are x-only with an implicit even y.
+	if y.Bit(0) == 1 {
+		y = new(big.Int).Sub(curve.Params().P, y)
+	}
+	xBytes := padTo32(x.Bytes())
+	tweak := taggedHash("TapTweak", xBytes)
+	tx, ty := curve.ScalarBaseMult(tweak)
+	qx, _ := curve.Add(x, y, tx, ty)
+	return padTo32(qx.Bytes())
+}
+
+func taggedHash(tag string, msg []byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	h.Write(msg)
+	return h.Sum(nil)
+}
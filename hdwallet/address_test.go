@@ -0,0 +1,64 @@
+package hdwallet
+
+import "testing"
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+// Vectors from BIP44/49/84/86 and common wallet implementations.
+func TestAddresses(t *testing.T) {
+	master, err := NewMasterKeyFromMnemonic(testMnemonic, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		addr func(k *Key) (string, error)
+		want string
+	}{
+		{"m/44'/60'/0'/0/0", ethAddr, "0x9858EfFD232B4033E47d90003D41EC34EcaEda94"},
+		{"m/44'/195'/0'/0/0", tronAddr, "TUEZSdKsoDHQMeZwihtdoBiN46zxhGWYdH"},
+		{"m/44'/0'/0'/0/0", btcAddr(P2PKH), "1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA"},
+		{"m/49'/0'/0'/0/0", btcAddr(P2SHP2WPKH), "37VucYSaXLCAsxYyAPfbSi9eh4iEcbShgf"},
+		{"m/84'/0'/0'/0/0", btcAddr(P2WPKH), "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"},
+		{"m/86'/0'/0'/0/0", btcAddr(P2TR), "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr"},
+	}
+	for _, test := range tests {
+		key, err := master.Derive(test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := test.addr(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Fatalf("%s: %s != %s", test.path, got, test.want)
+		}
+	}
+}
+
+func ethAddr(k *Key) (string, error) {
+	pub, err := k.ECDSAPublicKey()
+	if err != nil {
+		return "", err
+	}
+	return EthAddress(pub), nil
+}
+
+func tronAddr(k *Key) (string, error) {
+	pub, err := k.ECDSAPublicKey()
+	if err != nil {
+		return "", err
+	}
+	return TronAddress(pub), nil
+}
+
+func btcAddr(addrType BtcAddressType) func(k *Key) (string, error) {
+	return func(k *Key) (string, error) {
+		pub, err := k.ECDSAPublicKey()
+		if err != nil {
+			return "", err
+		}
+		return BtcAddress(pub, addrType, BtcMainnet)
+	}
+}
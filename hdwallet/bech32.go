@@ -0,0 +1,96 @@
+package hdwallet
+
+import (
+	"errors"
+	"strings"
+)
+
+// bech32 (BIP173) and bech32m (BIP350) encoding of segwit addresses.
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+const (
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+// ErrInvalidWitness is returned for witness programs that can not be encoded.
+var ErrInvalidWitness = errors.New("Invalid witness program")
+
+func bech32Polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32HrpExpand(hrp string) []byte {
+	out := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]>>5)
+	}
+	out = append(out, 0)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]&31)
+	}
+	return out
+}
+
+func bech32Encode(hrp string, data []byte, constant uint32) string {
+	values := append(bech32HrpExpand(hrp), data...)
+	values = append(values, 0, 0, 0, 0, 0, 0)
+	mod := bech32Polymod(values) ^ constant
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, d := range data {
+		sb.WriteByte(bech32Charset[d])
+	}
+	for i := 0; i < 6; i++ {
+		sb.WriteByte(bech32Charset[(mod>>uint(5*(5-i)))&31])
+	}
+	return sb.String()
+}
+
+// convertBits regroups 8 bit bytes into 5 bit groups with padding.
+func convertBits(data []byte, fromBits, toBits uint) []byte {
+	var acc, bits uint
+	maxv := uint(1)<<toBits - 1
+	out := make([]byte, 0, len(data)*int(fromBits)/int(toBits)+1)
+	for _, b := range data {
+		acc = acc<<fromBits | uint(b)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			out = append(out, byte(acc>>bits&maxv))
+		}
+	}
+	if bits > 0 {
+		out = append(out, byte(acc<<(toBits-bits)&maxv))
+	}
+	return out
+}
+
+// encodeSegwit encodes a witness program; version 0 uses bech32, 1+ bech32m.
+func encodeSegwit(hrp string, version byte, program []byte) (string, error) {
+	if version > 16 || len(program) < 2 || len(program) > 40 {
+		return "", ErrInvalidWitness
+	}
+	if version == 0 && len(program) != 20 && len(program) != 32 {
+		return "", ErrInvalidWitness
+	}
+	constant := uint32(bech32Const)
+	if version > 0 {
+		constant = bech32mConst
+	}
+	data := append([]byte{version}, convertBits(program, 8, 5)...)
+	return bech32Encode(hrp, data, constant), nil
+}
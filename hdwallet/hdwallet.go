@@ -0,0 +1,333 @@
+// Package hdwallet is the Golang implementation of BIP32 hierarchical
+// deterministic keys on secp256k1, with BIP44 style path derivation.
+//
+// The official BIP32 spec can be found at
+// https://github.com/bitcoin/bips/blob/master/bip-0032.mediawiki
+package hdwallet
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/shengdoushi/base58"
+	"github.com/suiguo/hwlib/bip39"
+	"golang.org/x/crypto/ripemd160"
+)
+
+// HardenedOffset is the index from which child keys are hardened.
+const HardenedOffset uint32 = 0x80000000
+
+const (
+	// serializedKeyLen is the length of a decoded xprv/xpub without checksum.
+	serializedKeyLen = 78
+
+	minSeedLen = 16
+	maxSeedLen = 64
+)
+
+var (
+	// Version bytes of serialized extended keys.
+	MainnetPrivate = [4]byte{0x04, 0x88, 0xad, 0xe4} // xprv
+	MainnetPublic  = [4]byte{0x04, 0x88, 0xb2, 0x1e} // xpub
+	TestnetPrivate = [4]byte{0x04, 0x35, 0x83, 0x94} // tprv
+	TestnetPublic  = [4]byte{0x04, 0x35, 0x87, 0xcf} // tpub
+
+	masterKey = []byte("Bitcoin seed")
+	curve     = crypto.S256()
+)
+
+var (
+	// ErrInvalidSeed is returned when the seed is not between 128 and 512 bits.
+	ErrInvalidSeed = errors.New("Seed length must be between 128 and 512 bits")
+
+	// ErrInvalidKey is returned when a derived key is zero or not less than the
+	// curve order. Callers should skip to the next index.
+	ErrInvalidKey = errors.New("Derived key is invalid")
+
+	// ErrHardenedFromPublic is returned when deriving a hardened child from a
+	// public extended key.
+	ErrHardenedFromPublic = errors.New("Cannot derive a hardened key from a public key")
+
+	// ErrDepthExceeded is returned when deriving past depth 255.
+	ErrDepthExceeded = errors.New("Depth can not be larger than 255")
+
+	// ErrInvalidExtendedKey is returned when an xprv/xpub string is malformed.
+	ErrInvalidExtendedKey = errors.New("Invalid extended key")
+
+	// ErrChecksumMismatch is returned when the base58check checksum is wrong.
+	ErrChecksumMismatch = errors.New("Extended key checksum mismatch")
+
+	// ErrUnknownVersion is returned when neutering a key with unknown version bytes.
+	ErrUnknownVersion = errors.New("Unknown extended key version")
+)
+
+// Key is a BIP32 extended key, either private or public.
+type Key struct {
+	Version     [4]byte
+	Depth       byte
+	ParentFP    [4]byte
+	ChildNumber uint32
+	ChainCode   []byte
+	// Key is the 32 byte private key or the 33 byte compressed public key.
+	Key       []byte
+	IsPrivate bool
+}
+
+// NewMasterKey creates the master extended private key from a seed, such as
+// the 64 byte output of bip39.NewSeed.
+func NewMasterKey(seed []byte) (*Key, error) {
+	if len(seed) < minSeedLen || len(seed) > maxSeedLen {
+		return nil, ErrInvalidSeed
+	}
+	mac := hmac.New(sha512.New, masterKey)
+	_, _ = mac.Write(seed)
+	I := mac.Sum(nil)
+
+	keyInt := new(big.Int).SetBytes(I[:32])
+	if keyInt.Sign() == 0 || keyInt.Cmp(curve.Params().N) >= 0 {
+		return nil, ErrInvalidKey
+	}
+
+	return &Key{
+		Version:   MainnetPrivate,
+		ChainCode: I[32:],
+		Key:       I[:32],
+		IsPrivate: true,
+	}, nil
+}
+
+// NewMasterKeyFromMnemonic validates the mnemonic and creates the master key
+// from its bip39 seed.
+func NewMasterKeyFromMnemonic(mnemonic string, password string) (*Key, error) {
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, password)
+	if err != nil {
+		return nil, err
+	}
+	return NewMasterKey(seed)
+}
+
+// Child derives the child key at index. Indexes from HardenedOffset on are
+// hardened and require a private key.
+func (k *Key) Child(index uint32) (*Key, error) {
+	if k.Depth == 255 {
+		return nil, ErrDepthExceeded
+	}
+	hardened := index >= HardenedOffset
+	if hardened && !k.IsPrivate {
+		return nil, ErrHardenedFromPublic
+	}
+
+	pub := k.PublicKeyBytes()
+	var data []byte
+	if hardened {
+		data = append([]byte{0x0}, k.Key...)
+	} else {
+		data = append([]byte{}, pub...)
+	}
+	var indexBytes [4]byte
+	binary.BigEndian.PutUint32(indexBytes[:], index)
+	data = append(data, indexBytes[:]...)
+
+	mac := hmac.New(sha512.New, k.ChainCode)
+	_, _ = mac.Write(data)
+	I := mac.Sum(nil)
+	IL, IR := I[:32], I[32:]
+
+	ilInt := new(big.Int).SetBytes(IL)
+	if ilInt.Cmp(curve.Params().N) >= 0 {
+		return nil, ErrInvalidKey
+	}
+
+	child := &Key{
+		Version:     k.Version,
+		Depth:       k.Depth + 1,
+		ChildNumber: index,
+		ChainCode:   IR,
+		IsPrivate:   k.IsPrivate,
+	}
+	copy(child.ParentFP[:], hash160(pub)[:4])
+
+	if k.IsPrivate {
+		keyInt := new(big.Int).Add(ilInt, new(big.Int).SetBytes(k.Key))
+		keyInt.Mod(keyInt, curve.Params().N)
+		if keyInt.Sign() == 0 {
+			return nil, ErrInvalidKey
+		}
+		child.Key = padTo32(keyInt.Bytes())
+		return child, nil
+	}
+
+	parent, err := crypto.DecompressPubkey(k.Key)
+	if err != nil {
+		return nil, err
+	}
+	x, y := curve.ScalarBaseMult(IL)
+	x, y = curve.Add(x, y, parent.X, parent.Y)
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, ErrInvalidKey
+	}
+	child.Key = crypto.CompressPubkey(&ecdsa.PublicKey{Curve: curve, X: x, Y: y})
+	return child, nil
+}
+
+// Derive walks a derivation path such as "m/44'/60'/0'/0/5" from this key.
+// A leading "m" is only meaningful on a master key; relative paths such as
+// "0/5" are accepted on any key.
+func (k *Key) Derive(path string) (*Key, error) {
+	indexes, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	key := k
+	for _, index := range indexes {
+		key, err = key.Child(index)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// Neuter returns the public extended key of k.
+func (k *Key) Neuter() (*Key, error) {
+	if !k.IsPrivate {
+		return k, nil
+	}
+	var version [4]byte
+	switch k.Version {
+	case MainnetPrivate:
+		version = MainnetPublic
+	case TestnetPrivate:
+		version = TestnetPublic
+	default:
+		return nil, ErrUnknownVersion
+	}
+	return &Key{
+		Version:     version,
+		Depth:       k.Depth,
+		ParentFP:    k.ParentFP,
+		ChildNumber: k.ChildNumber,
+		ChainCode:   k.ChainCode,
+		Key:         k.PublicKeyBytes(),
+	}, nil
+}
+
+// PublicKeyBytes returns the 33 byte compressed public key.
+func (k *Key) PublicKeyBytes() []byte {
+	if !k.IsPrivate {
+		return k.Key
+	}
+	x, y := curve.ScalarBaseMult(k.Key)
+	return crypto.CompressPubkey(&ecdsa.PublicKey{Curve: curve, X: x, Y: y})
+}
+
+// ECDSAPublicKey returns the public key for signing and address generation.
+func (k *Key) ECDSAPublicKey() (*ecdsa.PublicKey, error) {
+	return crypto.DecompressPubkey(k.PublicKeyBytes())
+}
+
+// ECDSAPrivateKey returns the private key. It fails on public extended keys.
+func (k *Key) ECDSAPrivateKey() (*ecdsa.PrivateKey, error) {
+	if !k.IsPrivate {
+		return nil, errors.New("Not a private key")
+	}
+	return crypto.ToECDSA(k.Key)
+}
+
+// Fingerprint is the first 4 bytes of the key identifier.
+func (k *Key) Fingerprint() [4]byte {
+	var fp [4]byte
+	copy(fp[:], hash160(k.PublicKeyBytes())[:4])
+	return fp
+}
+
+// String serializes the key in base58check, e.g. xprv... or xpub...
+func (k *Key) String() string {
+	buf := make([]byte, 0, serializedKeyLen+4)
+	buf = append(buf, k.Version[:]...)
+	buf = append(buf, k.Depth)
+	buf = append(buf, k.ParentFP[:]...)
+	var childBytes [4]byte
+	binary.BigEndian.PutUint32(childBytes[:], k.ChildNumber)
+	buf = append(buf, childBytes[:]...)
+	buf = append(buf, k.ChainCode...)
+	if k.IsPrivate {
+		buf = append(buf, 0x0)
+		buf = append(buf, padTo32(k.Key)...)
+	} else {
+		buf = append(buf, k.Key...)
+	}
+	buf = append(buf, checksum(buf)...)
+	return base58.Encode(buf, base58.BitcoinAlphabet)
+}
+
+// ParseKey parses a base58check serialized extended key (xprv/xpub/tprv/tpub).
+func ParseKey(s string) (*Key, error) {
+	buf, err := base58.Decode(s, base58.BitcoinAlphabet)
+	if err != nil || len(buf) != serializedKeyLen+4 {
+		return nil, ErrInvalidExtendedKey
+	}
+	payload, sum := buf[:serializedKeyLen], buf[serializedKeyLen:]
+	if !bytes.Equal(checksum(payload), sum) {
+		return nil, ErrChecksumMismatch
+	}
+
+	k := &Key{
+		Depth:       payload[4],
+		ChildNumber: binary.BigEndian.Uint32(payload[9:13]),
+		ChainCode:   append([]byte{}, payload[13:45]...),
+	}
+	copy(k.Version[:], payload[:4])
+	copy(k.ParentFP[:], payload[5:9])
+
+	keyData := payload[45:]
+	switch k.Version {
+	case MainnetPrivate, TestnetPrivate:
+		if keyData[0] != 0x0 {
+			return nil, ErrInvalidExtendedKey
+		}
+		keyInt := new(big.Int).SetBytes(keyData[1:])
+		if keyInt.Sign() == 0 || keyInt.Cmp(curve.Params().N) >= 0 {
+			return nil, ErrInvalidKey
+		}
+		k.Key = append([]byte{}, keyData[1:]...)
+		k.IsPrivate = true
+	case MainnetPublic, TestnetPublic:
+		if _, err := crypto.DecompressPubkey(keyData); err != nil {
+			return nil, ErrInvalidExtendedKey
+		}
+		k.Key = append([]byte{}, keyData...)
+	default:
+		return nil, ErrUnknownVersion
+	}
+	return k, nil
+}
+
+func hash160(data []byte) []byte {
+	sha := sha256.Sum256(data)
+	hasher := ripemd160.New()
+	_, _ = hasher.Write(sha[:])
+	return hasher.Sum(nil)
+}
+
+func checksum(data []byte) []byte {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	return second[:4]
+}
+
+func padTo32(b []byte) []byte {
+	if len(b) >= 32 {
+		return b
+	}
+	out := make([]byte, 32)
+	copy(out[32-len(b):], b)
+	return out
+}
@@ -0,0 +1,120 @@
+package hdwallet
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+// BIP32 test vector 1.
+func TestVector1(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, err := NewMasterKey(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		xprv string
+		xpub string
+	}{
+		{
+			"m",
+			"xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi",
+			"xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8",
+		},
+		{
+			"m/0'",
+			"xprv9uHRZZhk6KAJC1avXpDAp4MDc3sQKNxDiPvvkX8Br5ngLNv1TxvUxt4cV1rGL5hj6KCesnDYUhd7oWgT11eZG7XnxHrnYeSvkzY7d2bhkJ7",
+			"xpub68Gmy5EdvgibQVfPdqkBBCHxA5htiqg55crXYuXoQRKfDBFA1WEjWgP6LHhwBZeNK1VTsfTFUHCdrfp1bgwQ9xv5ski8PX9rL2dZXvgGDnw",
+		},
+		{
+			"m/0'/1/2'/2/1000000000",
+			"xprvA41z7zogVVwxVSgdKUHDy1SKmdb533PjDz7J6N6mV6uS3ze1ai8FHa8kmHScGpWmj4WggLyQjgPie1rFSruoUihUZREPSL39UNdE3BBDu76",
+			"xpub6H1LXWLaKsWFhvm6RVpEL9P4KfRZSW7abD2ttkWP3SSQvnyA8FSVqNTEcYFgJS2UaFcxupHiYkro49S8yGasTvXEYBVPamhGW6cFJodrTHy",
+		},
+	}
+	for _, test := range tests {
+		key, err := master.Derive(test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key.String() != test.xprv {
+			t.Fatalf("%s: xprv %s != %s", test.path, key.String(), test.xprv)
+		}
+		pub, err := key.Neuter()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pub.String() != test.xpub {
+			t.Fatalf("%s: xpub %s != %s", test.path, pub.String(), test.xpub)
+		}
+		parsed, err := ParseKey(test.xprv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed.String() != test.xprv {
+			t.Fatalf("%s: parse round trip failed", test.path)
+		}
+	}
+}
+
+// Non-hardened children of an xpub must match the public keys of the
+// corresponding private children.
+func TestPublicDerivation(t *testing.T) {
+	seed, _ := hex.DecodeString("fffcf9f6f3f0edeae7e4e1dedbd8d5d2cfccc9c6c3c0bdbab7b4b1aeaba8a5a29f9c999693908d8a8784817e7b7875726f6c696663605d5a5754514e4b484542")
+	master, err := NewMasterKey(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := master.Derive("m/44'/60'/0'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	xpub, err := account.Neuter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseKey(xpub.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := uint32(0); i < 5; i++ {
+		path := BIP44Path(CoinTypeETH, 0, 0, i)
+		priv, err := master.Derive(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub, err := parsed.Derive(fmt.Sprintf("0/%d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(priv.PublicKeyBytes()) != hex.EncodeToString(pub.PublicKeyBytes()) {
+			t.Fatalf("%s: public derivation mismatch", path)
+		}
+	}
+	if _, err := parsed.Child(HardenedOffset); err != ErrHardenedFromPublic {
+		t.Fatal("hardened derivation from xpub should fail")
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	indexes, err := ParsePath("m/44'/60h/0H/0/5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []uint32{44 + HardenedOffset, 60 + HardenedOffset, HardenedOffset, 0, 5}
+	if len(indexes) != len(want) {
+		t.Fatalf("got %v", indexes)
+	}
+	for i := range want {
+		if indexes[i] != want[i] {
+			t.Fatalf("got %v", indexes)
+		}
+	}
+	for _, bad := range []string{"m//0", "m/a", "m/2147483648", "m/0''"} {
+		if _, err := ParsePath(bad); err == nil {
+			t.Fatalf("%s should be invalid", bad)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package hdwallet
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Registered SLIP-44 coin types used in BIP44 paths.
+const (
+	CoinTypeBTC  uint32 = 0
+	CoinTypeETH  uint32 = 60
+	CoinTypeTRON uint32 = 195
+)
+
+// ErrInvalidPath is returned when a derivation path can not be parsed.
+var ErrInvalidPath = errors.New("Invalid derivation path")
+
+// ParsePath parses a derivation path like "m/44'/60'/0'/0/5" into child
+// indexes. Hardened components may be marked with ', h or H.
+func ParsePath(path string) ([]uint32, error) {
+	path = strings.TrimSpace(path)
+	parts := strings.Split(path, "/")
+	if len(parts) > 0 && (parts[0] == "m" || parts[0] == "M") {
+		parts = parts[1:]
+	}
+	indexes := make([]uint32, 0, len(parts))
+	for _, part := range parts {
+		if part == "" {
+			return nil, ErrInvalidPath
+		}
+		var offset uint32
+		if last := part[len(part)-1]; last == '\'' || last == 'h' || last == 'H' {
+			offset = HardenedOffset
+			part = part[:len(part)-1]
+		}
+		index, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || uint32(index) >= HardenedOffset {
+			return nil, ErrInvalidPath
+		}
+		indexes = append(indexes, uint32(index)+offset)
+	}
+	return indexes, nil
+}
+
+// BIP44Path formats m/44'/coin'/account'/change/index.
+func BIP44Path(coinType, account, change, index uint32) string {
+	return fmt.Sprintf("m/44'/%d'/%d'/%d/%d", coinType, account, change, index)
+}
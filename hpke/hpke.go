@@ -0,0 +1,279 @@
+// Package hpke is the Golang implementation of Hybrid Public Key Encryption
+// (RFC 9180) with DHKEM(X25519) and DHKEM(P-256), HKDF-SHA256 and the
+// AES-GCM and ChaCha20-Poly1305 AEADs, in base and auth modes.
+//
+// EnTool and DeTool wrap it in the ecies.EncryptTool and ecies.DecryptTool
+// interfaces, choosing the KEM from the type prefix of the key string.
+//
+// The RFC can be found at https://www.rfc-editor.org/rfc/rfc9180
+package hpke
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+// KDF identifies a key derivation function.
+type KDF uint16
+
+// AEAD identifies an authenticated encryption algorithm.
+type AEAD uint16
+
+const (
+	HKDFSHA256 KDF = 0x0001
+
+	AES128GCM        AEAD = 0x0001
+	AES256GCM        AEAD = 0x0002
+	ChaCha20Poly1305 AEAD = 0x0003
+)
+
+const (
+	modeBase byte = 0x00
+	modeAuth byte = 0x02
+
+	versionLabel = "HPKE-v1"
+)
+
+var (
+	ErrUnknownKDF     = errors.New("hpke: unknown kdf")
+	ErrUnknownAEAD    = errors.New("hpke: unknown aead")
+	ErrOpen           = errors.New("hpke: message authentication failed")
+	ErrSeqOverflow    = errors.New("hpke: message limit reached")
+	ErrExportTooLarge = errors.New("hpke: export length too large")
+)
+
+// Suite is a KEM, KDF and AEAD combination.
+type Suite struct {
+	KEM  KEM
+	KDF  KDF
+	AEAD AEAD
+}
+
+func (s Suite) id() []byte {
+	id := []byte("HPKE")
+	id = binary.BigEndian.AppendUint16(id, uint16(s.KEM))
+	id = binary.BigEndian.AppendUint16(id, uint16(s.KDF))
+	return binary.BigEndian.AppendUint16(id, uint16(s.AEAD))
+}
+
+func (s Suite) check() (*dhkem, error) {
+	kem, err := s.KEM.dhkem()
+	if err != nil {
+		return nil, err
+	}
+	if s.KDF != HKDFSHA256 {
+		return nil, ErrUnknownKDF
+	}
+	if _, err := s.AEAD.keyLen(); err != nil {
+		return nil, err
+	}
+	return kem, nil
+}
+
+func (a AEAD) keyLen() (int, error) {
+	switch a {
+	case AES128GCM:
+		return 16, nil
+	case AES256GCM, ChaCha20Poly1305:
+		return 32, nil
+	}
+	return 0, ErrUnknownAEAD
+}
+
+func (a AEAD) new(key []byte) (cipher.AEAD, error) {
+	switch a {
+	case AES128GCM, AES256GCM:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	case ChaCha20Poly1305:
+		return chacha20poly1305.New(key)
+	}
+	return nil, ErrUnknownAEAD
+}
+
+// GenerateKeyPair returns a random key pair of the suite's KEM, serialized
+// as in the RFC: 32 byte private keys, 32 byte X25519 and 65 byte
+// uncompressed P-256 public keys.
+func (s Suite) GenerateKeyPair(rand io.Reader) (pub, priv []byte, err error) {
+	kem, err := s.KEM.dhkem()
+	if err != nil {
+		return nil, nil, err
+	}
+	priv, pub, err = kem.generateKeyPair(rand)
+	return pub, priv, err
+}
+
+// DeriveKeyPair deterministically derives a key pair from ikm.
+func (s Suite) DeriveKeyPair(ikm []byte) (pub, priv []byte, err error) {
+	kem, err := s.KEM.dhkem()
+	if err != nil {
+		return nil, nil, err
+	}
+	priv, pub, err = kem.deriveKeyPair(ikm)
+	return pub, priv, err
+}
+
+// SetupBaseS sets up a sender context for pkR. enc must be sent to the
+// receiver along with the ciphertexts.
+func (s Suite) SetupBaseS(rand io.Reader, pkR, info []byte) (enc []byte, ctx *Context, err error) {
+	return s.setupS(rand, modeBase, pkR, info, nil, nil)
+}
+
+// SetupBaseR sets up the receiver context matching SetupBaseS.
+func (s Suite) SetupBaseR(enc, skR, info []byte) (*Context, error) {
+	return s.setupR(modeBase, enc, skR, info, nil)
+}
+
+// SetupAuthS is SetupBaseS that also authenticates the sender key skS.
+func (s Suite) SetupAuthS(rand io.Reader, pkR, info, skS []byte) (enc []byte, ctx *Context, err error) {
+	return s.setupS(rand, modeAuth, pkR, info, skS, nil)
+}
+
+// SetupAuthR sets up the receiver context matching SetupAuthS, failing to
+// open anything not sent by the owner of pkS.
+func (s Suite) SetupAuthR(enc, skR, info, pkS []byte) (*Context, error) {
+	return s.setupR(modeAuth, enc, skR, info, pkS)
+}
+
+// setupS uses the ephemeral key skE when given, for the test vectors.
+func (s Suite) setupS(rand io.Reader, mode byte, pkR, info, skS, skE []byte) ([]byte, *Context, error) {
+	kem, err := s.check()
+	if err != nil {
+		return nil, nil, err
+	}
+	if skE == nil {
+		if skE, _, err = kem.generateKeyPair(rand); err != nil {
+			return nil, nil, err
+		}
+	}
+	shared, enc, err := kem.encap(pkR, skE, skS)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx, err := s.keySchedule(mode, shared, info)
+	if err != nil {
+		return nil, nil, err
+	}
+	return enc, ctx, nil
+}
+
+func (s Suite) setupR(mode byte, enc, skR, info, pkS []byte) (*Context, error) {
+	kem, err := s.check()
+	if err != nil {
+		return nil, err
+	}
+	shared, err := kem.decap(enc, skR, pkS)
+	if err != nil {
+		return nil, err
+	}
+	return s.keySchedule(mode, shared, info)
+}
+
+func (s Suite) keySchedule(mode byte, sharedSecret, info []byte) (*Context, error) {
+	suiteID := s.id()
+	// psk and psk_id are empty in base and auth modes.
+	pskIDHash := labeledExtract(suiteID, nil, "psk_id_hash", nil)
+	infoHash := labeledExtract(suiteID, nil, "info_hash", info)
+	ksContext := append([]byte{mode}, pskIDHash...)
+	ksContext = append(ksContext, infoHash...)
+
+	secret := labeledExtract(suiteID, sharedSecret, "secret", nil)
+	keyLen, _ := s.AEAD.keyLen()
+	key := labeledExpand(suiteID, secret, "key", ksContext, keyLen)
+	aead, err := s.AEAD.new(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Context{
+		suiteID:        suiteID,
+		aead:           aead,
+		baseNonce:      labeledExpand(suiteID, secret, "base_nonce", ksContext, aead.NonceSize()),
+		exporterSecret: labeledExpand(suiteID, secret, "exp", ksContext, sha256.Size),
+	}, nil
+}
+
+// Context is an encryption context set up by one of the Setup functions.
+// A sender context must only Seal and a receiver context only Open, in the
+// same order. It is not safe for concurrent use.
+type Context struct {
+	suiteID        []byte
+	aead           cipher.AEAD
+	baseNonce      []byte
+	exporterSecret []byte
+	seq            uint64
+}
+
+func (c *Context) nonce() ([]byte, error) {
+	if c.seq == ^uint64(0) {
+		return nil, ErrSeqOverflow
+	}
+	nonce := append([]byte{}, c.baseNonce...)
+	var seq [8]byte
+	binary.BigEndian.PutUint64(seq[:], c.seq)
+	for i := range seq {
+		nonce[len(nonce)-8+i] ^= seq[i]
+	}
+	return nonce, nil
+}
+
+// Seal encrypts the next message.
+func (c *Context) Seal(aad, pt []byte) ([]byte, error) {
+	nonce, err := c.nonce()
+	if err != nil {
+		return nil, err
+	}
+	ct := c.aead.Seal(nil, nonce, pt, aad)
+	c.seq++
+	return ct, nil
+}
+
+// Open decrypts the next message.
+func (c *Context) Open(aad, ct []byte) ([]byte, error) {
+	nonce, err := c.nonce()
+	if err != nil {
+		return nil, err
+	}
+	pt, err := c.aead.Open(nil, nonce, ct, aad)
+	if err != nil {
+		return nil, ErrOpen
+	}
+	c.seq++
+	return pt, nil
+}
+
+// Export derives a secret of length bytes bound to exporterContext. Sender
+// and receiver export the same value.
+func (c *Context) Export(exporterContext []byte, length int) ([]byte, error) {
+	if length > 255*sha256.Size {
+		return nil, ErrExportTooLarge
+	}
+	return labeledExpand(c.suiteID, c.exporterSecret, "sec", exporterContext, length), nil
+}
+
+func labeledExtract(suiteID, salt []byte, label string, ikm []byte) []byte {
+	labeled := append([]byte(versionLabel), suiteID...)
+	labeled = append(labeled, label...)
+	labeled = append(labeled, ikm...)
+	return hkdf.Extract(sha256.New, labeled, salt)
+}
+
+func labeledExpand(suiteID, prk []byte, label string, info []byte, length int) []byte {
+	labeled := binary.BigEndian.AppendUint16(nil, uint16(length))
+	labeled = append(labeled, versionLabel...)
+	labeled = append(labeled, suiteID...)
+	labeled = append(labeled, label...)
+	labeled = append(labeled, info...)
+	out := make([]byte, length)
+	_, _ = io.ReadFull(hkdf.Expand(sha256.New, prk, labeled), out)
+	return out
+}
@@ -0,0 +1,209 @@
+package hpke
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/suiguo/hwlib/ecies"
+)
+
+type hexBytes []byte
+
+func (h *hexBytes) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := hex.DecodeString(s)
+	*h = v
+	return err
+}
+
+// testdata/vectors.json holds the RFC 9180 test vectors of the supported
+// suites in base and auth mode.
+func TestVectors(t *testing.T) {
+	raw, err := os.ReadFile("testdata/vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors []struct {
+		Mode        byte     `json:"mode"`
+		KEM         KEM      `json:"kem_id"`
+		KDF         KDF      `json:"kdf_id"`
+		AEAD        AEAD     `json:"aead_id"`
+		Info        hexBytes `json:"info"`
+		IkmR        hexBytes `json:"ikmR"`
+		IkmS        hexBytes `json:"ikmS"`
+		SkRm        hexBytes `json:"skRm"`
+		SkSm        hexBytes `json:"skSm"`
+		SkEm        hexBytes `json:"skEm"`
+		PkRm        hexBytes `json:"pkRm"`
+		PkSm        hexBytes `json:"pkSm"`
+		Enc         hexBytes `json:"enc"`
+		Encryptions []struct {
+			Aad hexBytes `json:"aad"`
+			Ct  hexBytes `json:"ct"`
+			Pt  hexBytes `json:"pt"`
+		} `json:"encryptions"`
+		Exports []struct {
+			Context hexBytes `json:"exporter_context"`
+			L       int      `json:"L"`
+			Value   hexBytes `json:"exported_value"`
+		} `json:"exports"`
+	}
+	if err := json.Unmarshal(raw, &vectors); err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range vectors {
+		suite := Suite{KEM: v.KEM, KDF: v.KDF, AEAD: v.AEAD}
+		pkR, skR, err := suite.DeriveKeyPair(v.IkmR)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(pkR, v.PkRm) || !bytes.Equal(skR, v.SkRm) {
+			t.Fatalf("%d: derived key pair mismatch", i)
+		}
+		var skS, pkS []byte
+		if v.Mode == modeAuth {
+			skS, pkS = v.SkSm, v.PkSm
+		}
+		enc, sender, err := suite.setupS(nil, v.Mode, v.PkRm, v.Info, skS, v.SkEm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(enc, v.Enc) {
+			t.Fatalf("%d: enc mismatch", i)
+		}
+		receiver, err := suite.setupR(v.Mode, v.Enc, v.SkRm, v.Info, pkS)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j, e := range v.Encryptions {
+			ct, err := sender.Seal(e.Aad, e.Pt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(ct, e.Ct) {
+				t.Fatalf("%d/%d: ciphertext mismatch", i, j)
+			}
+			pt, err := receiver.Open(e.Aad, e.Ct)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(pt, e.Pt) {
+				t.Fatalf("%d/%d: plaintext mismatch", i, j)
+			}
+		}
+		for j, e := range v.Exports {
+			for _, ctx := range []*Context{sender, receiver} {
+				out, err := ctx.Export(e.Context, e.L)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(out, e.Value) {
+					t.Fatalf("%d/%d: exported value mismatch", i, j)
+				}
+			}
+		}
+	}
+}
+
+func TestTool(t *testing.T) {
+	msg := bytes.Repeat([]byte("hpke"), 40000)
+	for _, kem := range []KEM{DHKEMX25519, DHKEMP256} {
+		pub, pri, err := GenKey(kem)
+		if err != nil {
+			t.Fatal(err)
+		}
+		spub, spri, err := GenKey(kem)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, aead := range []AEAD{AES128GCM, AES256GCM, ChaCha20Poly1305} {
+			en, err := EnTool(pub, WithAEAD(aead), WithAuth(spri), WithInfo([]byte("test")))
+			if err != nil {
+				t.Fatal(err)
+			}
+			de, err := DeTool(pri, WithAuth(spub), WithInfo([]byte("test")))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ct, err := en.ECCEncrypt(msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pt, err := de.ECCDecrypt(ct)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(pt, msg) {
+				t.Fatal("plaintext mismatch")
+			}
+			base, _ := DeTool(pri, WithInfo([]byte("test")))
+			if _, err := base.ECCDecrypt(ct); err != ErrOpen {
+				t.Fatal("auth ciphertext opened in base mode")
+			}
+
+			var buf bytes.Buffer
+			w, err := en.ECCEncryptStream(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write(msg); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			stream := buf.Bytes()
+			r, err := de.ECCDecryptStream(bytes.NewReader(stream))
+			if err != nil {
+				t.Fatal(err)
+			}
+			pt, err = io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(pt, msg) {
+				t.Fatal("stream plaintext mismatch")
+			}
+			// Drop the final chunk.
+			cut := len(stream) - (len(msg) - 2*StreamChunkSize) - 16
+			r, err = de.ECCDecryptStream(bytes.NewReader(stream[:cut]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := io.ReadAll(r); !errors.Is(err, ErrStreamTruncate) {
+				t.Fatalf("truncated stream: %v", err)
+			}
+		}
+	}
+}
+
+func TestToolFallback(t *testing.T) {
+	pub, pri, err := ecies.GenKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	en, err := EnTool(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	de, err := DeTool(pri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct, err := en.ECCEncrypt([]byte("ecies"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt, err := de.ECCDecrypt(ct)
+	if err != nil || string(pt) != "ecies" {
+		t.Fatal("ecies fallback failed")
+	}
+}
@@ -0,0 +1,208 @@
+package hpke
+
+import (
+	"crypto/elliptic"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+// KEM identifies a key encapsulation mechanism.
+type KEM uint16
+
+const (
+	DHKEMP256   KEM = 0x0010 // DHKEM(P-256, HKDF-SHA256)
+	DHKEMX25519 KEM = 0x0020 // DHKEM(X25519, HKDF-SHA256)
+)
+
+var (
+	ErrUnknownKEM       = errors.New("hpke: unknown kem")
+	ErrInvalidPublicKey = errors.New("hpke: invalid public key")
+	ErrInvalidKey       = errors.New("hpke: invalid private key")
+	ErrDeriveKeyPair    = errors.New("hpke: derive key pair failed")
+)
+
+// dhkem is a Diffie-Hellman based KEM of section 4.1.
+type dhkem struct {
+	id      KEM
+	nsecret int
+	npk     int
+	nsk     int
+
+	dh          func(sk, pk []byte) ([]byte, error)
+	publicKey   func(sk []byte) ([]byte, error)
+	deriveKey   func(k *dhkem, ikm []byte) ([]byte, error)
+	validPublic func(pk []byte) bool
+}
+
+func (id KEM) dhkem() (*dhkem, error) {
+	switch id {
+	case DHKEMX25519:
+		return x25519KEM, nil
+	case DHKEMP256:
+		return p256KEM, nil
+	}
+	return nil, ErrUnknownKEM
+}
+
+func (k *dhkem) suiteID() []byte {
+	id := []byte("KEM")
+	return binary.BigEndian.AppendUint16(id, uint16(k.id))
+}
+
+// generateKeyPair returns a random private key and its public key.
+func (k *dhkem) generateKeyPair(rand io.Reader) (sk, pk []byte, err error) {
+	ikm := make([]byte, k.nsk)
+	if _, err := io.ReadFull(rand, ikm); err != nil {
+		return nil, nil, err
+	}
+	return k.deriveKeyPair(ikm)
+}
+
+func (k *dhkem) deriveKeyPair(ikm []byte) (sk, pk []byte, err error) {
+	sk, err = k.deriveKey(k, ikm)
+	if err != nil {
+		return nil, nil, err
+	}
+	pk, err = k.publicKey(sk)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sk, pk, nil
+}
+
+func (k *dhkem) extractAndExpand(dh, kemContext []byte) []byte {
+	prk := labeledExtract(k.suiteID(), nil, "eae_prk", dh)
+	return labeledExpand(k.suiteID(), prk, "shared_secret", kemContext, k.nsecret)
+}
+
+// encap produces the shared secret and encapsulation for pkR with the
+// ephemeral key skE. skS is the sender key in auth mode, nil otherwise.
+func (k *dhkem) encap(pkR, skE, skS []byte) (sharedSecret, enc []byte, err error) {
+	if !k.validPublic(pkR) {
+		return nil, nil, ErrInvalidPublicKey
+	}
+	enc, err = k.publicKey(skE)
+	if err != nil {
+		return nil, nil, err
+	}
+	dh, err := k.dh(skE, pkR)
+	if err != nil {
+		return nil, nil, err
+	}
+	kemContext := append(append([]byte{}, enc...), pkR...)
+	if skS != nil {
+		dhS, err := k.dh(skS, pkR)
+		if err != nil {
+			return nil, nil, err
+		}
+		pkS, err := k.publicKey(skS)
+		if err != nil {
+			return nil, nil, err
+		}
+		dh = append(dh, dhS...)
+		kemContext = append(kemContext, pkS...)
+	}
+	return k.extractAndExpand(dh, kemContext), enc, nil
+}
+
+// decap recovers the shared secret. pkS is the sender key in auth mode.
+func (k *dhkem) decap(enc, skR, pkS []byte) ([]byte, error) {
+	if !k.validPublic(enc) {
+		return nil, ErrInvalidPublicKey
+	}
+	dh, err := k.dh(skR, enc)
+	if err != nil {
+		return nil, err
+	}
+	pkR, err := k.publicKey(skR)
+	if err != nil {
+		return nil, err
+	}
+	kemContext := append(append([]byte{}, enc...), pkR...)
+	if pkS != nil {
+		if !k.validPublic(pkS) {
+			return nil, ErrInvalidPublicKey
+		}
+		dhS, err := k.dh(skR, pkS)
+		if err != nil {
+			return nil, err
+		}
+		dh = append(dh, dhS...)
+		kemContext = append(kemContext, pkS...)
+	}
+	return k.extractAndExpand(dh, kemContext), nil
+}
+
+var x25519KEM = &dhkem{
+	id:      DHKEMX25519,
+	nsecret: 32,
+	npk:     32,
+	nsk:     32,
+	dh: func(sk, pk []byte) ([]byte, error) {
+		// X25519 fails on an all zero output, i.e. a low order point.
+		return curve25519.X25519(sk, pk)
+	},
+	publicKey: func(sk []byte) ([]byte, error) {
+		if len(sk) != curve25519.ScalarSize {
+			return nil, ErrInvalidKey
+		}
+		return curve25519.X25519(sk, curve25519.Basepoint)
+	},
+	deriveKey: func(k *dhkem, ikm []byte) ([]byte, error) {
+		prk := labeledExtract(k.suiteID(), nil, "dkp_prk", ikm)
+		return labeledExpand(k.suiteID(), prk, "sk", nil, k.nsk), nil
+	},
+	validPublic: func(pk []byte) bool {
+		return len(pk) == curve25519.PointSize
+	},
+}
+
+var p256 = elliptic.P256()
+
+var p256KEM = &dhkem{
+	id:      DHKEMP256,
+	nsecret: 32,
+	npk:     65,
+	nsk:     32,
+	dh: func(sk, pk []byte) ([]byte, error) {
+		x, y := elliptic.Unmarshal(p256, pk)
+		if x == nil {
+			return nil, ErrInvalidPublicKey
+		}
+		if !validP256Scalar(sk) {
+			return nil, ErrInvalidKey
+		}
+		sx, _ := p256.ScalarMult(x, y, sk)
+		return sx.FillBytes(make([]byte, 32)), nil
+	},
+	publicKey: func(sk []byte) ([]byte, error) {
+		if len(sk) != 32 || !validP256Scalar(sk) {
+			return nil, ErrInvalidKey
+		}
+		x, y := p256.ScalarBaseMult(sk)
+		return elliptic.Marshal(p256, x, y), nil
+	},
+	deriveKey: func(k *dhkem, ikm []byte) ([]byte, error) {
+		prk := labeledExtract(k.suiteID(), nil, "dkp_prk", ikm)
+		for counter := 0; counter < 256; counter++ {
+			sk := labeledExpand(k.suiteID(), prk, "candidate", []byte{byte(counter)}, k.nsk)
+			if validP256Scalar(sk) {
+				return sk, nil
+			}
+		}
+		return nil, ErrDeriveKeyPair
+	},
+	validPublic: func(pk []byte) bool {
+		x, _ := elliptic.Unmarshal(p256, pk)
+		return x != nil
+	},
+}
+
+func validP256Scalar(sk []byte) bool {
+	d := new(big.Int).SetBytes(sk)
+	return d.Sign() > 0 && d.Cmp(p256.Params().N) < 0
+}
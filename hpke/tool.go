@@ -0,0 +1,307 @@
+package hpke
+
+// This file exposes HPKE through the ecies.EncryptTool and ecies.DecryptTool
+// interfaces. Keys are hex strings prefixed with their KEM, "x25519:" or
+// "p256:"; keys without a prefix are handed to ecies, so callers switch
+// algorithms by key type alone.
+//
+// A single shot ciphertext is
+//
+//	version (1) | kem (2) | aead (2) | enc | sealed
+//
+// with the part before sealed as additional data. A stream is
+//
+//	streamVersion (1) | kem (2) | aead (2) | chunk size (4) | enc
+//
+// followed by sealed chunks of chunk size plaintext bytes, the last one
+// shorter or empty. The context sequence number orders the chunks and the
+// additional data of each chunk is its final flag.
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/suiguo/hwlib/ecies"
+	"github.com/suiguo/hwlib/internal/chunked"
+)
+
+const (
+	sealVersion   = 0x01
+	streamVersion = 0x02
+
+	// StreamChunkSize is the plaintext size of every chunk but the last.
+	StreamChunkSize = 64 * 1024
+	// maxStreamChunkSize bounds the buffer a reader allocates for a header.
+	maxStreamChunkSize = 16 * 1024 * 1024
+
+	headerLen = 5
+)
+
+var (
+	ErrKeyFormat      = errors.New("hpke: invalid key string")
+	ErrMessage        = errors.New("hpke: invalid message")
+	ErrStreamHeader   = errors.New("hpke: invalid stream header")
+	ErrStreamChunk    = errors.New("hpke: stream chunk authentication failed")
+	ErrStreamTruncate = errors.New("hpke: stream truncated")
+	ErrStreamClosed   = errors.New("hpke: stream closed")
+
+	streamErrors = chunked.Errors{
+		Closed:    ErrStreamClosed,
+		Chunk:     ErrStreamChunk,
+		Truncated: ErrStreamTruncate,
+	}
+)
+
+var kemPrefix = map[KEM]string{
+	DHKEMX25519: "x25519:",
+	DHKEMP256:   "p256:",
+}
+
+// GenKey returns a new key pair of kem as key strings.
+func GenKey(kem KEM) (pub string, pri string, err error) {
+	prefix, ok := kemPrefix[kem]
+	if !ok {
+		return "", "", ErrUnknownKEM
+	}
+	pk, sk, err := Suite{KEM: kem, KDF: HKDFSHA256, AEAD: AES128GCM}.GenerateKeyPair(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return prefix + hex.EncodeToString(pk), prefix + hex.EncodeToString(sk), nil
+}
+
+// parseKey splits a key string into its KEM and key bytes. ok is false for
+// keys without a KEM prefix.
+func parseKey(s string) (kem KEM, key []byte, ok bool, err error) {
+	for k, prefix := range kemPrefix {
+		if strings.HasPrefix(s, prefix) {
+			key, err = hex.DecodeString(s[len(prefix):])
+			if err != nil {
+				return 0, nil, true, ErrKeyFormat
+			}
+			return k, key, true, nil
+		}
+	}
+	return 0, nil, false, nil
+}
+
+type options struct {
+	aead AEAD
+	info []byte
+	auth string
+}
+
+// Option configures EnTool and DeTool.
+type Option func(*options)
+
+// WithAEAD selects the AEAD of EnTool, AES128GCM by default. DeTool reads
+// it from the ciphertext.
+func WithAEAD(aead AEAD) Option {
+	return func(o *options) {
+		o.aead = aead
+	}
+}
+
+// WithInfo binds the ciphertexts to an application info string, which
+// sender and receiver must agree on.
+func WithInfo(info []byte) Option {
+	return func(o *options) {
+		o.info = info
+	}
+}
+
+// WithAuth switches to auth mode: the sender's private key for EnTool, the
+// sender's public key for DeTool. It must use the same KEM as the receiver.
+func WithAuth(key string) Option {
+	return func(o *options) {
+		o.auth = key
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{aead: AES128GCM}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// authKey parses the auth key of o, nil in base mode.
+func (o *options) authKey(kem KEM) ([]byte, error) {
+	if o.auth == "" {
+		return nil, nil
+	}
+	k, key, ok, err := parseKey(o.auth)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || k != kem {
+		return nil, ErrKeyFormat
+	}
+	return key, nil
+}
+
+type enTool struct {
+	suite Suite
+	pkR   []byte
+	skS   []byte
+	info  []byte
+}
+
+// EnTool returns an encrypter for the public key string pub. An
+// unprefixed key returns ecies.EnTool(pub) and ignores opts.
+func EnTool(pub string, opts ...Option) (ecies.EncryptTool, error) {
+	kem, pkR, ok, err := parseKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return ecies.EnTool(pub)
+	}
+	o := newOptions(opts)
+	suite := Suite{KEM: kem, KDF: HKDFSHA256, AEAD: o.aead}
+	k, err := suite.check()
+	if err != nil {
+		return nil, err
+	}
+	if !k.validPublic(pkR) {
+		return nil, ErrInvalidPublicKey
+	}
+	skS, err := o.authKey(kem)
+	if err != nil {
+		return nil, err
+	}
+	return &enTool{suite: suite, pkR: pkR, skS: skS, info: o.info}, nil
+}
+
+func (e *enTool) setup() ([]byte, *Context, error) {
+	if e.skS != nil {
+		return e.suite.SetupAuthS(rand.Reader, e.pkR, e.info, e.skS)
+	}
+	return e.suite.SetupBaseS(rand.Reader, e.pkR, e.info)
+}
+
+func (e *enTool) header(version byte) []byte {
+	h := []byte{version}
+	h = binary.BigEndian.AppendUint16(h, uint16(e.suite.KEM))
+	return binary.BigEndian.AppendUint16(h, uint16(e.suite.AEAD))
+}
+
+func (e *enTool) ECCEncrypt(msg []byte) ([]byte, error) {
+	enc, ctx, err := e.setup()
+	if err != nil {
+		return nil, err
+	}
+	header := append(e.header(sealVersion), enc...)
+	ct, err := ctx.Seal(header, msg)
+	if err != nil {
+		return nil, err
+	}
+	return append(header, ct...), nil
+}
+
+func (e *enTool) ECCEncryptStream(w io.Writer) (io.WriteCloser, error) {
+	enc, ctx, err := e.setup()
+	if err != nil {
+		return nil, err
+	}
+	header := binary.BigEndian.AppendUint32(e.header(streamVersion), StreamChunkSize)
+	header = append(header, enc...)
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return chunked.NewWriter(w, StreamChunkSize, func(pt []byte, final bool) ([]byte, error) {
+		return ctx.Seal(chunkAAD(final), pt)
+	}, streamErrors), nil
+}
+
+type deTool struct {
+	kem  KEM
+	skR  []byte
+	pkS  []byte
+	info []byte
+}
+
+// DeTool returns a decrypter for the private key string pri. An
+// unprefixed key returns ecies.DeTool(pri) and ignores opts.
+func DeTool(pri string, opts ...Option) (ecies.DecryptTool, error) {
+	kem, skR, ok, err := parseKey(pri)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return ecies.DeTool(pri)
+	}
+	k, err := kem.dhkem()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := k.publicKey(skR); err != nil {
+		return nil, err
+	}
+	o := newOptions(opts)
+	pkS, err := o.authKey(kem)
+	if err != nil {
+		return nil, err
+	}
+	return &deTool{kem: kem, skR: skR, pkS: pkS, info: o.info}, nil
+}
+
+// setup reads the suite from header and the encapsulated key from enc.
+func (d *deTool) setup(header, enc []byte) (*Context, error) {
+	if KEM(binary.BigEndian.Uint16(header[1:3])) != d.kem {
+		return nil, ErrUnknownKEM
+	}
+	suite := Suite{KEM: d.kem, KDF: HKDFSHA256, AEAD: AEAD(binary.BigEndian.Uint16(header[3:5]))}
+	if d.pkS != nil {
+		return suite.SetupAuthR(enc, d.skR, d.info, d.pkS)
+	}
+	return suite.SetupBaseR(enc, d.skR, d.info)
+}
+
+func (d *deTool) encLen() int {
+	k, _ := d.kem.dhkem()
+	return k.npk
+}
+
+func (d *deTool) ECCDecrypt(msg []byte) ([]byte, error) {
+	n := headerLen + d.encLen()
+	if len(msg) < n || msg[0] != sealVersion {
+		return nil, ErrMessage
+	}
+	ctx, err := d.setup(msg[:headerLen], msg[headerLen:n])
+	if err != nil {
+		return nil, err
+	}
+	return ctx.Open(msg[:n], msg[n:])
+}
+
+func (d *deTool) ECCDecryptStream(r io.Reader) (io.Reader, error) {
+	header := make([]byte, headerLen+4+d.encLen())
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, ErrStreamHeader
+	}
+	size := int(binary.BigEndian.Uint32(header[headerLen:]))
+	if header[0] != streamVersion || size == 0 || size > maxStreamChunkSize {
+		return nil, ErrStreamHeader
+	}
+	ctx, err := d.setup(header[:headerLen], header[headerLen+4:])
+	if err != nil {
+		return nil, err
+	}
+	return chunked.NewReader(r, size, ctx.aead.Overhead(), func(ct []byte, final bool) ([]byte, error) {
+		return ctx.Open(chunkAAD(final), ct)
+	}, streamErrors), nil
+}
+
+// chunkAAD is the additional data of a stream chunk, its final flag.
+func chunkAAD(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
@@ -0,0 +1,66 @@
+package httpsig
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HMACSigner signs requests with the HMAC scheme.
+type HMACSigner struct {
+	keyID  string
+	secret []byte
+	now    func() time.Time
+}
+
+func NewHMACSigner(keyID string, secret []byte) *HMACSigner {
+	return &HMACSigner{keyID: keyID, secret: secret, now: time.Now}
+}
+
+// Sign sets X-Key-Id, X-Timestamp, X-Nonce and X-Signature.
+func (s *HMACSigner) Sign(req *http.Request) error {
+	body, err := readBody(req)
+	if err != nil {
+		return err
+	}
+	nonce, err := newNonce()
+	if err != nil {
+		return err
+	}
+	ts := strconv.FormatInt(s.now().Unix(), 10)
+	req.Header.Set(HeaderKeyID, s.keyID)
+	req.Header.Set(HeaderTimestamp, ts)
+	req.Header.Set(HeaderNonce, nonce)
+	req.Header.Set(HeaderSignature, base64.StdEncoding.EncodeToString(hmacSum(s.secret, CanonicalString(req, ts, nonce, body))))
+	return nil
+}
+
+// CanonicalString is the HMAC scheme's signed string, one item per line:
+// method, escaped path, query sorted by key, timestamp, nonce and the hex
+// SHA-256 of the body.
+func CanonicalString(req *http.Request, ts, nonce string, body []byte) string {
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	sum := sha256.Sum256(body)
+	return strings.Join([]string{
+		strings.ToUpper(req.Method),
+		path,
+		req.URL.Query().Encode(),
+		ts,
+		nonce,
+		hex.EncodeToString(sum[:]),
+	}, "\n")
+}
+
+func hmacSum(secret []byte, s string) []byte {
+	m := hmac.New(sha256.New, secret)
+	m.Write([]byte(s))
+	return m.Sum(nil)
+}
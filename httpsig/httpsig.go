@@ -0,0 +1,172 @@
+// Package httpsig signs outgoing HTTP requests and verifies incoming ones.
+//
+// Two schemes are supported. The HMAC scheme signs a canonical string of
+// method, path, query, timestamp, nonce and body hash with HMAC-SHA256 and
+// sends it in X-Key-Id, X-Timestamp, X-Nonce and X-Signature headers. The
+// RFC 9421 scheme produces Signature-Input and Signature headers using
+// hmac-sha256, ed25519 or rsa-pss-sha512 keys.
+//
+// Signers plug into resty clients with Resty, or sign any *http.Request.
+// Verifier checks both schemes, enforces a clock window and, with a
+// NonceStore such as RedisNonces, rejects replayed nonces.
+package httpsig
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// Algorithm is an RFC 9421 signature algorithm name.
+type Algorithm string
+
+const (
+	AlgHMACSHA256   Algorithm = "hmac-sha256"
+	AlgEd25519      Algorithm = "ed25519"
+	AlgRSAPSSSHA512 Algorithm = "rsa-pss-sha512"
+)
+
+// Headers of the HMAC scheme.
+const (
+	HeaderKeyID     = "X-Key-Id"
+	HeaderTimestamp = "X-Timestamp"
+	HeaderNonce     = "X-Nonce"
+	HeaderSignature = "X-Signature"
+)
+
+var (
+	ErrNoSignature  = errors.New("httpsig: request is not signed")
+	ErrMalformed    = errors.New("httpsig: malformed signature header")
+	ErrUnknownKey   = errors.New("httpsig: unknown key id")
+	ErrAlgorithm    = errors.New("httpsig: algorithm does not match key")
+	ErrSignature    = errors.New("httpsig: signature mismatch")
+	ErrExpired      = errors.New("httpsig: signature outside time window")
+	ErrReplay       = errors.New("httpsig: nonce already used")
+	ErrNonce        = errors.New("httpsig: nonce required")
+	ErrDigest       = errors.New("httpsig: content digest mismatch")
+	ErrComponent    = errors.New("httpsig: unsupported or missing component")
+	ErrUnsupported  = errors.New("httpsig: unsupported key type")
+	ErrKeyNotSigner = errors.New("httpsig: key cannot sign")
+)
+
+// Key is a signing or verification key. Secret is set for HMAC keys,
+// Private and/or Public for ed25519 and RSA-PSS keys.
+type Key struct {
+	ID      string
+	Alg     Algorithm
+	Secret  []byte
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+// HMACKey returns an hmac-sha256 key. It works with both schemes.
+func HMACKey(id string, secret []byte) *Key {
+	return &Key{ID: id, Alg: AlgHMACSHA256, Secret: secret}
+}
+
+// Ed25519Key returns an ed25519 key from an ed25519.PrivateKey or
+// ed25519.PublicKey.
+func Ed25519Key(id string, key interface{}) (*Key, error) {
+	k := &Key{ID: id, Alg: AlgEd25519}
+	switch v := key.(type) {
+	case ed25519.PrivateKey:
+		k.Private, k.Public = v, v.Public()
+	case ed25519.PublicKey:
+		k.Public = v
+	default:
+		return nil, ErrUnsupported
+	}
+	return k, nil
+}
+
+// RSAPSSKey returns an rsa-pss-sha512 key from a *rsa.PrivateKey or
+// *rsa.PublicKey.
+func RSAPSSKey(id string, key interface{}) (*Key, error) {
+	k := &Key{ID: id, Alg: AlgRSAPSSSHA512}
+	switch v := key.(type) {
+	case *rsa.PrivateKey:
+		k.Private, k.Public = v, &v.PublicKey
+	case *rsa.PublicKey:
+		k.Public = v
+	default:
+		return nil, ErrUnsupported
+	}
+	return k, nil
+}
+
+// RequestSigner adds signature headers to a request.
+type RequestSigner interface {
+	Sign(req *http.Request) error
+}
+
+// Resty signs every request sent by c, including retries, with s. It takes
+// over no hook: the client's transport is wrapped, so pre-request hooks and
+// OnBeforeRequest middlewares set before or after keep running and their
+// changes are signed. Configure TLS, proxies and SetTransport before calling
+// Resty, since resty only applies those to an *http.Transport.
+func Resty(c *resty.Client, s RequestSigner) *resty.Client {
+	next := c.GetClient().Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return c.SetTransport(&signingTransport{next: next, signer: s})
+}
+
+// signingTransport signs a copy of each request, as a RoundTripper must not
+// modify the request it is given.
+type signingTransport struct {
+	next   http.RoundTripper
+	signer RequestSigner
+}
+
+func (t *signingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	signed := req.Clone(req.Context())
+	if err := t.signer.Sign(signed); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.next.RoundTrip(signed)
+}
+
+// readBody returns the request body and leaves req readable again.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
+
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
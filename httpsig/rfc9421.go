@@ -0,0 +1,444 @@
+package httpsig
+
+// This file implements RFC 9421 HTTP Message Signatures for requests, with
+// RFC 9530 Content-Digest for bodies.
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultComponents are covered by Signer unless WithComponents is given.
+// Header fields missing from the request are left out.
+var DefaultComponents = []string{"@method", "@authority", "@path", "@query", "content-digest", "content-type"}
+
+// Signer signs requests with RFC 9421 HTTP Message Signatures.
+type Signer struct {
+	key        *Key
+	label      string
+	components []string
+	expires    time.Duration
+	now        func() time.Time
+}
+
+type Option func(*Signer)
+
+// WithComponents sets the covered components, e.g. "@method", "@path" or
+// lowercase header names.
+func WithComponents(components ...string) Option {
+	return func(s *Signer) {
+		s.components = components
+	}
+}
+
+// WithLabel sets the signature label, "sig1" by default.
+func WithLabel(label string) Option {
+	return func(s *Signer) {
+		s.label = label
+	}
+}
+
+// WithExpires adds an expires parameter d after created.
+func WithExpires(d time.Duration) Option {
+	return func(s *Signer) {
+		s.expires = d
+	}
+}
+
+func NewSigner(key *Key, opts ...Option) (*Signer, error) {
+	if key == nil || (key.Private == nil && key.Secret == nil) {
+		return nil, ErrKeyNotSigner
+	}
+	s := &Signer{key: key, label: "sig1", components: DefaultComponents, now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// Sign sets Content-Digest when covered and the body is not empty, then
+// Signature-Input and Signature.
+func (s *Signer) Sign(req *http.Request) error {
+	body, err := readBody(req)
+	if err != nil {
+		return err
+	}
+	covered := make([]string, 0, len(s.components))
+	for _, c := range s.components {
+		if c == "content-digest" && len(body) > 0 {
+			sum := sha256.Sum256(body)
+			req.Header.Set("Content-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(sum[:])+":")
+		}
+		if _, err := componentValue(req, c); err != nil {
+			if strings.HasPrefix(c, "@") {
+				return err
+			}
+			continue
+		}
+		covered = append(covered, c)
+	}
+	nonce, err := newNonce()
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	params := signatureParams(covered, [][2]string{
+		{"created", strconv.FormatInt(now.Unix(), 10)},
+		{"expires", expiresParam(now, s.expires)},
+		{"nonce", sfQuote(nonce)},
+		{"keyid", sfQuote(s.key.ID)},
+		{"alg", sfQuote(string(s.key.Alg))},
+	})
+	base, err := signatureBase(req, covered, params)
+	if err != nil {
+		return err
+	}
+	sig, err := signBase(s.key, base)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Signature-Input", s.label+"="+params)
+	req.Header.Set("Signature", s.label+"=:"+base64.StdEncoding.EncodeToString(sig)+":")
+	return nil
+}
+
+// sfQuote serializes an sf-string. Only " and \ are escaped.
+func sfQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+func expiresParam(now time.Time, d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	return strconv.FormatInt(now.Add(d).Unix(), 10)
+}
+
+// signatureParams serializes the inner list of covered components and the
+// parameters with non-empty values.
+func signatureParams(covered []string, params [][2]string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for i, c := range covered {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(sfQuote(c))
+	}
+	b.WriteByte(')')
+	for _, p := range params {
+		if p[1] == "" {
+			continue
+		}
+		b.WriteString(";" + p[0] + "=" + p[1])
+	}
+	return b.String()
+}
+
+// signatureBase builds the RFC 9421 section 2.5 signature base.
+func signatureBase(req *http.Request, covered []string, params string) ([]byte, error) {
+	var b strings.Builder
+	for _, c := range covered {
+		v, err := componentValue(req, c)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(sfQuote(c) + ": " + v + "\n")
+	}
+	b.WriteString(`"@signature-params": ` + params)
+	return []byte(b.String()), nil
+}
+
+func componentValue(req *http.Request, c string) (string, error) {
+	switch c {
+	case "@method":
+		return req.Method, nil
+	case "@authority":
+		return authority(req), nil
+	case "@scheme":
+		return scheme(req), nil
+	case "@target-uri":
+		return scheme(req) + "://" + authority(req) + req.URL.RequestURI(), nil
+	case "@request-target":
+		return req.URL.RequestURI(), nil
+	case "@path":
+		if p := req.URL.EscapedPath(); p != "" {
+			return p, nil
+		}
+		return "/", nil
+	case "@query":
+		return "?" + req.URL.RawQuery, nil
+	}
+	if strings.HasPrefix(c, "@") || c != strings.ToLower(c) {
+		return "", ErrComponent
+	}
+	values := req.Header.Values(c)
+	switch {
+	case len(values) > 0:
+	case c == "host":
+		values = []string{authority(req)}
+	case c == "content-length" && req.ContentLength > 0:
+		values = []string{strconv.FormatInt(req.ContentLength, 10)}
+	default:
+		return "", ErrComponent
+	}
+	// Header.Values returns the request's own slice; trim a copy.
+	trimmed := make([]string, len(values))
+	for i, v := range values {
+		trimmed[i] = strings.TrimSpace(v)
+	}
+	return strings.Join(trimmed, ", "), nil
+}
+
+func authority(req *http.Request) string {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	return strings.ToLower(host)
+}
+
+func scheme(req *http.Request) string {
+	if req.URL.Scheme != "" {
+		return strings.ToLower(req.URL.Scheme)
+	}
+	if req.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+func signBase(key *Key, base []byte) ([]byte, error) {
+	switch key.Alg {
+	case AlgHMACSHA256:
+		m := hmac.New(sha256.New, key.Secret)
+		m.Write(base)
+		return m.Sum(nil), nil
+	case AlgEd25519:
+		if key.Private == nil {
+			return nil, ErrKeyNotSigner
+		}
+		return key.Private.Sign(rand.Reader, base, crypto.Hash(0))
+	case AlgRSAPSSSHA512:
+		priv, ok := key.Private.(*rsa.PrivateKey)
+		if !ok {
+			return nil, ErrKeyNotSigner
+		}
+		h := sha512.Sum512(base)
+		return rsa.SignPSS(rand.Reader, priv, crypto.SHA512, h[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	}
+	return nil, ErrAlgorithm
+}
+
+func verifyBase(key *Key, base, sig []byte) error {
+	switch key.Alg {
+	case AlgHMACSHA256:
+		m := hmac.New(sha256.New, key.Secret)
+		m.Write(base)
+		if hmac.Equal(m.Sum(nil), sig) {
+			return nil
+		}
+	case AlgEd25519:
+		pub, ok := key.Public.(ed25519.PublicKey)
+		if ok && ed25519.Verify(pub, base, sig) {
+			return nil
+		}
+	case AlgRSAPSSSHA512:
+		pub, ok := key.Public.(*rsa.PublicKey)
+		if !ok {
+			return ErrUnsupported
+		}
+		h := sha512.Sum512(base)
+		if rsa.VerifyPSS(pub, crypto.SHA512, h[:], sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil {
+			return nil
+		}
+	default:
+		return ErrAlgorithm
+	}
+	return ErrSignature
+}
+
+// member is one entry of a structured field dictionary.
+type member struct {
+	label string
+	value string
+}
+
+// parseDictionary splits a structured field dictionary (RFC 8941) into its
+// members, keeping each value as it appeared on the wire.
+func parseDictionary(field string) ([]member, error) {
+	var out []member
+	for _, item := range splitTopLevel(field) {
+		item = strings.TrimSpace(item)
+		label, value, ok := strings.Cut(item, "=")
+		if !ok || label == "" || value == "" {
+			return nil, ErrMalformed
+		}
+		out = append(out, member{label: label, value: value})
+	}
+	return out, nil
+}
+
+// splitTopLevel splits on commas outside of strings and inner lists.
+func splitTopLevel(s string) []string {
+	var out []string
+	depth, start := 0, 0
+	quoted, escaped := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && c == '\\':
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			out = append(out, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(out, s[start:])
+}
+
+// parseSignatureInput parses an inner list of plain string components
+// followed by parameters. Component parameters such as ;sf are rejected.
+func parseSignatureInput(value string) ([]string, map[string]string, error) {
+	if !strings.HasPrefix(value, "(") {
+		return nil, nil, ErrMalformed
+	}
+	rest := value[1:]
+	var covered []string
+	for {
+		rest = strings.TrimLeft(rest, " ")
+		if strings.HasPrefix(rest, ")") {
+			rest = rest[1:]
+			break
+		}
+		s, n, err := parseString(rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		rest = rest[n:]
+		if strings.HasPrefix(rest, ";") {
+			return nil, nil, ErrComponent
+		}
+		covered = append(covered, s)
+	}
+	params := map[string]string{}
+	for rest != "" {
+		if rest[0] != ';' {
+			return nil, nil, ErrMalformed
+		}
+		rest = rest[1:]
+		end := strings.IndexAny(rest, "=;")
+		if end < 0 {
+			end = len(rest)
+		}
+		name := strings.TrimSpace(rest[:end])
+		rest = rest[end:]
+		if !strings.HasPrefix(rest, "=") {
+			params[name] = "?1"
+			continue
+		}
+		rest = rest[1:]
+		if strings.HasPrefix(rest, `"`) {
+			s, n, err := parseString(rest)
+			if err != nil {
+				return nil, nil, err
+			}
+			params[name] = s
+			rest = rest[n:]
+			continue
+		}
+		end = strings.IndexByte(rest, ';')
+		if end < 0 {
+			end = len(rest)
+		}
+		params[name] = rest[:end]
+		rest = rest[end:]
+	}
+	return covered, params, nil
+}
+
+// parseString reads a quoted sf-string and returns it and the bytes used.
+func parseString(s string) (string, int, error) {
+	if !strings.HasPrefix(s, `"`) {
+		return "", 0, ErrMalformed
+	}
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 >= len(s) || (s[i+1] != '"' && s[i+1] != '\\') {
+				return "", 0, ErrMalformed
+			}
+			i++
+			b.WriteByte(s[i])
+		case '"':
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", 0, ErrMalformed
+}
+
+// parseBytes decodes an sf-binary :base64: value.
+func parseBytes(value string) ([]byte, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 || value[0] != ':' || value[len(value)-1] != ':' {
+		return nil, ErrMalformed
+	}
+	b, err := base64.StdEncoding.DecodeString(value[1 : len(value)-1])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	return b, nil
+}
+
+// checkDigest verifies a Content-Digest header with sha-256 or sha-512.
+func checkDigest(header string, body []byte) error {
+	members, err := parseDictionary(header)
+	if err != nil {
+		return ErrDigest
+	}
+	for _, m := range members {
+		want, err := parseBytes(m.value)
+		if err != nil {
+			return ErrDigest
+		}
+		var got []byte
+		switch m.label {
+		case "sha-256":
+			sum := sha256.Sum256(body)
+			got = sum[:]
+		case "sha-512":
+			sum := sha512.Sum512(body)
+			got = sum[:]
+		default:
+			continue
+		}
+		if hmac.Equal(got, want) {
+			return nil
+		}
+		return ErrDigest
+	}
+	return ErrDigest
+}
@@ -0,0 +1,300 @@
+package httpsig
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/suiguo/hwlib/redis"
+)
+
+// KeyIDKey is the gin.Context key holding the verified key id.
+const KeyIDKey = "hwlib.httpsig.keyid"
+
+// KeyResolver returns the key for a key id, or ErrUnknownKey.
+type KeyResolver func(keyID string) (*Key, error)
+
+// StaticKeys resolves key ids from a fixed set of keys.
+func StaticKeys(keys ...*Key) KeyResolver {
+	m := make(map[string]*Key, len(keys))
+	for _, k := range keys {
+		m[k.ID] = k
+	}
+	return func(keyID string) (*Key, error) {
+		k, ok := m[keyID]
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+		return k, nil
+	}
+}
+
+// NonceStore records used nonces. Use reports false if the nonce was
+// already used by keyID within ttl.
+type NonceStore interface {
+	Use(ctx context.Context, keyID, nonce string, ttl time.Duration) (bool, error)
+}
+
+// RedisNonces is a NonceStore backed by SET NX with a TTL, shared by all
+// instances behind a load balancer.
+type RedisNonces struct {
+	c      *redis.Client
+	prefix string
+}
+
+// NewRedisNonces stores nonces under prefix, "httpsig:nonce:" if empty.
+func NewRedisNonces(c *redis.Client, prefix string) *RedisNonces {
+	if prefix == "" {
+		prefix = "httpsig:nonce:"
+	}
+	return &RedisNonces{c: c, prefix: prefix}
+}
+
+func (r *RedisNonces) Use(ctx context.Context, keyID, nonce string, ttl time.Duration) (bool, error) {
+	return r.c.SETNX(ctx, r.prefix+keyID+":"+nonce, 1, ttl)
+}
+
+// Verifier checks HMAC scheme and RFC 9421 signatures on incoming requests.
+type Verifier struct {
+	keys     KeyResolver
+	nonces   NonceStore
+	window   time.Duration
+	required []string
+	now      func() time.Time
+}
+
+type VerifierOption func(*Verifier)
+
+// WithNonceStore rejects replayed nonces. Requests without a nonce are
+// rejected once a store is set.
+func WithNonceStore(s NonceStore) VerifierOption {
+	return func(v *Verifier) {
+		v.nonces = s
+	}
+}
+
+// WithWindow sets how far the signature time may be from now, 5 minutes by
+// default.
+func WithWindow(d time.Duration) VerifierOption {
+	return func(v *Verifier) {
+		v.window = d
+	}
+}
+
+// WithRequiredComponents sets the components an RFC 9421 signature must
+// cover, "@method", "@authority" and "@path" by default. content-digest is
+// always required for requests with a body.
+func WithRequiredComponents(components ...string) VerifierOption {
+	return func(v *Verifier) {
+		v.required = components
+	}
+}
+
+func NewVerifier(keys KeyResolver, opts ...VerifierOption) *Verifier {
+	v := &Verifier{
+		keys:     keys,
+		window:   5 * time.Minute,
+		required: []string{"@method", "@authority", "@path"},
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Verify checks the request signature and returns the signing key. An RFC
+// 9421 Signature-Input header takes precedence over the HMAC scheme.
+func (v *Verifier) Verify(req *http.Request) (*Key, error) {
+	if req.Header.Get("Signature-Input") != "" {
+		return v.verifyMessage(req)
+	}
+	if req.Header.Get(HeaderSignature) != "" {
+		return v.verifyHMAC(req)
+	}
+	return nil, ErrNoSignature
+}
+
+func (v *Verifier) verifyHMAC(req *http.Request) (*Key, error) {
+	key, err := v.keys(req.Header.Get(HeaderKeyID))
+	if err != nil {
+		return nil, err
+	}
+	if key.Alg != AlgHMACSHA256 {
+		return nil, ErrAlgorithm
+	}
+	ts := req.Header.Get(HeaderTimestamp)
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	if err := v.checkTime(sec, 0); err != nil {
+		return nil, err
+	}
+	sig, err := base64.StdEncoding.DecodeString(req.Header.Get(HeaderSignature))
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	nonce := req.Header.Get(HeaderNonce)
+	if !hmac.Equal(hmacSum(key.Secret, CanonicalString(req, ts, nonce, body)), sig) {
+		return nil, ErrSignature
+	}
+	if err := v.useNonce(req.Context(), key.ID, nonce, 0); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (v *Verifier) verifyMessage(req *http.Request) (*Key, error) {
+	inputs, err := parseDictionary(req.Header.Get("Signature-Input"))
+	if err != nil {
+		return nil, err
+	}
+	sigs, err := parseDictionary(req.Header.Get("Signature"))
+	if err != nil {
+		return nil, err
+	}
+	// Verify the first label present in both headers.
+	var input, signature string
+	for _, in := range inputs {
+		for _, s := range sigs {
+			if s.label == in.label {
+				input, signature = in.value, s.value
+				break
+			}
+		}
+		if input != "" {
+			break
+		}
+	}
+	if input == "" {
+		return nil, ErrNoSignature
+	}
+	covered, params, err := parseSignatureInput(input)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := parseBytes(signature)
+	if err != nil {
+		return nil, err
+	}
+	key, err := v.keys(params["keyid"])
+	if err != nil {
+		return nil, err
+	}
+	if alg, ok := params["alg"]; ok && Algorithm(alg) != key.Alg {
+		return nil, ErrAlgorithm
+	}
+	created, err := strconv.ParseInt(params["created"], 10, 64)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	var expires int64
+	if e, ok := params["expires"]; ok {
+		if expires, err = strconv.ParseInt(e, 10, 64); err != nil {
+			return nil, ErrMalformed
+		}
+	}
+	if err := v.checkTime(created, expires); err != nil {
+		return nil, err
+	}
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	required := v.required
+	if len(body) > 0 {
+		required = append(required[:len(required):len(required)], "content-digest")
+	}
+	for _, r := range required {
+		if !contains(covered, r) {
+			return nil, ErrComponent
+		}
+	}
+	base, err := signatureBase(req, covered, input)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyBase(key, base, sig); err != nil {
+		return nil, err
+	}
+	if contains(covered, "content-digest") {
+		if err := checkDigest(req.Header.Get("Content-Digest"), body); err != nil {
+			return nil, err
+		}
+	}
+	ttl := time.Duration(0)
+	if expires > 0 {
+		ttl = time.Until(time.Unix(expires, 0))
+	}
+	if err := v.useNonce(req.Context(), key.ID, params["nonce"], ttl); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// checkTime accepts created within the window around now and rejects
+// signatures past expires.
+func (v *Verifier) checkTime(created, expires int64) error {
+	now := v.now()
+	t := time.Unix(created, 0)
+	if t.Before(now.Add(-v.window)) || t.After(now.Add(v.window)) {
+		return ErrExpired
+	}
+	if expires > 0 && now.After(time.Unix(expires, 0)) {
+		return ErrExpired
+	}
+	return nil
+}
+
+// useNonce keeps a nonce for the whole window a signature can be accepted in.
+func (v *Verifier) useNonce(ctx context.Context, keyID, nonce string, ttl time.Duration) error {
+	if v.nonces == nil {
+		return nil
+	}
+	if nonce == "" {
+		return ErrNonce
+	}
+	if ttl < 2*v.window {
+		ttl = 2 * v.window
+	}
+	ok, err := v.nonces.Use(ctx, keyID, nonce, ttl)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrReplay
+	}
+	return nil
+}
+
+// Middleware rejects requests that fail Verify with 401 and stores the key
+// id under KeyIDKey.
+func (v *Verifier) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key, err := v.Verify(ctx.Request)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "msg": err.Error()})
+			return
+		}
+		ctx.Set(KeyIDKey, key.ID)
+		ctx.Next()
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package httpsig
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-resty/resty/v2"
+)
+
+type memNonces struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+func (m *memNonces) Use(_ context.Context, keyID, nonce string, _ time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.seen[keyID+":"+nonce] {
+		return false, nil
+	}
+	m.seen[keyID+":"+nonce] = true
+	return true, nil
+}
+
+func TestSignAndVerify(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	_, edPriv, _ := ed25519.GenerateKey(rand.Reader)
+	rsaPriv, _ := rsa.GenerateKey(rand.Reader, 2048)
+	edKey, _ := Ed25519Key("partner-ed", edPriv)
+	rsaKey, _ := RSAPSSKey("partner-rsa", rsaPriv)
+	hmacKey := HMACKey("partner-hmac", []byte("shared-secret"))
+	edPub, _ := Ed25519Key("partner-ed", edPriv.Public())
+	rsaPub, _ := RSAPSSKey("partner-rsa", &rsaPriv.PublicKey)
+
+	nonces := &memNonces{seen: map[string]bool{}}
+	verifier := NewVerifier(StaticKeys(edPub, rsaPub, hmacKey), WithNonceStore(nonces))
+	g := gin.New()
+	g.Use(verifier.Middleware())
+	g.POST("/orders", func(c *gin.Context) {
+		body, _ := c.GetRawData()
+		c.String(http.StatusOK, c.GetString(KeyIDKey)+":"+string(body))
+	})
+	srv := httptest.NewServer(g)
+	defer srv.Close()
+
+	edSigner, _ := NewSigner(edKey)
+	rsaSigner, _ := NewSigner(rsaKey, WithExpires(time.Minute))
+	hmacSigner, _ := NewSigner(hmacKey)
+	signers := map[string]RequestSigner{
+		"partner-ed":   edSigner,
+		"partner-rsa":  rsaSigner,
+		"partner-hmac": NewHMACSigner("partner-hmac", []byte("shared-secret")),
+	}
+	for id, s := range signers {
+		client := Resty(resty.New(), s)
+		resp, err := client.R().SetHeader("Content-Type", "application/json").
+			SetQueryParam("b", "2").SetQueryParam("a", "1").
+			SetBody(`{"amount":"10"}`).Post(srv.URL + "/orders")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode() != http.StatusOK || resp.String() != id+`:{"amount":"10"}` {
+			t.Fatalf("%s: %d %s", id, resp.StatusCode(), resp.String())
+		}
+	}
+	client := Resty(resty.New(), hmacSigner)
+	if resp, _ := client.R().SetBody("x").Post(srv.URL + "/orders"); resp.StatusCode() != http.StatusOK {
+		t.Fatalf("rfc 9421 hmac: %s", resp.String())
+	}
+
+	for id, s := range signers {
+		req := httptest.NewRequest("POST", "http://example.com/orders", strings.NewReader(`{"amount":"10"}`))
+		if err := s.Sign(req); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := verifier.Verify(req); err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+		if _, err := verifier.Verify(req); !errors.Is(err, ErrReplay) {
+			t.Fatalf("%s: replay accepted: %v", id, err)
+		}
+
+		req = httptest.NewRequest("POST", "http://example.com/orders", strings.NewReader(`{"amount":"10"}`))
+		s.Sign(req)
+		req.Body = io.NopCloser(strings.NewReader(`{"amount":"99"}`))
+		req.GetBody = nil
+		if _, err := verifier.Verify(req); err == nil {
+			t.Fatalf("%s: tampered body accepted", id)
+		}
+	}
+
+	old := NewHMACSigner("partner-hmac", []byte("shared-secret"))
+	old.now = func() time.Time { return time.Now().Add(-time.Hour) }
+	req := httptest.NewRequest("GET", "http://example.com/orders", nil)
+	old.Sign(req)
+	if _, err := verifier.Verify(req); !errors.Is(err, ErrExpired) {
+		t.Fatalf("stale timestamp accepted: %v", err)
+	}
+
+	// A body must be covered by content-digest.
+	noDigest, _ := NewSigner(edKey, WithComponents("@method", "@authority", "@path"))
+	req = httptest.NewRequest("POST", "http://example.com/orders", strings.NewReader("x"))
+	noDigest.Sign(req)
+	if _, err := verifier.Verify(req); !errors.Is(err, ErrComponent) {
+		t.Fatalf("body without digest accepted: %v", err)
+	}
+	if _, err := verifier.Verify(httptest.NewRequest("GET", "/orders", nil)); !errors.Is(err, ErrNoSignature) {
+		t.Fatalf("unsigned: %v", err)
+	}
+}
+
+func TestHeadersUnchanged(t *testing.T) {
+	key := HMACKey("partner-hmac", []byte("shared-secret"))
+	signer, _ := NewSigner(key, WithComponents("@method", "@authority", "@path", "x-pad"))
+	verifier := NewVerifier(StaticKeys(key), WithNonceStore(&memNonces{seen: map[string]bool{}}))
+	req := httptest.NewRequest("GET", "http://example.com/orders", nil)
+	req.Header.Add("X-Pad", "  a ")
+	req.Header.Add("X-Pad", "b  ")
+	if err := signer.Sign(req); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifier.Verify(req); err != nil {
+		t.Fatal(err)
+	}
+	if got := req.Header.Values("X-Pad"); len(got) != 2 || got[0] != "  a " || got[1] != "b  " {
+		t.Fatalf("header changed: %q", got)
+	}
+}
+
+func TestRestyKeepsHooks(t *testing.T) {
+	key := HMACKey("partner-hmac", []byte("shared-secret"))
+	verifier := NewVerifier(StaticKeys(key), WithNonceStore(&memNonces{seen: map[string]bool{}}))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := verifier.Verify(r); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(r.Header.Get("X-Hook")))
+	}))
+	defer srv.Close()
+
+	signer, _ := NewSigner(key, WithComponents("@method", "@authority", "@path", "x-hook"))
+	hooks := 0
+	client := resty.New().SetPreRequestHook(func(_ *resty.Client, req *http.Request) error {
+		hooks++
+		req.Header.Set("X-Hook", "set")
+		return nil
+	})
+	client = Resty(client, signer)
+	resp, err := client.R().Get(srv.URL + "/orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The hook ran before signing, so its header is covered.
+	if resp.StatusCode() != http.StatusOK || resp.String() != "set" || hooks != 1 {
+		t.Fatalf("%d %s hooks %d", resp.StatusCode(), resp.String(), hooks)
+	}
+}
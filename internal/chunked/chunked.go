@@ -0,0 +1,166 @@
+// Package chunked frames an encrypted stream as a sequence of sealed chunks
+// of a fixed plaintext size, the last one shorter or empty. Every chunk is
+// sealed with a final flag, so a reader detects a stream cut at a chunk
+// boundary. The cipher is supplied by the caller; ordering of chunks is up to
+// the seal and open functions, typically through a counter in the nonce or
+// the authenticated data.
+package chunked
+
+import (
+	"bufio"
+	"io"
+)
+
+// SealFunc encrypts and authenticates one chunk. It is called once per chunk
+// in stream order and may reuse pt for its result.
+type SealFunc func(pt []byte, final bool) ([]byte, error)
+
+// OpenFunc authenticates and decrypts one sealed chunk. It is called in
+// stream order and must not advance its state when it fails.
+type OpenFunc func(ct []byte, final bool) ([]byte, error)
+
+// Errors are the errors a Writer or Reader returns for the protocol failures
+// of its stream format.
+type Errors struct {
+	// Closed is returned by Write after Close.
+	Closed error
+	// Chunk is returned when a chunk does not open.
+	Chunk error
+	// Truncated is returned when the stream ends before its final chunk.
+	Truncated error
+}
+
+type writer struct {
+	w      io.Writer
+	seal   SealFunc
+	errs   Errors
+	buf    []byte
+	size   int
+	closed bool
+}
+
+// NewWriter returns a writer that seals every size bytes written to it and
+// writes them to w. Close seals the final chunk; it does not close w.
+func NewWriter(w io.Writer, size int, seal SealFunc, errs Errors) io.WriteCloser {
+	return &writer{
+		w:    w,
+		seal: seal,
+		errs: errs,
+		buf:  make([]byte, 0, size),
+		size: size,
+	}
+}
+
+func (e *writer) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, e.errs.Closed
+	}
+	n := 0
+	for len(p) > 0 {
+		// A full buffer is only flushed once more data arrives, so the
+		// last chunk is always written by Close with the final flag.
+		if len(e.buf) == e.size {
+			if err := e.flush(false); err != nil {
+				return n, err
+			}
+		}
+		k := copy(e.buf[len(e.buf):e.size], p)
+		e.buf = e.buf[:len(e.buf)+k]
+		p = p[k:]
+		n += k
+	}
+	return n, nil
+}
+
+// Close writes the final chunk.
+func (e *writer) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	return e.flush(true)
+}
+
+func (e *writer) flush(final bool) error {
+	ct, err := e.seal(e.buf, final)
+	if err != nil {
+		return err
+	}
+	if _, err := e.w.Write(ct); err != nil {
+		return err
+	}
+	e.buf = e.buf[:0]
+	return nil
+}
+
+type reader struct {
+	r        *bufio.Reader
+	open     OpenFunc
+	errs     Errors
+	buf      []byte
+	overhead int
+	out      []byte
+	done     bool
+	err      error
+}
+
+// NewReader returns a reader of the plaintext of chunks of size bytes sealed
+// with overhead bytes each. Reads fail once a chunk does not open or the
+// stream ends before its final chunk, so callers must not act on the data
+// before reading to io.EOF.
+func NewReader(r io.Reader, size, overhead int, open OpenFunc, errs Errors) io.Reader {
+	return &reader{
+		r:        bufio.NewReaderSize(r, size+overhead+1),
+		open:     open,
+		errs:     errs,
+		buf:      make([]byte, size+overhead),
+		overhead: overhead,
+	}
+}
+
+func (d *reader) Read(p []byte) (int, error) {
+	for len(d.out) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		if d.done {
+			return 0, io.EOF
+		}
+		d.err = d.next()
+	}
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
+
+// next reads and opens one chunk. A chunk is final when nothing follows it.
+func (d *reader) next() error {
+	n, err := io.ReadFull(d.r, d.buf)
+	switch {
+	case err == io.EOF || n < d.overhead:
+		return d.errs.Truncated
+	case err != nil && err != io.ErrUnexpectedEOF:
+		return err
+	}
+	final := err == io.ErrUnexpectedEOF
+	if !final {
+		if _, peekErr := d.r.Peek(1); peekErr == io.EOF {
+			final = true
+		} else if peekErr != nil {
+			return peekErr
+		}
+	}
+	pt, err := d.open(d.buf[:n], final)
+	if err != nil {
+		// An intact non-final chunk at the end means chunks were dropped.
+		if final {
+			if _, e := d.open(d.buf[:n], false); e == nil {
+				return d.errs.Truncated
+			}
+		}
+		return d.errs.Chunk
+	}
+	d.out = pt
+	d.done = final
+	return nil
+}
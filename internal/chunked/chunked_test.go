@@ -0,0 +1,138 @@
+package chunked
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+)
+
+var (
+	errClosed    = errors.New("closed")
+	errChunk     = errors.New("chunk")
+	errTruncated = errors.New("truncated")
+	testErrors   = Errors{Closed: errClosed, Chunk: errChunk, Truncated: errTruncated}
+)
+
+// counterAEAD seals chunks with AES-GCM, the chunk index as nonce and the
+// final flag as additional data.
+type counterAEAD struct {
+	aead  cipher.AEAD
+	index uint64
+}
+
+func newCounterAEAD(t *testing.T) *counterAEAD {
+	block, err := aes.NewCipher(make([]byte, 16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	aead, _ := cipher.NewGCM(block)
+	return &counterAEAD{aead: aead}
+}
+
+func (c *counterAEAD) nonce() []byte {
+	nonce := make([]byte, c.aead.NonceSize())
+	binary.BigEndian.PutUint64(nonce[4:], c.index)
+	return nonce
+}
+
+func aad(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+func (c *counterAEAD) seal(pt []byte, final bool) ([]byte, error) {
+	ct := c.aead.Seal(nil, c.nonce(), pt, aad(final))
+	c.index++
+	return ct, nil
+}
+
+func (c *counterAEAD) open(ct []byte, final bool) ([]byte, error) {
+	pt, err := c.aead.Open(nil, c.nonce(), ct, aad(final))
+	if err != nil {
+		return nil, err
+	}
+	c.index++
+	return pt, nil
+}
+
+const size = 16
+
+func seal(t *testing.T, msg []byte) []byte {
+	var out bytes.Buffer
+	w := NewWriter(&out, size, newCounterAEAD(t).seal, testErrors)
+	// Odd write sizes cross chunk boundaries.
+	for p := msg; len(p) > 0; {
+		n := len(p)
+		if n > 7 {
+			n = 7
+		}
+		if _, err := w.Write(p[:n]); err != nil {
+			t.Fatal(err)
+		}
+		p = p[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte{1}); err != errClosed {
+		t.Fatalf("write after close: %v", err)
+	}
+	return out.Bytes()
+}
+
+func open(t *testing.T, stream []byte) ([]byte, error) {
+	a := newCounterAEAD(t)
+	return io.ReadAll(NewReader(bytes.NewReader(stream), size, a.aead.Overhead(), a.open, testErrors))
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, n := range []int{0, 1, size - 1, size, size + 1, 3 * size, 3*size + 5} {
+		msg := bytes.Repeat([]byte{'x'}, n)
+		stream := seal(t, msg)
+		// Only an empty message has an empty final chunk.
+		chunks := (n + size - 1) / size
+		if n == 0 {
+			chunks = 1
+		}
+		if len(stream) != n+chunks*16 {
+			t.Fatalf("%d: stream length %d", n, len(stream))
+		}
+		got, err := open(t, stream)
+		if err != nil || !bytes.Equal(got, msg) {
+			t.Fatalf("%d: %v", n, err)
+		}
+	}
+}
+
+func TestTampered(t *testing.T) {
+	msg := bytes.Repeat([]byte{'x'}, 3*size+5)
+	stream := seal(t, msg)
+	chunk := size + 16
+
+	flipped := append([]byte{}, stream...)
+	flipped[chunk+1] ^= 1
+	swapped := append(append(append([]byte{}, stream[chunk:2*chunk]...), stream[:chunk]...), stream[2*chunk:]...)
+
+	cases := []struct {
+		name   string
+		stream []byte
+		want   error
+	}{
+		{"flipped", flipped, errChunk},
+		{"swapped", swapped, errChunk},
+		{"cut at chunk", stream[:2*chunk], errTruncated},
+		{"cut in tag", stream[:chunk+10], errTruncated},
+		{"empty", nil, errTruncated},
+	}
+	for _, c := range cases {
+		if _, err := open(t, c.stream); err != c.want {
+			t.Fatalf("%s: got %v want %v", c.name, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package keycodec
+
+// This file implements JSON Web Keys (RFC 7517, RFC 7518 section 6 and
+// RFC 8037) and JWK thumbprints (RFC 7638).
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+)
+
+// JWK is a JSON Web Key. Private members are empty for public keys.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+
+	// RSA
+	N  string `json:"n,omitempty"`
+	E  string `json:"e,omitempty"`
+	D  string `json:"d,omitempty"`
+	P  string `json:"p,omitempty"`
+	Q  string `json:"q,omitempty"`
+	Dp string `json:"dp,omitempty"`
+	Dq string `json:"dq,omitempty"`
+	Qi string `json:"qi,omitempty"`
+
+	// EC and OKP, D is shared with RSA
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+var b64 = base64.RawURLEncoding
+
+var jwkCurves = map[string]elliptic.Curve{
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+func curveName(c elliptic.Curve) (string, error) {
+	for name, curve := range jwkCurves {
+		if curve == c {
+			return name, nil
+		}
+	}
+	return "", ErrUnsupportedKey
+}
+
+func encodeInt(i *big.Int) string {
+	return b64.EncodeToString(i.Bytes())
+}
+
+// encodeFixed encodes i as size bytes, as JWK requires for EC members.
+func encodeFixed(i *big.Int, size int) string {
+	return b64.EncodeToString(i.FillBytes(make([]byte, size)))
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	b, err := b64.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, ErrInvalidJWK
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// NewJWK returns the JWK of an RSA, ECDSA or Ed25519 public or private key.
+func NewJWK(key interface{}) (*JWK, error) {
+	switch k := normalize(key).(type) {
+	case *rsa.PublicKey:
+		return &JWK{Kty: "RSA", N: encodeInt(k.N), E: encodeInt(big.NewInt(int64(k.E)))}, nil
+	case *rsa.PrivateKey:
+		j, _ := NewJWK(&k.PublicKey)
+		j.D = encodeInt(k.D)
+		if len(k.Primes) == 2 {
+			k.Precompute()
+			j.P = encodeInt(k.Primes[0])
+			j.Q = encodeInt(k.Primes[1])
+			j.Dp = encodeInt(k.Precomputed.Dp)
+			j.Dq = encodeInt(k.Precomputed.Dq)
+			j.Qi = encodeInt(k.Precomputed.Qinv)
+		}
+		return j, nil
+	case *ecdsa.PublicKey:
+		crv, err := curveName(k.Curve)
+		if err != nil {
+			return nil, err
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		return &JWK{Kty: "EC", Crv: crv, X: encodeFixed(k.X, size), Y: encodeFixed(k.Y, size)}, nil
+	case *ecdsa.PrivateKey:
+		j, err := NewJWK(&k.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		j.D = encodeFixed(k.D, (k.Curve.Params().BitSize+7)/8)
+		return j, nil
+	case ed25519.PublicKey:
+		return &JWK{Kty: "OKP", Crv: "Ed25519", X: b64.EncodeToString(k)}, nil
+	case ed25519.PrivateKey:
+		j, _ := NewJWK(k.Public())
+		j.D = b64.EncodeToString(k.Seed())
+		return j, nil
+	}
+	return nil, ErrUnsupportedKey
+}
+
+// ParseJWK parses a JSON encoded JWK.
+func ParseJWK(data []byte) (*JWK, error) {
+	j := new(JWK)
+	if err := json.Unmarshal(data, j); err != nil {
+		return nil, ErrInvalidJWK
+	}
+	return j, nil
+}
+
+// IsPrivate reports whether j holds a private key.
+func (j *JWK) IsPrivate() bool {
+	return j.D != ""
+}
+
+// Public returns j without its private members.
+func (j *JWK) Public() *JWK {
+	return &JWK{Kty: j.Kty, Kid: j.Kid, Use: j.Use, Alg: j.Alg, N: j.N, E: j.E, Crv: j.Crv, X: j.X, Y: j.Y}
+}
+
+// Key returns the crypto key of j: *rsa.PublicKey, *rsa.PrivateKey,
+// *ecdsa.PublicKey, *ecdsa.PrivateKey, ed25519.PublicKey or
+// ed25519.PrivateKey.
+func (j *JWK) Key() (interface{}, error) {
+	switch j.Kty {
+	case "RSA":
+		return j.rsaKey()
+	case "EC":
+		return j.ecKey()
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return nil, ErrUnsupportedKey
+		}
+		x, err := b64.DecodeString(j.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, ErrInvalidJWK
+		}
+		if !j.IsPrivate() {
+			return ed25519.PublicKey(x), nil
+		}
+		d, err := b64.DecodeString(j.D)
+		if err != nil || len(d) != ed25519.SeedSize {
+			return nil, ErrInvalidJWK
+		}
+		priv := ed25519.NewKeyFromSeed(d)
+		if !priv.Public().(ed25519.PublicKey).Equal(ed25519.PublicKey(x)) {
+			return nil, ErrInvalidJWK
+		}
+		return priv, nil
+	}
+	return nil, ErrUnsupportedKey
+}
+
+func (j *JWK) rsaKey() (interface{}, error) {
+	n, err := decodeInt(j.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeInt(j.E)
+	if err != nil || !e.IsInt64() || e.Int64() > 1<<31-1 {
+		return nil, ErrInvalidJWK
+	}
+	pub := &rsa.PublicKey{N: n, E: int(e.Int64())}
+	if !j.IsPrivate() {
+		return pub, nil
+	}
+	d, err := decodeInt(j.D)
+	if err != nil {
+		return nil, err
+	}
+	priv := &rsa.PrivateKey{PublicKey: *pub, D: d}
+	if j.P == "" || j.Q == "" {
+		return nil, ErrInvalidJWK
+	}
+	p, err := decodeInt(j.P)
+	if err != nil {
+		return nil, err
+	}
+	q, err := decodeInt(j.Q)
+	if err != nil {
+		return nil, err
+	}
+	priv.Primes = []*big.Int{p, q}
+	if err := priv.Validate(); err != nil {
+		return nil, ErrInvalidJWK
+	}
+	priv.Precompute()
+	return priv, nil
+}
+
+func (j *JWK) ecKey() (interface{}, error) {
+	curve, ok := jwkCurves[j.Crv]
+	if !ok {
+		return nil, ErrUnsupportedKey
+	}
+	x, err := decodeInt(j.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeInt(j.Y)
+	if err != nil {
+		return nil, err
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, ErrInvalidJWK
+	}
+	pub := &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+	if !j.IsPrivate() {
+		return pub, nil
+	}
+	d, err := decodeInt(j.D)
+	if err != nil {
+		return nil, err
+	}
+	priv := &ecdsa.PrivateKey{PublicKey: *pub, D: d}
+	if px, py := curve.ScalarBaseMult(d.Bytes()); px.Cmp(x) != 0 || py.Cmp(y) != 0 {
+		return nil, ErrInvalidJWK
+	}
+	return priv, nil
+}
+
+// Thumbprint returns the RFC 7638 SHA-256 thumbprint of j, base64url
+// encoded, commonly used as its kid.
+func (j *JWK) Thumbprint() (string, error) {
+	var members interface{}
+	switch j.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{j.E, j.Kty, j.N}
+	case "EC":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{j.Crv, j.Kty, j.X, j.Y}
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{j.Crv, j.Kty, j.X}
+	default:
+		return "", ErrUnsupportedKey
+	}
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return b64.EncodeToString(sum[:]), nil
+}
+
+// publicOf returns the public key of a private key.
+func publicOf(key interface{}) (crypto.PublicKey, error) {
+	if s, ok := normalize(key).(crypto.Signer); ok {
+		return s.Public(), nil
+	}
+	return nil, ErrUnsupportedKey
+}
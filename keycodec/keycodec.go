@@ -0,0 +1,348 @@
+// Package keycodec reads and writes RSA, ECDSA and Ed25519 keys in the
+// common encodings: PKCS#1, SEC 1, PKCS#8, PKIX, OpenSSH, JWK and password
+// protected PKCS#8.
+//
+// The Parse functions detect the encoding: PEM blocks by type, a JSON
+// object as JWK, "ssh-" and "ecdsa-" lines as OpenSSH public keys, and
+// anything else as DER, raw or base64 encoded.
+package keycodec
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Format is a key encoding.
+type Format string
+
+const (
+	PKCS1   Format = "pkcs1"   // RSA PRIVATE KEY, RSA PUBLIC KEY
+	SEC1    Format = "sec1"    // EC PRIVATE KEY
+	PKCS8   Format = "pkcs8"   // PRIVATE KEY
+	PKIX    Format = "pkix"    // PUBLIC KEY
+	OpenSSH Format = "openssh" // OPENSSH PRIVATE KEY, authorized_keys line
+	JWKJSON Format = "jwk"     // JSON Web Key
+)
+
+var (
+	ErrUnsupportedKey    = errors.New("keycodec: unsupported key type")
+	ErrUnsupportedFormat = errors.New("keycodec: unsupported key format")
+	ErrUnsupportedCipher = errors.New("keycodec: unsupported key encryption")
+	ErrInvalidJWK        = errors.New("keycodec: invalid jwk")
+	ErrInvalidKey        = errors.New("keycodec: unrecognized key encoding")
+	ErrPasswordRequired  = errors.New("keycodec: key is password protected")
+	ErrDecrypt           = errors.New("keycodec: could not decrypt key, wrong password")
+)
+
+// normalize turns the pointer forms some parsers return into the value
+// forms used by crypto/ed25519.
+func normalize(key interface{}) interface{} {
+	switch k := key.(type) {
+	case *ed25519.PrivateKey:
+		return *k
+	case *ed25519.PublicKey:
+		return *k
+	}
+	return key
+}
+
+func checkPrivate(key interface{}) (crypto.Signer, error) {
+	switch k := normalize(key).(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+		return k.(crypto.Signer), nil
+	}
+	return nil, ErrUnsupportedKey
+}
+
+func checkPublic(key interface{}) (crypto.PublicKey, error) {
+	switch k := normalize(key).(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		return k, nil
+	}
+	return nil, ErrUnsupportedKey
+}
+
+// ParsePrivateKey parses an unencrypted private key. The result is a
+// *rsa.PrivateKey, *ecdsa.PrivateKey or ed25519.PrivateKey.
+func ParsePrivateKey(data []byte) (crypto.Signer, error) {
+	return ParsePrivateKeyWithPassword(data, nil)
+}
+
+// ParsePrivateKeyWithPassword parses a private key, decrypting ENCRYPTED
+// PRIVATE KEY blocks and encrypted OpenSSH keys with password.
+func ParsePrivateKeyWithPassword(data, password []byte) (crypto.Signer, error) {
+	data = bytes.TrimSpace(data)
+	if isJSON(data) {
+		return parseJWKPrivate(data)
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		return parsePrivatePEM(block, data, password)
+	}
+	der, err := decodeDER(data)
+	if err != nil {
+		return nil, err
+	}
+	return parsePrivateDER(der)
+}
+
+func parsePrivatePEM(block *pem.Block, data, password []byte) (crypto.Signer, error) {
+	switch block.Type {
+	case "ENCRYPTED PRIVATE KEY":
+		if password == nil {
+			return nil, ErrPasswordRequired
+		}
+		der, err := decryptPKCS8(block.Bytes, password)
+		if err != nil {
+			return nil, err
+		}
+		key, err := x509.ParsePKCS8PrivateKey(der)
+		if err != nil {
+			return nil, ErrDecrypt
+		}
+		return checkPrivate(key)
+	case "OPENSSH PRIVATE KEY":
+		var key interface{}
+		var err error
+		if password == nil {
+			key, err = ssh.ParseRawPrivateKey(data)
+		} else {
+			key, err = ssh.ParseRawPrivateKeyWithPassphrase(data, password)
+		}
+		var missing *ssh.PassphraseMissingError
+		switch {
+		case errors.As(err, &missing):
+			return nil, ErrPasswordRequired
+		case errors.Is(err, x509.IncorrectPasswordError):
+			return nil, ErrDecrypt
+		case err != nil:
+			return nil, err
+		}
+		return checkPrivate(key)
+	case "RSA PRIVATE KEY", "EC PRIVATE KEY", "PRIVATE KEY":
+		if _, ok := block.Headers["DEK-Info"]; ok {
+			// Legacy OpenSSL encryption is insecure and not supported.
+			return nil, ErrUnsupportedCipher
+		}
+		return parsePrivateDER(block.Bytes)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+func parsePrivateDER(der []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return checkPrivate(key)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	return nil, ErrInvalidKey
+}
+
+// ParsePublicKey parses a public key. The result is a *rsa.PublicKey,
+// *ecdsa.PublicKey or ed25519.PublicKey. Certificates and private keys
+// yield their public key.
+func ParsePublicKey(data []byte) (crypto.PublicKey, error) {
+	data = bytes.TrimSpace(data)
+	if isJSON(data) {
+		j, err := ParseJWK(data)
+		if err != nil {
+			return nil, err
+		}
+		key, err := j.Public().Key()
+		if err != nil {
+			return nil, err
+		}
+		return checkPublic(key)
+	}
+	if bytes.HasPrefix(data, []byte("ssh-")) || bytes.HasPrefix(data, []byte("ecdsa-")) {
+		pub, _, _, _, err := ssh.ParseAuthorizedKey(data)
+		if err != nil {
+			return nil, err
+		}
+		if c, ok := pub.(ssh.CryptoPublicKey); ok {
+			return checkPublic(c.CryptoPublicKey())
+		}
+		return nil, ErrUnsupportedKey
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		switch block.Type {
+		case "PUBLIC KEY", "RSA PUBLIC KEY":
+			return parsePublicDER(block.Bytes)
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			return checkPublic(cert.PublicKey)
+		}
+		priv, err := parsePrivatePEM(block, data, nil)
+		if err != nil {
+			return nil, err
+		}
+		return priv.Public(), nil
+	}
+	der, err := decodeDER(data)
+	if err != nil {
+		return nil, err
+	}
+	return parsePublicDER(der)
+}
+
+func parsePublicDER(der []byte) (crypto.PublicKey, error) {
+	if key, err := x509.ParsePKIXPublicKey(der); err == nil {
+		return checkPublic(key)
+	}
+	if key, err := x509.ParsePKCS1PublicKey(der); err == nil {
+		return key, nil
+	}
+	return nil, ErrInvalidKey
+}
+
+func parseJWKPrivate(data []byte) (crypto.Signer, error) {
+	j, err := ParseJWK(data)
+	if err != nil {
+		return nil, err
+	}
+	if !j.IsPrivate() {
+		return nil, ErrInvalidKey
+	}
+	key, err := j.Key()
+	if err != nil {
+		return nil, err
+	}
+	return checkPrivate(key)
+}
+
+func isJSON(data []byte) bool {
+	return len(data) > 0 && data[0] == '{'
+}
+
+// decodeDER accepts DER as is or base64 encoded.
+func decodeDER(data []byte) ([]byte, error) {
+	if len(data) > 0 && data[0] == 0x30 {
+		return data, nil
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if der, err := enc.DecodeString(string(data)); err == nil {
+			return der, nil
+		}
+	}
+	return nil, ErrInvalidKey
+}
+
+// MarshalPrivateKey encodes key as PEM, or JSON for JWKJSON. PKCS1 is
+// only valid for RSA keys and SEC1 only for ECDSA keys.
+func MarshalPrivateKey(key interface{}, format Format) ([]byte, error) {
+	priv, err := checkPrivate(key)
+	if err != nil {
+		return nil, err
+	}
+	var block *pem.Block
+	switch format {
+	case PKCS8:
+		der, err := x509.MarshalPKCS8PrivateKey(priv)
+		if err != nil {
+			return nil, err
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	case PKCS1:
+		k, ok := priv.(*rsa.PrivateKey)
+		if !ok {
+			return nil, ErrUnsupportedFormat
+		}
+		block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}
+	case SEC1:
+		k, ok := priv.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, ErrUnsupportedFormat
+		}
+		der, err := x509.MarshalECPrivateKey(k)
+		if err != nil {
+			return nil, err
+		}
+		block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+	case OpenSSH:
+		if block, err = ssh.MarshalPrivateKey(priv, ""); err != nil {
+			return nil, err
+		}
+	case JWKJSON:
+		j, err := NewJWK(priv)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(j)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	return pem.EncodeToMemory(block), nil
+}
+
+// MarshalEncryptedPrivateKey encodes key as a password protected PKCS#8
+// ENCRYPTED PRIVATE KEY, using PBKDF2-HMAC-SHA256 and AES-256-CBC.
+func MarshalEncryptedPrivateKey(key interface{}, password []byte) ([]byte, error) {
+	priv, err := checkPrivate(key)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	enc, err := encryptPKCS8(der, password)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: enc}), nil
+}
+
+// MarshalPublicKey encodes key, or the public key of a private key, as
+// PEM, an authorized_keys line for OpenSSH or JSON for JWKJSON. PKCS1 is
+// only valid for RSA keys.
+func MarshalPublicKey(key interface{}, format Format) ([]byte, error) {
+	pub, err := checkPublic(key)
+	if err != nil {
+		if pub, err = publicOf(key); err != nil {
+			return nil, err
+		}
+	}
+	switch format {
+	case PKIX:
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+	case PKCS1:
+		k, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return nil, ErrUnsupportedFormat
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(k)}), nil
+	case OpenSSH:
+		k, err := ssh.NewPublicKey(pub)
+		if err != nil {
+			return nil, err
+		}
+		return ssh.MarshalAuthorizedKey(k), nil
+	case JWKJSON:
+		j, err := NewJWK(pub)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(j)
+	}
+	return nil, ErrUnsupportedFormat
+}
@@ -0,0 +1,167 @@
+package keycodec
+
+// This file implements password protected PKCS#8 (RFC 5958) with PBES2
+// (RFC 8018), as written by openssl pkcs8 -topk8 -v2 aes-256-cbc.
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"hash"
+	"io"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// PBKDF2Iterations is the iteration count of newly encrypted keys.
+var PBKDF2Iterations = 100000
+
+var (
+	oidPBES2  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+
+	oidAES128CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+func prfHash(prf pkix.AlgorithmIdentifier) (func() hash.Hash, error) {
+	switch {
+	case len(prf.Algorithm) == 0, prf.Algorithm.Equal(oidHMACWithSHA1):
+		return sha1.New, nil
+	case prf.Algorithm.Equal(oidHMACWithSHA256):
+		return sha256.New, nil
+	case prf.Algorithm.Equal(oidHMACWithSHA384):
+		return sha512.New384, nil
+	case prf.Algorithm.Equal(oidHMACWithSHA512):
+		return sha512.New, nil
+	}
+	return nil, ErrUnsupportedCipher
+}
+
+func aesKeyLen(oid asn1.ObjectIdentifier) int {
+	switch {
+	case oid.Equal(oidAES128CBC):
+		return 16
+	case oid.Equal(oidAES192CBC):
+		return 24
+	case oid.Equal(oidAES256CBC):
+		return 32
+	}
+	return 0
+}
+
+// decryptPKCS8 returns the PKCS#8 DER inside an ENCRYPTED PRIVATE KEY.
+func decryptPKCS8(der, password []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, err
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, ErrUnsupportedCipher
+	}
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, err
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, ErrUnsupportedCipher
+	}
+	var kdf pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		return nil, err
+	}
+	h, err := prfHash(kdf.PRF)
+	if err != nil {
+		return nil, err
+	}
+	keyLen := aesKeyLen(params.EncryptionScheme.Algorithm)
+	if keyLen == 0 || (kdf.KeyLength != 0 && kdf.KeyLength != keyLen) {
+		return nil, ErrUnsupportedCipher
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, err
+	}
+	if len(iv) != aes.BlockSize || len(info.EncryptedData) == 0 || len(info.EncryptedData)%aes.BlockSize != 0 {
+		return nil, ErrDecrypt
+	}
+
+	block, _ := aes.NewCipher(pbkdf2.Key(password, kdf.Salt, kdf.IterationCount, keyLen, h))
+	out := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, info.EncryptedData)
+	// A wrong password almost always leaves invalid padding.
+	pad := int(out[len(out)-1])
+	if pad == 0 || pad > aes.BlockSize || !bytes.Equal(out[len(out)-pad:], bytes.Repeat([]byte{byte(pad)}, pad)) {
+		return nil, ErrDecrypt
+	}
+	return out[:len(out)-pad], nil
+}
+
+// encryptPKCS8 wraps PKCS#8 DER with PBKDF2-HMAC-SHA256 and AES-256-CBC.
+func encryptPKCS8(der, password []byte) ([]byte, error) {
+	salt := make([]byte, 16)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, err
+	}
+	kdf, err := asn1.Marshal(pbkdf2Params{
+		Salt:           salt,
+		IterationCount: PBKDF2Iterations,
+		PRF:            pkix.AlgorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1.NullRawValue},
+	})
+	if err != nil {
+		return nil, err
+	}
+	ivDER, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdf}},
+		EncryptionScheme:  pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivDER}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pad := aes.BlockSize - len(der)%aes.BlockSize
+	data := append(append([]byte{}, der...), bytes.Repeat([]byte{byte(pad)}, pad)...)
+	block, _ := aes.NewCipher(pbkdf2.Key(password, salt, PBKDF2Iterations, 32, sha256.New))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, data)
+	return asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}},
+		EncryptedData: data,
+	})
+}
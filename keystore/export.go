@@ -0,0 +1,105 @@
+package keystore
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/suiguo/hwlib/bip39"
+)
+
+// Import encrypts key material given in the plaintext form the other
+// packages of this module produce:
+//
+//   - KeySecp256k1, KeyP256: hex private key (ecies PrivateKey.String)
+//   - KeyEd25519: hex of the 64 byte private key or 32 byte seed
+//   - KeyRSA: PEM private key, PKCS#8 (rsa.GenerateRSAKey) or PKCS#1
+//   - KeySeed: hex seed (bip39.NewSeed)
+//   - KeyMnemonic: bip39 mnemonic in any bundled language
+func Import(keyType KeyType, material string, password string, kdf KDF) ([]byte, error) {
+	secret, err := parseMaterial(keyType, material)
+	if err != nil {
+		return nil, err
+	}
+	return Encrypt(keyType, secret, password, kdf)
+}
+
+// Export decrypts a key file and returns its material in the form accepted
+// by Import.
+func Export(keyjson []byte, password string) (KeyType, string, error) {
+	keyType, secret, err := Decrypt(keyjson, password)
+	if err != nil {
+		return "", "", err
+	}
+	switch keyType {
+	case KeySecp256k1, KeyP256, KeyEd25519, KeySeed:
+		return keyType, hex.EncodeToString(secret), nil
+	case KeyRSA:
+		return keyType, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: secret})), nil
+	case KeyMnemonic:
+		return keyType, string(secret), nil
+	}
+	return "", "", ErrKeyType
+}
+
+func parseMaterial(keyType KeyType, material string) ([]byte, error) {
+	material = strings.TrimSpace(material)
+	switch keyType {
+	case KeySecp256k1:
+		key, err := crypto.HexToECDSA(strings.TrimPrefix(material, "0x"))
+		if err != nil {
+			return nil, err
+		}
+		return crypto.FromECDSA(key), nil
+	case KeyP256:
+		raw, err := hex.DecodeString(strings.TrimPrefix(material, "0x"))
+		if err != nil || len(raw) == 0 || len(raw) > 32 {
+			return nil, errors.New("invalid p256 private key")
+		}
+		// ecies.PrivateKey.String drops leading zero bytes.
+		out := make([]byte, 32)
+		copy(out[32-len(raw):], raw)
+		return out, nil
+	case KeyEd25519:
+		raw, err := hex.DecodeString(material)
+		if err != nil {
+			return nil, err
+		}
+		switch len(raw) {
+		case ed25519.SeedSize:
+			return ed25519.NewKeyFromSeed(raw), nil
+		case ed25519.PrivateKeySize:
+			return raw, nil
+		}
+		return nil, errors.New("invalid ed25519 private key")
+	case KeyRSA:
+		block, _ := pem.Decode([]byte(material))
+		if block == nil {
+			return nil, fmt.Errorf("block is nil")
+		}
+		if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+			return x509.MarshalPKCS8PrivateKey(key)
+		}
+		if _, err := x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+			return nil, err
+		}
+		return block.Bytes, nil
+	case KeySeed:
+		return hex.DecodeString(material)
+	case KeyMnemonic:
+		list, err := bip39.DetectLanguage(material)
+		if err != nil {
+			return nil, err
+		}
+		if !bip39.IsMnemonicValidIn(material, list) {
+			return nil, bip39.ErrInvalidMnemonic
+		}
+		return []byte(material), nil
+	}
+	return nil, ErrKeyType
+}
@@ -0,0 +1,133 @@
+package keystore
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// KeyType is the kind of material held by a generic key file.
+type KeyType string
+
+const (
+	KeySecp256k1 KeyType = "secp256k1"
+	KeyP256      KeyType = "p256"
+	KeyEd25519   KeyType = "ed25519"
+	KeyRSA       KeyType = "rsa"
+	KeySeed      KeyType = "bip39-seed"
+	KeyMnemonic  KeyType = "bip39-mnemonic"
+)
+
+// EncryptKey encrypts a secp256k1 key into a V3 key file compatible with
+// Ethereum clients.
+func EncryptKey(key *ecdsa.PrivateKey, password string, kdf KDF) ([]byte, error) {
+	if key.Curve != crypto.S256() {
+		return nil, ErrKeyType
+	}
+	c, err := encryptData(crypto.FromECDSA(key), password, kdf)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&keyJSON{
+		Address: addressOf(key),
+		Crypto:  c,
+		ID:      newID(),
+		Version: version,
+	})
+}
+
+// DecryptKey decrypts a V3 key file holding a secp256k1 key.
+func DecryptKey(keyjson []byte, password string) (*ecdsa.PrivateKey, error) {
+	k, err := parseKeyJSON(keyjson)
+	if err != nil {
+		return nil, err
+	}
+	if k.Type != "" && k.Type != KeySecp256k1 {
+		return nil, ErrKeyType
+	}
+	plain, err := decryptData(k.Crypto, password)
+	if err != nil {
+		return nil, err
+	}
+	key, err := crypto.ToECDSA(plain)
+	if err != nil {
+		return nil, err
+	}
+	if k.Address != "" && !strings.EqualFold(strings.TrimPrefix(k.Address, "0x"), addressOf(key)) {
+		return nil, ErrDecrypt
+	}
+	return key, nil
+}
+
+// Encrypt encrypts raw key material of the given type. secp256k1 keys are
+// written as plain V3 files, like EncryptKey.
+func Encrypt(keyType KeyType, secret []byte, password string, kdf KDF) ([]byte, error) {
+	if keyType == KeySecp256k1 {
+		key, err := crypto.ToECDSA(secret)
+		if err != nil {
+			return nil, err
+		}
+		return EncryptKey(key, password, kdf)
+	}
+	c, err := encryptData(secret, password, kdf)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&keyJSON{
+		Crypto:  c,
+		ID:      newID(),
+		Version: version,
+		Type:    keyType,
+	})
+}
+
+// Decrypt decrypts any key file and returns its type and raw material.
+// Plain V3 files report KeySecp256k1.
+func Decrypt(keyjson []byte, password string) (KeyType, []byte, error) {
+	k, err := parseKeyJSON(keyjson)
+	if err != nil {
+		return "", nil, err
+	}
+	plain, err := decryptData(k.Crypto, password)
+	if err != nil {
+		return "", nil, err
+	}
+	if k.Type == "" {
+		return KeySecp256k1, plain, nil
+	}
+	return k.Type, plain, nil
+}
+
+// ChangePassword re-encrypts a key file under a new password, keeping its id,
+// address and type.
+func ChangePassword(keyjson []byte, oldPassword, newPassword string, kdf KDF) ([]byte, error) {
+	k, err := parseKeyJSON(keyjson)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := decryptData(k.Crypto, oldPassword)
+	if err != nil {
+		return nil, err
+	}
+	k.Crypto, err = encryptData(plain, newPassword, kdf)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(k)
+}
+
+// Address returns the address field of a V3 key file without decrypting it.
+func Address(keyjson []byte) (string, error) {
+	k, err := parseKeyJSON(keyjson)
+	if err != nil {
+		return "", err
+	}
+	return k.Address, nil
+}
+
+func addressOf(key *ecdsa.PrivateKey) string {
+	return hex.EncodeToString(crypto.PubkeyToAddress(key.PublicKey).Bytes())
+}
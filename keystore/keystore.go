@@ -0,0 +1,243 @@
+// Package keystore encrypts private key material with a password in the
+// Web3 Secret Storage Definition (version 3) JSON format: scrypt or PBKDF2
+// key derivation, AES-128-CTR encryption and a Keccak-256 MAC.
+//
+// secp256k1 keys are written as plain V3 files readable by Ethereum clients.
+// Other material (P-256 ecies keys, Ed25519 and RSA keys, bip39 seeds and
+// mnemonics) uses the same crypto section with an extra "type" field.
+//
+// The spec can be found at
+// https://ethereum.org/en/developers/docs/data-structures-and-encoding/web3-secret-storage/
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	version = 3
+
+	KDFScrypt = "scrypt"
+	KDFPBKDF2 = "pbkdf2"
+
+	cipherAES128CTR = "aes-128-ctr"
+	prfHmacSHA256   = "hmac-sha256"
+
+	dkLen   = 32
+	saltLen = 32
+
+	// Bounds on kdfparams read from a file, so a crafted file cannot make
+	// decryption take unbounded memory or time.
+	maxDKLen        = 64
+	maxScryptMemory = 1 << 30 // 128 * n * r bytes
+	maxScryptP      = 16
+	maxPBKDF2C      = 1 << 24
+)
+
+// KDF selects the key derivation function and its cost.
+type KDF struct {
+	Name    string
+	ScryptN int
+	ScryptR int
+	ScryptP int
+	PBKDF2C int
+}
+
+var (
+	// StandardScrypt matches the default of Ethereum clients, about 1s and
+	// 256MB of memory per operation.
+	StandardScrypt = KDF{Name: KDFScrypt, ScryptN: 1 << 18, ScryptR: 8, ScryptP: 1}
+
+	// LightScrypt is cheaper, for tests and low powered devices.
+	LightScrypt = KDF{Name: KDFScrypt, ScryptN: 1 << 12, ScryptR: 8, ScryptP: 6}
+
+	// StandardPBKDF2 uses PBKDF2-HMAC-SHA256.
+	StandardPBKDF2 = KDF{Name: KDFPBKDF2, PBKDF2C: 262144}
+)
+
+var (
+	// ErrDecrypt is returned when the MAC does not match, usually because of
+	// a wrong password.
+	ErrDecrypt = errors.New("Could not decrypt key with given password")
+
+	// ErrVersion is returned for key files other than version 3.
+	ErrVersion = errors.New("Unsupported keystore version")
+
+	// ErrKeyType is returned when the file holds a different type of key
+	// than requested.
+	ErrKeyType = errors.New("Unexpected key type")
+)
+
+type cipherparamsJSON struct {
+	IV string `json:"iv"`
+}
+
+type cryptoJSON struct {
+	Cipher       string                 `json:"cipher"`
+	CipherText   string                 `json:"ciphertext"`
+	CipherParams cipherparamsJSON       `json:"cipherparams"`
+	KDF          string                 `json:"kdf"`
+	KDFParams    map[string]interface{} `json:"kdfparams"`
+	MAC          string                 `json:"mac"`
+}
+
+type keyJSON struct {
+	Address string     `json:"address,omitempty"`
+	Crypto  cryptoJSON `json:"crypto"`
+	ID      string     `json:"id"`
+	Version int        `json:"version"`
+	// Type is empty for plain V3 secp256k1 files.
+	Type KeyType `json:"type,omitempty"`
+}
+
+// encryptData encrypts data with a key derived from password.
+func encryptData(data []byte, password string, kdf KDF) (cryptoJSON, error) {
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return cryptoJSON{}, err
+	}
+	params := map[string]interface{}{
+		"dklen": dkLen,
+		"salt":  hex.EncodeToString(salt),
+	}
+	switch kdf.Name {
+	case KDFScrypt:
+		params["n"] = kdf.ScryptN
+		params["r"] = kdf.ScryptR
+		params["p"] = kdf.ScryptP
+	case KDFPBKDF2:
+		params["c"] = kdf.PBKDF2C
+		params["prf"] = prfHmacSHA256
+	default:
+		return cryptoJSON{}, fmt.Errorf("unsupported kdf %s", kdf.Name)
+	}
+	derived, err := deriveKey(password, kdf.Name, params)
+	if err != nil {
+		return cryptoJSON{}, err
+	}
+
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return cryptoJSON{}, err
+	}
+	cipherText, err := aesCTR(derived[:16], iv, data)
+	if err != nil {
+		return cryptoJSON{}, err
+	}
+	return cryptoJSON{
+		Cipher:       cipherAES128CTR,
+		CipherText:   hex.EncodeToString(cipherText),
+		CipherParams: cipherparamsJSON{IV: hex.EncodeToString(iv)},
+		KDF:          kdf.Name,
+		KDFParams:    params,
+		MAC:          hex.EncodeToString(crypto.Keccak256(derived[16:32], cipherText)),
+	}, nil
+}
+
+// decryptData checks the MAC and decrypts the crypto section.
+func decryptData(c cryptoJSON, password string) ([]byte, error) {
+	if c.Cipher != cipherAES128CTR {
+		return nil, fmt.Errorf("unsupported cipher %s", c.Cipher)
+	}
+	mac, err := hex.DecodeString(c.MAC)
+	if err != nil {
+		return nil, err
+	}
+	iv, err := hex.DecodeString(c.CipherParams.IV)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != aes.BlockSize {
+		return nil, fmt.Errorf("invalid iv length %d", len(iv))
+	}
+	cipherText, err := hex.DecodeString(c.CipherText)
+	if err != nil {
+		return nil, err
+	}
+	derived, err := deriveKey(password, c.KDF, c.KDFParams)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(crypto.Keccak256(derived[16:32], cipherText), mac) != 1 {
+		return nil, ErrDecrypt
+	}
+	return aesCTR(derived[:16], iv, cipherText)
+}
+
+func deriveKey(password string, kdf string, params map[string]interface{}) ([]byte, error) {
+	salt, err := hex.DecodeString(fmt.Sprint(params["salt"]))
+	if err != nil {
+		return nil, err
+	}
+	keyLen := intParam(params, "dklen")
+	if keyLen < 32 || keyLen > maxDKLen {
+		return nil, fmt.Errorf("invalid dklen %d", keyLen)
+	}
+	switch kdf {
+	case KDFScrypt:
+		n, r, p := intParam(params, "n"), intParam(params, "r"), intParam(params, "p")
+		if n <= 1 || n&(n-1) != 0 || r < 1 || p < 1 || p > maxScryptP || n > maxScryptMemory/128/r {
+			return nil, fmt.Errorf("invalid scrypt params n=%d r=%d p=%d", n, r, p)
+		}
+		return scrypt.Key([]byte(password), salt, n, r, p, keyLen)
+	case KDFPBKDF2:
+		if prf := fmt.Sprint(params["prf"]); prf != prfHmacSHA256 {
+			return nil, fmt.Errorf("unsupported pbkdf2 prf %s", prf)
+		}
+		c := intParam(params, "c")
+		if c < 1 || c > maxPBKDF2C {
+			return nil, fmt.Errorf("invalid pbkdf2 count %d", c)
+		}
+		return pbkdf2.Key([]byte(password), salt, c, keyLen, sha256.New), nil
+	}
+	return nil, fmt.Errorf("unsupported kdf %s", kdf)
+}
+
+// intParam reads a number from kdfparams, which json decodes as float64.
+func intParam(params map[string]interface{}, name string) int {
+	switch v := params[name].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
+
+func aesCTR(key, iv, in []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(in))
+	cipher.NewCTR(block, iv).XORKeyStream(out, in)
+	return out, nil
+}
+
+func parseKeyJSON(keyjson []byte) (*keyJSON, error) {
+	k := new(keyJSON)
+	if err := json.Unmarshal(keyjson, k); err != nil {
+		return nil, err
+	}
+	if k.Version != version {
+		return nil, ErrVersion
+	}
+	return k, nil
+}
+
+func newID() string {
+	return uuid.NewString()
+}
@@ -0,0 +1,96 @@
+package keystore
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Test vectors from the Web3 Secret Storage Definition.
+func TestSpecVectors(t *testing.T) {
+	vectors := []string{
+		`{"crypto":{"cipher":"aes-128-ctr","cipherparams":{"iv":"6087dab2f9fdbbfaddc31a909735c1e6"},"ciphertext":"5318b4d5bcd28de64ee5559e671353e16f075ecae9f99c7a79a38af5f869aa46","kdf":"pbkdf2","kdfparams":{"c":262144,"dklen":32,"prf":"hmac-sha256","salt":"ae3cd4e7013836a3df6bd7241b12db061dbe2c6785853cce422d148a624ce0bd"},"mac":"517ead924a9d0dc3124507e3393d175ce3ff7c1e96529c6c555ce9e51205e9b2"},"id":"3198bc9c-6672-5ab3-d995-4942343ae5b6","version":3}`,
+		`{"crypto":{"cipher":"aes-128-ctr","cipherparams":{"iv":"83dbcc02d8ccb40e466191a123791e0e"},"ciphertext":"d172bf743a674da9cdad04534d56926ef8358534d458fffccd4e6ad2fbde479c","kdf":"scrypt","kdfparams":{"dklen":32,"n":262144,"r":1,"p":8,"salt":"ab0c7876052600dd703518d6fc3fe8984592145b591fc8fb5c6d43190334ba19"},"mac":"2103ac29920d71da29f15d75b4a16dbe95cfd7ff8faea1056c33131d846e3097"},"id":"3198bc9c-6672-5ab3-d995-4942343ae5b6","version":3}`,
+	}
+	for _, v := range vectors {
+		key, err := DecryptKey([]byte(v), "testpassword")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(crypto.FromECDSA(key)) != "7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d" {
+			t.Fatal("private key mismatch")
+		}
+		if _, err := DecryptKey([]byte(v), "wrong"); err != ErrDecrypt {
+			t.Fatal("wrong password should fail")
+		}
+	}
+}
+
+func TestImportExport(t *testing.T) {
+	tests := []struct {
+		keyType  KeyType
+		material string
+	}{
+		{KeySecp256k1, "7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d"},
+		{KeyP256, "00c2f3a1b1d6e08e34bba4e0b58d2a1c9c5f7e4d3b2a1908f7e6d5c4b3a29181"},
+		{KeySeed, "5eb00bbddcf069084889a8ab9155568165f5c453ccb85e70811aaed6f6da5fc19a5ac40b389cd370d086206dec8aa6c43daea6690f20ad3d8d48b2d2ce9e38e4"},
+		{KeyMnemonic, "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"},
+	}
+	for _, test := range tests {
+		for _, kdf := range []KDF{LightScrypt, {Name: KDFPBKDF2, PBKDF2C: 1024}} {
+			keyjson, err := Import(test.keyType, test.material, "foo", kdf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			keyjson, err = ChangePassword(keyjson, "foo", "bar", kdf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, _, err := Export(keyjson, "foo"); err != ErrDecrypt {
+				t.Fatalf("%s: old password still works", test.keyType)
+			}
+			keyType, material, err := Export(keyjson, "bar")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if keyType != test.keyType || material != test.material {
+				t.Fatalf("%s: got %s %s", test.keyType, keyType, material)
+			}
+		}
+	}
+}
+
+func TestRejectsBadParams(t *testing.T) {
+	keyjson, err := Import(KeySecp256k1, "7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d", "foo", LightScrypt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]func(c *cryptoJSON){
+		"short iv":         func(c *cryptoJSON) { c.CipherParams.IV = c.CipherParams.IV[:30] },
+		"long iv":          func(c *cryptoJSON) { c.CipherParams.IV += "00" },
+		"large dklen":      func(c *cryptoJSON) { c.KDFParams["dklen"] = 1 << 30 },
+		"large n":          func(c *cryptoJSON) { c.KDFParams["n"] = 1 << 40 },
+		"n not power of 2": func(c *cryptoJSON) { c.KDFParams["n"] = 3000 },
+		"large r":          func(c *cryptoJSON) { c.KDFParams["r"] = 1 << 20 },
+		"large p":          func(c *cryptoJSON) { c.KDFParams["p"] = 1 << 20 },
+		"zero r":           func(c *cryptoJSON) { c.KDFParams["r"] = 0 },
+		"large c": func(c *cryptoJSON) {
+			c.KDF = KDFPBKDF2
+			c.KDFParams["prf"] = prfHmacSHA256
+			c.KDFParams["c"] = 1 << 40
+		},
+	}
+	for name, tamper := range cases {
+		var k keyJSON
+		if err := json.Unmarshal(keyjson, &k); err != nil {
+			t.Fatal(err)
+		}
+		tamper(&k.Crypto)
+		tampered, _ := json.Marshal(k)
+		if _, _, err := Export(tampered, "foo"); err == nil || err == ErrDecrypt {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/suiguo/hwlib/gjwt"
+)
+
+const (
+	// ClaimsKey 验证通过后claims存放在gin.Context中的key
+	ClaimsKey     = "hwlib.auth.claims"
+	defaultHeader = "Authorization"
+)
+
+var (
+	ErrNoToken   = errors.New("auth: token not found")
+	ErrNoAuth    = errors.New("auth: authenticator not set")
+	ErrForbidden = errors.New("auth: insufficient scope or role")
+	errBadBearer = errors.New("auth: malformed authorization header")
+)
+
+// AuthClaims 中间件解析的claims
+// scope为空格分隔的字符串(RFC 8693) roles为角色列表 data与gjwt.SignData兼容
+type AuthClaims struct {
+	Data  interface{} `json:"data,omitempty"`
+	Scope string      `json:"scope,omitempty"`
+	Roles []string    `json:"roles,omitempty"`
+	gojwt.RegisteredClaims
+}
+
+// Scopes 拆分后的scope
+func (c *AuthClaims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+func (c *AuthClaims) HasScope(scope string) bool {
+	for _, s := range c.Scopes() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *AuthClaims) HasRole(role string) bool {
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Auth 路由的鉴权要求 Url.Auth为nil时不需要登录
+// Scopes必须全部具备 Roles具备任意一个即可 都为空时只要求令牌有效
+type Auth struct {
+	Scopes []string
+	Roles  []string
+}
+
+func (a *Auth) allow(c *AuthClaims) bool {
+	for _, s := range a.Scopes {
+		if !c.HasScope(s) {
+			return false
+		}
+	}
+	if len(a.Roles) == 0 {
+		return true
+	}
+	for _, r := range a.Roles {
+		if c.HasRole(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator 从请求中提取令牌并用gjwt.ClaimsUtils验证
+type Authenticator struct {
+	jwt      gjwt.ClaimsUtils
+	header   string
+	cookie   string
+	opts     []gjwt.VerifyOption
+	check    func(ctx *gin.Context, claims *AuthClaims) error
+	onReject func(ctx *gin.Context, status int, err error)
+}
+
+type AuthOption func(*Authenticator)
+
+// WithCookie Authorization头没有令牌时再从该cookie读取
+func WithCookie(name string) AuthOption {
+	return func(a *Authenticator) {
+		a.cookie = name
+	}
+}
+
+// WithHeader 从其他请求头读取Bearer令牌 默认Authorization
+func WithHeader(name string) AuthOption {
+	return func(a *Authenticator) {
+		a.header = name
+	}
+}
+
+// WithVerifyOptions 验证时附加的选项 例如 gjwt.RequireIssuer
+func WithVerifyOptions(opts ...gjwt.VerifyOption) AuthOption {
+	return func(a *Authenticator) {
+		a.opts = append(a.opts, opts...)
+	}
+}
+
+// WithClaimsCheck 签名验证通过后的额外检查 例如查询令牌是否已撤销 返回错误时按401处理
+func WithClaimsCheck(check func(ctx *gin.Context, claims *AuthClaims) error) AuthOption {
+	return func(a *Authenticator) {
+		a.check = check
+	}
+}
+
+// WithReject 自定义拒绝时的响应 默认返回 {"code":status,"msg":err}
+func WithReject(f func(ctx *gin.Context, status int, err error)) AuthOption {
+	return func(a *Authenticator) {
+		a.onReject = f
+	}
+}
+
+// NewAuthenticator jwt可直接传入gjwt.NewJWT()或gjwt.NewJwtTool()
+func NewAuthenticator(jwt gjwt.ClaimsUtils, opts ...AuthOption) *Authenticator {
+	a := &Authenticator{
+		jwt:    jwt,
+		header: defaultHeader,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.onReject == nil {
+		a.onReject = func(ctx *gin.Context, status int, err error) {
+			ctx.AbortWithStatusJSON(status, gin.H{"code": status, "msg": err.Error()})
+		}
+	}
+	return a
+}
+
+// token 优先读取 Authorization: Bearer xxx 没有时读取cookie
+func (a *Authenticator) token(ctx *gin.Context) (string, error) {
+	if h := ctx.GetHeader(a.header); h != "" {
+		scheme, token, ok := strings.Cut(strings.TrimSpace(h), " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+			return "", errBadBearer
+		}
+		return strings.TrimSpace(token), nil
+	}
+	if a.cookie != "" {
+		if v, err := ctx.Cookie(a.cookie); err == nil && v != "" {
+			return v, nil
+		}
+	}
+	return "", ErrNoToken
+}
+
+// Authenticate 验证请求中的令牌 成功后claims写入gin.Context
+func (a *Authenticator) Authenticate(ctx *gin.Context) (*AuthClaims, error) {
+	token, err := a.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	claims := &AuthClaims{}
+	if err := a.jwt.ParseClaims(token, claims, a.opts...); err != nil {
+		return nil, err
+	}
+	if a.check != nil {
+		if err := a.check(ctx, claims); err != nil {
+			return nil, err
+		}
+	}
+	ctx.Set(ClaimsKey, claims)
+	return claims, nil
+}
+
+// Middleware 鉴权中间件 auth为nil时只要求令牌有效
+// 令牌缺失或无效返回401 scope/角色不足返回403
+func (a *Authenticator) Middleware(auth *Auth) gin.HandlerFunc {
+	if auth == nil {
+		auth = &Auth{}
+	}
+	return func(ctx *gin.Context) {
+		claims, err := a.Authenticate(ctx)
+		if err != nil {
+			a.onReject(ctx, http.StatusUnauthorized, err)
+			return
+		}
+		if !auth.allow(claims) {
+			a.onReject(ctx, http.StatusForbidden, ErrForbidden)
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// GetClaims 读取中间件写入的claims
+func GetClaims(ctx *gin.Context) (*AuthClaims, bool) {
+	v, ok := ctx.Get(ClaimsKey)
+	if !ok {
+		return nil, false
+	}
+	c, ok := v.(*AuthClaims)
+	return c, ok
+}
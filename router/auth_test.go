@@ -0,0 +1,122 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/suiguo/hwlib/gjwt"
+)
+
+type adminApi struct{}
+
+func (adminApi) Urls() []Url {
+	return []Url{
+		{Path: "/ping", Method: GET},
+		{Path: "/me", Method: GET, Auth: &Auth{}},
+		{Path: "/users", Method: POST, Auth: &Auth{Scopes: []string{"users:write"}, Roles: []string{"admin", "ops"}}},
+	}
+}
+
+func (adminApi) Router(path string) HandlerFunc {
+	switch path {
+	case "/me":
+		return func(c *gin.Context) any {
+			claims, _ := GetClaims(c)
+			return claims.Subject
+		}
+	}
+	return func(c *gin.Context) any { return "ok" }
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tool := gjwt.NewJwtTool()
+	tool.SetSecretPub(gjwt.MethodHMAC256, []byte("secret"))
+	tool.SetSecretPriv(gjwt.MethodHMAC256, []byte("secret"))
+	sign := func(scope string, roles ...string) string {
+		token, err := tool.SignClaims(gjwt.MethodHMAC256, &AuthClaims{
+			Scope: scope,
+			Roles: roles,
+			RegisteredClaims: gojwt.RegisteredClaims{
+				Subject:   "42",
+				ExpiresAt: gojwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	auth := NewAuthenticator(tool, WithCookie("token"))
+	g := NewRouter().SetAuth(auth).RegisterRouter("/api", adminApi{}).Package(gin.New())
+
+	cases := []struct {
+		method, path, header, cookie string
+		status                       int
+	}{
+		{"GET", "/api/ping", "", "", http.StatusOK},
+		{"GET", "/api/me", "", "", http.StatusUnauthorized},
+		{"GET", "/api/me", "Basic abc", "", http.StatusUnauthorized},
+		{"GET", "/api/me", "Bearer bad.token.here", "", http.StatusUnauthorized},
+		{"GET", "/api/me", "Bearer " + sign(""), "", http.StatusOK},
+		{"GET", "/api/me", "", sign(""), http.StatusOK},
+		{"POST", "/api/users", "Bearer " + sign("users:read", "admin"), "", http.StatusForbidden},
+		{"POST", "/api/users", "Bearer " + sign("users:read users:write", "guest"), "", http.StatusForbidden},
+		{"POST", "/api/users", "Bearer " + sign("users:read users:write", "ops"), "", http.StatusOK},
+	}
+	for i, c := range cases {
+		req := httptest.NewRequest(c.method, c.path, nil)
+		if c.header != "" {
+			req.Header.Set("Authorization", c.header)
+		}
+		if c.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: "token", Value: c.cookie})
+		}
+		w := httptest.NewRecorder()
+		g.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Fatalf("case %d: status %d want %d: %s", i, w.Code, c.status, w.Body.String())
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("protected route registered without authenticator")
+		}
+	}()
+	NewRouter().RegisterRouter("/api", adminApi{}).Package(gin.New())
+}
+
+func TestAuthFromNewJWT(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tool := gjwt.NewJWT()
+	tool.SetSecretPub(gjwt.MethodHMAC256, []byte("secret"))
+	tool.SetSecretPriv(gjwt.MethodHMAC256, []byte("secret"))
+	auth := NewAuthenticator(tool)
+	g := NewRouter().SetAuth(auth).RegisterRouter("/api", adminApi{}).Package(gin.New())
+
+	// SignData签发的令牌 data字段保留在AuthClaims.Data
+	token, err := tool.SignData(gjwt.MethodHMAC256, map[string]string{"name": "bob"}, time.Minute, gjwt.WithSubject("42"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest("GET", "/api/me", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "42") {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+	req = httptest.NewRequest("POST", "/api/users", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w = httptest.NewRecorder()
+	g.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("no scope: status %d", w.Code)
+	}
+}
@@ -21,6 +21,7 @@ const (
 
 type Router struct {
 	router map[RouterGroup][]EndPointGroup
+	auth   *Authenticator
 }
 
 type HandlerFunc func(c *gin.Context) any
@@ -28,6 +29,8 @@ type HandlerFunc func(c *gin.Context) any
 type Url struct {
 	Path   string
 	Method MethodName
+	// 不为nil时需要登录 并校验scope/角色
+	Auth *Auth
 }
 type EndPointGroup interface {
 	Urls() []Url
@@ -52,6 +55,12 @@ func (r *Router) RegisterRouter(group RouterGroup, param EndPointGroup) *Router
 	return r
 }
 
+// SetAuth 设置Url.Auth不为nil的路由使用的鉴权
+func (r *Router) SetAuth(auth *Authenticator) *Router {
+	r.auth = auth
+	return r
+}
+
 func (r *Router) Package(g *gin.Engine) *gin.Engine {
 	for key, roues := range r.router {
 		group := g.Group(string(key))
@@ -70,19 +79,27 @@ func (r *Router) Package(g *gin.Engine) *gin.Engine {
 							ctx.SecureJSON(200, d)
 						}
 					}
+					handlers := []gin.HandlerFunc{do}
+					if val.Auth != nil {
+						// 没有设置鉴权时不能把需要登录的接口暴露出去
+						if r.auth == nil {
+							panic(ErrNoAuth)
+						}
+						handlers = []gin.HandlerFunc{r.auth.Middleware(val.Auth), do}
+					}
 					switch val.Method {
 					case Any:
-						group.Any(val.Path, do)
+						group.Any(val.Path, handlers...)
 					case GET:
-						group.GET(val.Path, do)
+						group.GET(val.Path, handlers...)
 					case POST:
-						group.POST(val.Path, do)
+						group.POST(val.Path, handlers...)
 					case DELETE:
-						group.DELETE(val.Path, do)
+						group.DELETE(val.Path, handlers...)
 					case PATCH:
-						group.PATCH(val.Path, do)
+						group.PATCH(val.Path, handlers...)
 					case PUT:
-						group.PUT(val.Path, do)
+						group.PUT(val.Path, handlers...)
 					}
 				}
 			}
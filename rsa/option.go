@@ -0,0 +1,216 @@
+package rsa
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"io"
+)
+
+var (
+	ErrCiphertext = errors.New("rsa: invalid ciphertext length")
+	ErrHash       = errors.New("rsa: hash function unavailable")
+	ErrKeyType    = errors.New("rsa: not an rsa key")
+	ErrKeySize    = errors.New("rsa: key too small for padding")
+)
+
+type mode int
+
+const (
+	modeSingle  mode = iota // 单块 明文不能超过密钥长度
+	modeChunked             // 按密钥长度分块 逐块rsa加密
+	modeHybrid              // rsa加密随机aes密钥 aes-gcm加密数据
+)
+
+type options struct {
+	oaep    bool
+	hash    crypto.Hash
+	label   []byte
+	pss     bool
+	saltLen int
+	mode    mode
+
+	password []byte
+}
+
+// Option Encrypt/Decrypt/SignRSA/VerifyRSA 的可选参数 默认PKCS#1 v1.5
+type Option func(*options)
+
+// WithOAEP 加解密使用OAEP填充 hash和label双方必须一致
+func WithOAEP(hash crypto.Hash, label []byte) Option {
+	return func(o *options) {
+		o.oaep = true
+		o.hash = hash
+		o.label = label
+	}
+}
+
+// WithPSS 签名使用PSS填充 saltLen可以是 rsa.PSSSaltLengthAuto 或 rsa.PSSSaltLengthEqualsHash
+func WithPSS(saltLen int) Option {
+	return func(o *options) {
+		o.pss = true
+		o.saltLen = saltLen
+	}
+}
+
+// WithChunked 按密钥长度分块加密 支持任意长度数据 密文长度为密钥长度的整数倍
+func WithChunked() Option {
+	return func(o *options) {
+		o.mode = modeChunked
+	}
+}
+
+// WithHybrid 混合加密 rsa只加密随机的aes-256密钥 数据用aes-gcm加密
+// 密文格式: rsa加密的密钥 | nonce | aes-gcm密文
+func WithHybrid() Option {
+	return func(o *options) {
+		o.mode = modeHybrid
+	}
+}
+
+// WithPassword 私钥为加密的PKCS#8或OpenSSH时的密码
+func WithPassword(password []byte) Option {
+	return func(o *options) {
+		o.password = password
+	}
+}
+
+func newOptions(opts []Option) (*options, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.oaep && !o.hash.Available() {
+		return nil, ErrHash
+	}
+	return o, nil
+}
+
+// maxBlock 单块最大明文长度
+func (o *options) maxBlock(pub *rsa.PublicKey) int {
+	if o.oaep {
+		return pub.Size() - 2*o.hash.Size() - 2
+	}
+	return pub.Size() - 11
+}
+
+func (o *options) encryptBlock(pub *rsa.PublicKey, data []byte) ([]byte, error) {
+	if o.oaep {
+		return rsa.EncryptOAEP(o.hash.New(), rand.Reader, pub, data, o.label)
+	}
+	return rsa.EncryptPKCS1v15(rand.Reader, pub, data)
+}
+
+func (o *options) decryptBlock(pri *rsa.PrivateKey, data []byte) ([]byte, error) {
+	if o.oaep {
+		return rsa.DecryptOAEP(o.hash.New(), rand.Reader, pri, data, o.label)
+	}
+	return rsa.DecryptPKCS1v15(rand.Reader, pri, data)
+}
+
+func (o *options) encrypt(pub *rsa.PublicKey, data []byte) ([]byte, error) {
+	switch o.mode {
+	case modeChunked:
+		size := o.maxBlock(pub)
+		// 密钥长度不足以容纳 OAEP 填充
+		if size <= 0 {
+			return nil, ErrKeySize
+		}
+		out := make([]byte, 0, (len(data)/size+1)*pub.Size())
+		for len(data) > 0 {
+			n := size
+			if len(data) < n {
+				n = len(data)
+			}
+			ct, err := o.encryptBlock(pub, data[:n])
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, ct...)
+			data = data[n:]
+		}
+		return out, nil
+	case modeHybrid:
+		key := make([]byte, 32)
+		if _, err := io.ReadFull(rand.Reader, key); err != nil {
+			return nil, err
+		}
+		out, err := o.encryptBlock(pub, key)
+		if err != nil {
+			return nil, err
+		}
+		gcm, err := newGCM(key)
+		if err != nil {
+			return nil, err
+		}
+		nonce := make([]byte, gcm.NonceSize())
+		if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+			return nil, err
+		}
+		out = append(out, nonce...)
+		return gcm.Seal(out, nonce, data, nil), nil
+	}
+	return o.encryptBlock(pub, data)
+}
+
+func (o *options) decrypt(pri *rsa.PrivateKey, data []byte) ([]byte, error) {
+	k := pri.Size()
+	switch o.mode {
+	case modeChunked:
+		if len(data)%k != 0 {
+			return nil, ErrCiphertext
+		}
+		var out []byte
+		for ; len(data) > 0; data = data[k:] {
+			pt, err := o.decryptBlock(pri, data[:k])
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, pt...)
+		}
+		return out, nil
+	case modeHybrid:
+		if len(data) < k {
+			return nil, ErrCiphertext
+		}
+		key, err := o.decryptBlock(pri, data[:k])
+		if err != nil {
+			return nil, err
+		}
+		gcm, err := newGCM(key)
+		if err != nil {
+			return nil, err
+		}
+		data = data[k:]
+		if len(data) < gcm.NonceSize()+gcm.Overhead() {
+			return nil, ErrCiphertext
+		}
+		return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	}
+	return o.decryptBlock(pri, data)
+}
+
+func (o *options) sign(pri *rsa.PrivateKey, hash crypto.Hash, hashed []byte) ([]byte, error) {
+	if o.pss {
+		return rsa.SignPSS(rand.Reader, pri, hash, hashed, &rsa.PSSOptions{SaltLength: o.saltLen})
+	}
+	return rsa.SignPKCS1v15(rand.Reader, pri, hash, hashed)
+}
+
+func (o *options) verify(pub *rsa.PublicKey, hash crypto.Hash, hashed, sig []byte) error {
+	if o.pss {
+		return rsa.VerifyPSS(pub, hash, hashed, sig, &rsa.PSSOptions{SaltLength: o.saltLen})
+	}
+	return rsa.VerifyPKCS1v15(pub, hash, hashed, sig)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
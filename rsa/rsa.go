@@ -7,8 +7,9 @@ import (
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/pem"
-	"fmt"
 	"os"
+
+	"github.com/suiguo/hwlib/keycodec"
 )
 
 // 创建RSA
@@ -79,34 +80,33 @@ func GenerateRSAKeyFile(bits int, file string) error {
 	return pem.Encode(f2, pubPKCS8Block)
 }
 
-// SignRSA ras签名 返回签名信息后的base64 (sha256)
-func SignRSA(hashtype crypto.Hash, data []byte, pri []byte) (string, error) {
+// SignRSA ras签名 返回签名信息后的base64 默认PKCS#1 v1.5 WithPSS使用PSS
+func SignRSA(hashtype crypto.Hash, data []byte, pri []byte, opts ...Option) (string, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return "", err
+	}
 	myHash := hashtype
 	hashInstance := myHash.New()
 	hashInstance.Write(data)
 	hashed := hashInstance.Sum(nil)
-	block, _ := pem.Decode(pri)
-	if block == nil {
-		return "", fmt.Errorf("block is nil")
-	}
-	// x509.ParsePKCSPrivateKey()
-	pkcs8key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	privateKey, err := ParsePrivateKey(pri, o.password)
 	if err != nil {
 		return "", err
 	}
-	privateKey, ok := pkcs8key.(*rsa.PrivateKey)
-	if !ok {
-		return "", fmt.Errorf("privatekey is error")
-	}
-	signature, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, hashed)
+	signature, err := o.sign(privateKey, myHash, hashed)
 	if err != nil {
 		return "", err
 	}
 	return base64.StdEncoding.EncodeToString(signature), nil
 }
 
-// data 签名原始数据  base64Sig签名后base64的数据  pub公钥（sha256）
-func VerifyRSA(hashtype crypto.Hash, data []byte, base64Sig string, pub []byte) bool {
+// data 签名原始数据  base64Sig签名后base64的数据  pub公钥 opts需与签名时一致
+func VerifyRSA(hashtype crypto.Hash, data []byte, base64Sig string, pub []byte, opts ...Option) bool {
+	o, err := newOptions(opts)
+	if err != nil {
+		return false
+	}
 	bytes, err := base64.StdEncoding.DecodeString(base64Sig)
 	if err != nil {
 		return false
@@ -115,51 +115,63 @@ func VerifyRSA(hashtype crypto.Hash, data []byte, base64Sig string, pub []byte)
 	hashInstance := myHash.New()
 	hashInstance.Write(data)
 	hashed := hashInstance.Sum(nil)
-	block, _ := pem.Decode(pub)
-	if block == nil {
+	publicKey, err := ParsePublicKey(pub)
+	if err != nil {
 		return false
 	}
-	publicKeyInterface, err := x509.ParsePKIXPublicKey(block.Bytes)
+	err = o.verify(publicKey, myHash, hashed, bytes)
+	return err == nil
+}
+
+// Encrypt 公钥加密 默认PKCS#1 v1.5单块 可选OAEP及分块/混合模式
+func Encrypt(pub []byte, data []byte, opts ...Option) ([]byte, error) {
+	o, err := newOptions(opts)
 	if err != nil {
-		return false
+		return nil, err
 	}
-	publicKey, ok := publicKeyInterface.(*rsa.PublicKey)
-	if !ok {
-		return false
+	publicKey, err := ParsePublicKey(pub)
+	if err != nil {
+		return nil, err
 	}
-	err = rsa.VerifyPKCS1v15(publicKey, myHash, hashed, bytes)
-	return err == nil
+	return o.encrypt(publicKey, data)
 }
 
-func Encrypt(pub []byte, data []byte) ([]byte, error) {
-	block, _ := pem.Decode(pub)
-	if block == nil {
-		return nil, fmt.Errorf("block is nil")
+// Decrypt 私钥解密 opts需与加密时一致
+func Decrypt(pri []byte, data []byte, opts ...Option) ([]byte, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
 	}
-	publicKeyInterface, err := x509.ParsePKIXPublicKey(block.Bytes)
+	privateKey, err := ParsePrivateKey(pri, o.password)
 	if err != nil {
 		return nil, err
 	}
-	publicKey, ok := publicKeyInterface.(*rsa.PublicKey)
+	return o.decrypt(privateKey, data)
+}
+
+// ParsePublicKey 解析rsa公钥 支持PKIX/PKCS#1 PEM、DER、base64、OpenSSH和JWK
+func ParsePublicKey(pub []byte) (*rsa.PublicKey, error) {
+	key, err := keycodec.ParsePublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	k, ok := key.(*rsa.PublicKey)
 	if !ok {
-		return nil, fmt.Errorf("pub error")
+		return nil, ErrKeyType
 	}
-	return rsa.EncryptPKCS1v15(rand.Reader, publicKey, data)
+	return k, nil
 }
 
-func Decrypt(pri []byte, data []byte) ([]byte, error) {
-	block, _ := pem.Decode(pri)
-	if block == nil {
-		return nil, fmt.Errorf("block is nil")
-	}
-	// x509.ParsePKCSPrivateKey()
-	pkcs8key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+// ParsePrivateKey 解析rsa私钥 支持PKCS#8/PKCS#1 PEM、DER、base64、OpenSSH和JWK
+// password不为nil时可以解析加密的PKCS#8
+func ParsePrivateKey(pri []byte, password []byte) (*rsa.PrivateKey, error) {
+	key, err := keycodec.ParsePrivateKeyWithPassword(pri, password)
 	if err != nil {
 		return nil, err
 	}
-	privateKey, ok := pkcs8key.(*rsa.PrivateKey)
+	k, ok := key.(*rsa.PrivateKey)
 	if !ok {
-		return nil, fmt.Errorf("private key error")
+		return nil, ErrKeyType
 	}
-	return rsa.DecryptPKCS1v15(rand.Reader, privateKey, data)
+	return k, nil
 }
@@ -0,0 +1,93 @@
+package rsa
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"testing"
+)
+
+func TestEncrypt(t *testing.T) {
+	pub, pri, err := GenerateRSAKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	short := []byte("hello")
+	long := bytes.Repeat([]byte("0123456789"), 100)
+	tests := []struct {
+		data []byte
+		opts []Option
+	}{
+		{short, nil},
+		{short, []Option{WithOAEP(crypto.SHA256, []byte("label"))}},
+		{long, []Option{WithChunked()}},
+		{long, []Option{WithChunked(), WithOAEP(crypto.SHA1, nil)}},
+		{long, []Option{WithHybrid()}},
+		{long, []Option{WithHybrid(), WithOAEP(crypto.SHA512, nil)}},
+	}
+	for i, test := range tests {
+		ct, err := Encrypt(pub, test.data, test.opts...)
+		if err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		pt, err := Decrypt(pri, ct, test.opts...)
+		if err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		if !bytes.Equal(pt, test.data) {
+			t.Fatalf("%d: plaintext mismatch", i)
+		}
+	}
+	if _, err := Encrypt(pub, long); err == nil {
+		t.Fatal("long data should not fit a single block")
+	}
+	ct, _ := Encrypt(pub, short, WithOAEP(crypto.SHA256, []byte("a")))
+	if _, err := Decrypt(pri, ct, WithOAEP(crypto.SHA256, []byte("b"))); err == nil {
+		t.Fatal("wrong label should fail")
+	}
+}
+
+func TestChunkedKeyTooSmall(t *testing.T) {
+	pub, pri, err := GenerateRSAKey(1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 128 - 2*64 - 2 < 0
+	if _, err := Encrypt(pub, []byte("hello"), WithChunked(), WithOAEP(crypto.SHA512, nil)); err != ErrKeySize {
+		t.Fatalf("sha512: %v", err)
+	}
+	opts := []Option{WithChunked(), WithOAEP(crypto.SHA384, nil)}
+	ct, err := Encrypt(pub, []byte("hello"), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pt, err := Decrypt(pri, ct, opts...); err != nil || string(pt) != "hello" {
+		t.Fatalf("sha384: %v", err)
+	}
+}
+
+func TestSign(t *testing.T) {
+	pub, pri, err := GenerateRSAKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("data")
+	for _, opts := range [][]Option{nil, {WithPSS(rsa.PSSSaltLengthEqualsHash)}} {
+		for _, h := range []crypto.Hash{crypto.SHA256, crypto.SHA512} {
+			sig, err := SignRSA(h, data, pri, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !VerifyRSA(h, data, sig, pub, opts...) {
+				t.Fatal("verify failed")
+			}
+			if VerifyRSA(h, []byte("other"), sig, pub, opts...) {
+				t.Fatal("verify should fail")
+			}
+		}
+	}
+	sig, _ := SignRSA(crypto.SHA256, data, pri, WithPSS(rsa.PSSSaltLengthAuto))
+	if VerifyRSA(crypto.SHA256, data, sig, pub) {
+		t.Fatal("pss signature verified as PKCS#1 v1.5")
+	}
+}
@@ -0,0 +1,135 @@
+package scan
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/fbsobreira/gotron-sdk/pkg/address"
+	"golang.org/x/crypto/sha3"
+)
+
+var ErrInvalidAddress = fmt.Errorf("invalid address")
+
+// ToChecksumAddress EIP-55 大小写校验格式 输入可以是任意大小写的 0x 地址
+func ToChecksumAddress(addr string) (string, error) {
+	raw, err := ethAddressBytes(addr)
+	if err != nil {
+		return "", err
+	}
+	lower := hex.EncodeToString(raw)
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write([]byte(lower))
+	hash := hasher.Sum(nil)
+	out := []byte(lower)
+	for i := range out {
+		if out[i] < 'a' {
+			continue
+		}
+		nibble := hash[i/2]
+		if i%2 == 0 {
+			nibble >>= 4
+		}
+		if nibble&0xf >= 8 {
+			out[i] -= 'a' - 'A'
+		}
+	}
+	return "0x" + string(out), nil
+}
+
+// IsChecksumAddress 全小写/全大写视为未校验 返回 true；混合大小写时必须符合 EIP-55
+func IsChecksumAddress(addr string) bool {
+	if _, err := ethAddressBytes(addr); err != nil {
+		return false
+	}
+	body := strings.TrimPrefix(strings.TrimPrefix(addr, "0x"), "0X")
+	if body == strings.ToLower(body) || body == strings.ToUpper(body) {
+		return true
+	}
+	sum, _ := ToChecksumAddress(addr)
+	return sum[2:] == body
+}
+
+func ethAddressBytes(addr string) ([]byte, error) {
+	body := strings.TrimPrefix(strings.TrimPrefix(addr, "0x"), "0X")
+	if len(body) != 40 {
+		return nil, ErrInvalidAddress
+	}
+	raw, err := hex.DecodeString(body)
+	if err != nil {
+		return nil, ErrInvalidAddress
+	}
+	return raw, nil
+}
+
+// TronHexToBase58 支持 41 开头的 21 字节 hex、0x 开头或不带 41 前缀的 20 字节 hex
+func TronHexToBase58(addr string) (string, error) {
+	body := strings.TrimPrefix(strings.TrimPrefix(addr, "0x"), "0X")
+	switch len(body) {
+	case 40:
+		body = "41" + body
+	case 42:
+		if !strings.HasPrefix(body, "41") {
+			return "", ErrInvalidAddress
+		}
+	default:
+		return "", ErrInvalidAddress
+	}
+	raw, err := hex.DecodeString(body)
+	if err != nil {
+		return "", ErrInvalidAddress
+	}
+	return address.Address(raw).String(), nil
+}
+
+// TronBase58ToHex 返回 41 开头的 hex 地址
+func TronBase58ToHex(addr string) (string, error) {
+	raw, err := address.Base58ToAddress(addr)
+	if err != nil || len(raw) != address.AddressLength || raw[0] != address.TronBytePrefix {
+		return "", ErrInvalidAddress
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+// IsValidAddress 按链校验地址格式 eth 系地址混合大小写时会校验 EIP-55
+func IsValidAddress(chain ChainType, addr string) bool {
+	switch chain {
+	case Tron:
+		_, err := TronBase58ToHex(addr)
+		return err == nil
+	case Eth, BSC, Arbitrum:
+		return IsChecksumAddress(addr)
+	}
+	return false
+}
+
+// NormalizeAddress 统一地址格式 用于关注列表、合约查询和推送结果
+// eth 系统一为小写 0x hex，tron 统一为 base58 (接受 base58 或 hex 输入)
+func NormalizeAddress(chain ChainType, addr string) (string, error) {
+	switch chain {
+	case Tron:
+		if strings.HasPrefix(addr, "T") {
+			if _, err := TronBase58ToHex(addr); err != nil {
+				return "", err
+			}
+			return addr, nil
+		}
+		return TronHexToBase58(addr)
+	case Eth, BSC, Arbitrum:
+		raw, err := ethAddressBytes(addr)
+		if err != nil {
+			return "", err
+		}
+		return "0x" + hex.EncodeToString(raw), nil
+	}
+	return "", fmt.Errorf("not support chain %s", chain)
+}
+
+// normalizeAddress 无法识别的地址保持原样 (例如合约创建交易 to 为空)
+func normalizeAddress(chain ChainType, addr string) string {
+	out, err := NormalizeAddress(chain, addr)
+	if err != nil {
+		return addr
+	}
+	return out
+}
@@ -0,0 +1,143 @@
+package scan
+
+import (
+	"strings"
+	"testing"
+)
+
+// EIP-55 规范中的测试向量
+var eip55Vectors = []string{
+	"0x52908400098527886E0F7030069857D2E4169EE7",
+	"0x8617E340B3D01FA5F11F306F4090FD50E238070D",
+	"0xde709f2102306220921060314715629080e2fb77",
+	"0x27b1fdb04752bbc536007a920d24acb045561c26",
+	"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+	"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+	"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+	"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+}
+
+func TestToChecksumAddress(t *testing.T) {
+	for _, want := range eip55Vectors[4:] {
+		for _, in := range []string{want, strings.ToLower(want), "0x" + strings.ToUpper(want[2:]), "0X" + want[2:]} {
+			got, err := ToChecksumAddress(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Fatalf("%s: got %s want %s", in, got, want)
+			}
+		}
+	}
+	for _, in := range []string{"", "0x", "0x1234", "0x" + strings.Repeat("g", 40), "0x" + strings.Repeat("a", 42)} {
+		if _, err := ToChecksumAddress(in); err != ErrInvalidAddress {
+			t.Fatalf("%q: err %v", in, err)
+		}
+	}
+}
+
+func TestIsChecksumAddress(t *testing.T) {
+	for _, addr := range eip55Vectors {
+		if !IsChecksumAddress(addr) {
+			t.Fatalf("%s should be valid", addr)
+		}
+	}
+	// 混合大小写但校验错误
+	for _, addr := range []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD",
+		"0xfb6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA",
+	} {
+		if IsChecksumAddress(addr) {
+			t.Fatalf("%s should be invalid", addr)
+		}
+	}
+}
+
+func TestTronAddress(t *testing.T) {
+	cases := []struct {
+		hex    string
+		base58 string
+	}{
+		{"41a614f803b6fd780986a42c78ec9c7f77e6ded13c", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"},
+		{"410000000000000000000000000000000000000000", "T9yD14Nj9j7xAB4dbGeiX9h8unkKHxuWwb"},
+	}
+	for _, c := range cases {
+		for _, in := range []string{c.hex, "0x" + c.hex[2:], c.hex[2:], strings.ToUpper(c.hex)} {
+			got, err := TronHexToBase58(in)
+			if err != nil {
+				t.Fatalf("%s: %v", in, err)
+			}
+			if got != c.base58 {
+				t.Fatalf("%s: got %s want %s", in, got, c.base58)
+			}
+		}
+		got, err := TronBase58ToHex(c.base58)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != c.hex {
+			t.Fatalf("%s: got %s want %s", c.base58, got, c.hex)
+		}
+	}
+	for _, in := range []string{"", "41a614", "42a614f803b6fd780986a42c78ec9c7f77e6ded13c", "41" + strings.Repeat("zz", 20)} {
+		if _, err := TronHexToBase58(in); err != ErrInvalidAddress {
+			t.Fatalf("%q: err %v", in, err)
+		}
+	}
+	// 校验和错误、长度错误、非 41 前缀
+	for _, in := range []string{"", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6u", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj", "1BoatSLRHtKNngkdXEeobR76b53LETtpyT"} {
+		if _, err := TronBase58ToHex(in); err != ErrInvalidAddress {
+			t.Fatalf("%q: err %v", in, err)
+		}
+	}
+}
+
+func TestNormalizeAddress(t *testing.T) {
+	cases := []struct {
+		chain ChainType
+		in    string
+		want  string
+	}{
+		{Eth, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"},
+		{BSC, "5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"},
+		{Tron, "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"},
+		{Tron, "41a614f803b6fd780986a42c78ec9c7f77e6ded13c", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"},
+		{Tron, "a614f803b6fd780986a42c78ec9c7f77e6ded13c", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"},
+	}
+	for _, c := range cases {
+		got, err := NormalizeAddress(c.chain, c.in)
+		if err != nil {
+			t.Fatalf("%s %s: %v", c.chain, c.in, err)
+		}
+		if got != c.want {
+			t.Fatalf("%s %s: got %s want %s", c.chain, c.in, got, c.want)
+		}
+	}
+	for _, c := range []struct {
+		chain ChainType
+		in    string
+	}{
+		{Eth, "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"},
+		{Tron, "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6u"},
+		{Tron, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA"},
+		{"BTC", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"},
+	} {
+		if _, err := NormalizeAddress(c.chain, c.in); err == nil {
+			t.Fatalf("%s %s: expected error", c.chain, c.in)
+		}
+	}
+	// 无法识别的地址保持原样
+	if got := normalizeAddress(Eth, ""); got != "" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestIsValidAddress(t *testing.T) {
+	if !IsValidAddress(Tron, "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t") || IsValidAddress(Tron, "41a614f803b6fd780986a42c78ec9c7f77e6ded13c") {
+		t.Fatal("tron")
+	}
+	if !IsValidAddress(Eth, eip55Vectors[4]) || IsValidAddress(Eth, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD") {
+		t.Fatal("eth")
+	}
+}
@@ -0,0 +1,283 @@
+package scan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// LatestBlock 查询最新状态
+const LatestBlock int64 = -1
+
+// BalanceOfMethodId balanceOf(address)
+const BalanceOfMethodId = "70a08231"
+
+// batchSize 单次 json-rpc 批量请求的最大条数
+const batchSize = 100
+
+const getAccount = "/wallet/getaccount"
+const triggerConstant = "/wallet/triggerconstantcontract"
+
+type Balance struct {
+	Addr     string          `json:"addr"`
+	Contract string          `json:"contract"` //本币为空
+	Symbol   string          `json:"symbol"`
+	Amount   decimal.Decimal `json:"amount"`
+}
+
+type JsonRpcResult struct {
+	Jsonrpc string          `json:"jsonrpc"`
+	ID      int64           `json:"id"`
+	Error   Error           `json:"error"`
+	Result  json.RawMessage `json:"result"`
+}
+
+func blockTag(block int64) string {
+	if block < 0 {
+		return "latest"
+	}
+	return fmt.Sprintf("0x%x", block)
+}
+
+// batchCall 批量 json-rpc 请求 返回结果顺序与 reqs 一致
+func (t *ethTool) batchCall(reqs []*JsonRpcParam) ([]json.RawMessage, error) {
+	out := make([]json.RawMessage, len(reqs))
+	pos := make(map[int64]int, len(reqs))
+	for start := 0; start < len(reqs); start += batchSize {
+		end := start + batchSize
+		if end > len(reqs) {
+			end = len(reqs)
+		}
+		batch := reqs[start:end]
+		for idx, req := range batch {
+			req.Jsonrpc = "2.0"
+			req.ID = t.requestId.Add(1)
+			pos[req.ID] = start + idx
+		}
+		body, code, err := Request(Post, t.url, nil, batch)
+		if err != nil {
+			return nil, err
+		}
+		if code != 200 {
+			return nil, fmt.Errorf("code not 200")
+		}
+		resps := make([]JsonRpcResult, 0, len(batch))
+		err = json.Unmarshal(body, &resps)
+		if err != nil {
+			return nil, err
+		}
+		for _, resp := range resps {
+			if resp.Error.Code != 0 {
+				return nil, errors.New(resp.Error.Message)
+			}
+			idx, ok := pos[resp.ID]
+			if !ok {
+				continue
+			}
+			out[idx] = resp.Result
+		}
+	}
+	for idx := range out {
+		if out[idx] == nil {
+			return nil, fmt.Errorf("missing result for %s", reqs[idx].Method)
+		}
+	}
+	return out, nil
+}
+
+func hexResultValue(raw json.RawMessage, decimals uint8) (decimal.Decimal, error) {
+	var str string
+	err := json.Unmarshal(raw, &str)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	str = strings.TrimPrefix(str, "0x")
+	if str == "" {
+		return decimal.Zero, nil
+	}
+	val, ok := new(big.Int).SetString(str, 16)
+	if !ok {
+		return decimal.Zero, fmt.Errorf("invalid hex value %s", str)
+	}
+	return ChainValue(val.String(), decimals)
+}
+
+// Balances 查询 addrs 在 block 高度的本币以及 tokens 余额 tokens 必须已通过 AddContract 注册
+func (t *ethTool) Balances(addrs []string, tokens []string, block int64) ([]*Balance, error) {
+	contracts := make([]*Contract, 0, len(tokens))
+	for _, token := range tokens {
+		info, ok := t.GetContract(token)
+		if !ok {
+			return nil, fmt.Errorf("contract %s not added", token)
+		}
+		contracts = append(contracts, info)
+	}
+	tag := blockTag(block)
+	reqs := make([]*JsonRpcParam, 0, len(addrs)*(len(contracts)+1))
+	out := make([]*Balance, 0, cap(reqs))
+	for _, addr := range addrs {
+		addr, err := NormalizeAddress(t.chain_type, addr)
+		if err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, &JsonRpcParam{
+			Method: "eth_getBalance",
+			Params: []any{addr, tag},
+		})
+		out = append(out, &Balance{Addr: addr, Symbol: string(t.ChainType())})
+		for _, contract := range contracts {
+			reqs = append(reqs, &JsonRpcParam{
+				Method: "eth_call",
+				Params: []any{map[string]string{
+					"to":   contract.Addr,
+					"data": "0x" + BalanceOfMethodId + strings.Repeat("0", 24) + addr[2:],
+				}, tag},
+			})
+			out = append(out, &Balance{Addr: addr, Contract: contract.Addr, Symbol: contract.TokenName})
+		}
+	}
+	results, err := t.batchCall(reqs)
+	if err != nil {
+		return nil, err
+	}
+	i := 0
+	for range addrs {
+		out[i].Amount, err = hexResultValue(results[i], 18)
+		if err != nil {
+			return nil, err
+		}
+		i++
+		for _, contract := range contracts {
+			out[i].Amount, err = hexResultValue(results[i], contract.Decimals)
+			if err != nil {
+				return nil, err
+			}
+			i++
+		}
+	}
+	return out, nil
+}
+
+// Nonce 地址在 block 高度的 nonce (eth_getTransactionCount)
+func (t *ethTool) Nonce(addr string, block int64) (uint64, error) {
+	addr, err := NormalizeAddress(t.chain_type, addr)
+	if err != nil {
+		return 0, err
+	}
+	results, err := t.batchCall([]*JsonRpcParam{{
+		Method: "eth_getTransactionCount",
+		Params: []any{addr, blockTag(block)},
+	}})
+	if err != nil {
+		return 0, err
+	}
+	var str string
+	err = json.Unmarshal(results[0], &str)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(str, 0, 64)
+}
+
+type TronAccount struct {
+	Address string `json:"address"`
+	Balance int64  `json:"balance"`
+}
+
+type TronConstantResult struct {
+	Result         TronReturn `json:"result"`
+	ConstantResult []string   `json:"constant_result"`
+}
+
+type TronReturn struct {
+	Result  bool   `json:"result"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Balances tron 节点只提供最新状态 block 仅支持 LatestBlock
+func (t *tronTool) Balances(addrs []string, tokens []string, block int64) ([]*Balance, error) {
+	if block >= 0 {
+		return nil, fmt.Errorf("tron not support history balance")
+	}
+	contracts := make([]*Contract, 0, len(tokens))
+	for _, token := range tokens {
+		info, ok := t.GetContract(token)
+		if !ok {
+			return nil, fmt.Errorf("contract %s not added", token)
+		}
+		contracts = append(contracts, info)
+	}
+	out := make([]*Balance, 0, len(addrs)*(len(contracts)+1))
+	for _, addr := range addrs {
+		addr, err := NormalizeAddress(Tron, addr)
+		if err != nil {
+			return nil, err
+		}
+		resp, code, err := Request(Post, t.url+getAccount, nil, map[string]any{"address": addr, "visible": true})
+		if err != nil {
+			return nil, err
+		}
+		if code != 200 {
+			return nil, fmt.Errorf("code not 200")
+		}
+		account := &TronAccount{}
+		err = json.Unmarshal(resp, account)
+		if err != nil {
+			return nil, err
+		}
+		//未激活账户返回 {}
+		out = append(out, &Balance{
+			Addr:   addr,
+			Symbol: "TRX",
+			Amount: decimal.NewFromInt(account.Balance).Div(trxDecimal),
+		})
+		hexAddr, err := TronBase58ToHex(addr)
+		if err != nil {
+			return nil, err
+		}
+		for _, contract := range contracts {
+			amount, err := t.balanceOf(contract, addr, hexAddr)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, &Balance{Addr: addr, Contract: contract.Addr, Symbol: contract.TokenName, Amount: amount})
+		}
+	}
+	return out, nil
+}
+
+func (t *tronTool) balanceOf(contract *Contract, addr string, hexAddr string) (decimal.Decimal, error) {
+	resp, code, err := Request(Post, t.url+triggerConstant, nil, map[string]any{
+		"owner_address":     addr,
+		"contract_address":  contract.Addr,
+		"function_selector": "balanceOf(address)",
+		"parameter":         strings.Repeat("0", 24) + hexAddr[2:],
+		"visible":           true,
+	})
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if code != 200 {
+		return decimal.Zero, fmt.Errorf("code not 200")
+	}
+	result := &TronConstantResult{}
+	err = json.Unmarshal(resp, result)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if !result.Result.Result || len(result.ConstantResult) == 0 {
+		return decimal.Zero, fmt.Errorf("balanceOf fail %s %s", result.Result.Code, result.Result.Message)
+	}
+	return hexResultValue(json.RawMessage(strconv.Quote(result.ConstantResult[0])), contract.Decimals)
+}
+
+// Nonce tron 没有 nonce 概念
+func (t *tronTool) Nonce(addr string, block int64) (uint64, error) {
+	return 0, fmt.Errorf("tron not support nonce")
+}
@@ -0,0 +1,169 @@
+package scan
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// rpcServer 批量 json-rpc 测试节点 handle 返回单个请求的 result 回复顺序与请求相反
+type rpcServer struct {
+	*httptest.Server
+	lock    sync.Mutex
+	batches []int
+}
+
+func newRPCServer(t *testing.T, handle func(method string, params []json.RawMessage) any) *rpcServer {
+	s := &rpcServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		type request struct {
+			ID     int64             `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		reqs := make([]request, 0)
+		body := json.NewDecoder(r.Body)
+		raw := json.RawMessage{}
+		if err := body.Decode(&raw); err != nil {
+			t.Error(err)
+			return
+		}
+		batch := strings.HasPrefix(string(raw), "[")
+		if batch {
+			json.Unmarshal(raw, &reqs)
+		} else {
+			req := request{}
+			json.Unmarshal(raw, &req)
+			reqs = append(reqs, req)
+		}
+		s.lock.Lock()
+		s.batches = append(s.batches, len(reqs))
+		s.lock.Unlock()
+		resps := make([]map[string]any, 0, len(reqs))
+		for i := len(reqs) - 1; i >= 0; i-- {
+			resp := map[string]any{"jsonrpc": "2.0", "id": reqs[i].ID}
+			switch result := handle(reqs[i].Method, reqs[i].Params).(type) {
+			case error:
+				resp["error"] = map[string]any{"code": -32000, "message": result.Error()}
+			default:
+				resp["result"] = result
+			}
+			resps = append(resps, resp)
+		}
+		if batch {
+			json.NewEncoder(w).Encode(resps)
+		} else {
+			json.NewEncoder(w).Encode(resps[0])
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func testAddr(i int) string {
+	return fmt.Sprintf("0x%040x", i+1)
+}
+
+func TestEthBalancesBatch(t *testing.T) {
+	const token = "0xdac17f958d2ee523a2206206994597c13d831ec7"
+	srv := newRPCServer(t, func(method string, params []json.RawMessage) any {
+		switch method {
+		case "eth_getBalance":
+			var addr string
+			json.Unmarshal(params[0], &addr)
+			// 余额 = 地址序号 finney
+			var idx int64
+			fmt.Sscanf(addr[2:], "%x", &idx)
+			return fmt.Sprintf("0x%x", idx*1e15)
+		case "eth_call":
+			call := map[string]string{}
+			json.Unmarshal(params[0], &call)
+			if call["to"] != token {
+				return fmt.Errorf("unexpected contract %s", call["to"])
+			}
+			// balanceOf 参数就是地址 余额 = 地址序号 * 2 USDT
+			var idx int64
+			fmt.Sscanf(call["data"][len(BalanceOfMethodId)+2:], "%x", &idx)
+			return fmt.Sprintf("0x%064x", idx*2_000_000)
+		}
+		return fmt.Errorf("unexpected method %s", method)
+	})
+	tool := &ethTool{chain_type: Eth, url: srv.URL}
+	tool.AddContract(Contract{Addr: token, TokenName: "USDT", Decimals: 6})
+
+	// 60 个地址 * (本币 + 1 个代币) = 120 个请求 跨过 batchSize
+	addrs := make([]string, 60)
+	for i := range addrs {
+		addrs[i] = "0x" + strings.ToUpper(testAddr(i)[2:])
+	}
+	balances, err := tool.Balances(addrs, []string{strings.ToUpper(token[2:])}, LatestBlock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(balances) != 120 {
+		t.Fatalf("got %d balances", len(balances))
+	}
+	for i := range addrs {
+		native, usdt := balances[2*i], balances[2*i+1]
+		if native.Addr != testAddr(i) || native.Contract != "" || native.Amount.Shift(3).IntPart() != int64(i+1) {
+			t.Fatalf("%d native %+v", i, native)
+		}
+		if usdt.Addr != testAddr(i) || usdt.Contract != token || usdt.Symbol != "USDT" || usdt.Amount.IntPart() != int64(2*(i+1)) {
+			t.Fatalf("%d usdt %+v", i, usdt)
+		}
+	}
+	if len(srv.batches) != 2 || srv.batches[0] != batchSize || srv.batches[1] != 20 {
+		t.Fatalf("batches %v", srv.batches)
+	}
+
+	if _, err := tool.Balances([]string{"0x1234"}, nil, LatestBlock); err == nil {
+		t.Fatal("expected invalid address error")
+	}
+	if _, err := tool.Balances(addrs, []string{"0x0000000000000000000000000000000000000001"}, LatestBlock); err == nil {
+		t.Fatal("expected unknown contract error")
+	}
+}
+
+func TestEthBatchCallError(t *testing.T) {
+	srv := newRPCServer(t, func(method string, params []json.RawMessage) any {
+		if method == "eth_fail" {
+			return fmt.Errorf("execution reverted")
+		}
+		return "0x1"
+	})
+	tool := &ethTool{chain_type: Eth, url: srv.URL}
+	_, err := tool.batchCall([]*JsonRpcParam{{Method: "eth_ok"}, {Method: "eth_fail"}})
+	if err == nil || err.Error() != "execution reverted" {
+		t.Fatalf("err %v", err)
+	}
+}
+
+func TestEthNonce(t *testing.T) {
+	var got []string
+	srv := newRPCServer(t, func(method string, params []json.RawMessage) any {
+		var addr, tag string
+		json.Unmarshal(params[0], &addr)
+		json.Unmarshal(params[1], &tag)
+		got = append(got, method, addr, tag)
+		return "0x2a"
+	})
+	tool := &ethTool{chain_type: Eth, url: srv.URL}
+	nonce, err := tool.Nonce("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nonce != 42 {
+		t.Fatalf("nonce %d", nonce)
+	}
+	want := []string{"eth_getTransactionCount", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", "0x64"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("request %v", got)
+	}
+	if _, err := tool.Nonce("0x5aAeb6", LatestBlock); err == nil {
+		t.Fatal("expected invalid address error")
+	}
+}
@@ -64,6 +64,21 @@ func SetLastWork(chain ScanTool, idx int, block int64) error {
 	return err
 }
 
+func GetLastSeen(chain ScanTool, idx int) (int64, error) {
+	if Redis == nil {
+		return 0, fmt.Errorf("redis nil")
+	}
+	return Redis.HIncrBy(context.Background(), fmt.Sprintf("scan:seen:%d", idx), string(chain.ChainType()), 0).Result()
+}
+
+func SetLastSeen(chain ScanTool, idx int, block int64) error {
+	if Redis == nil {
+		return fmt.Errorf("redis nil")
+	}
+	_, err := Redis.HSet(context.Background(), fmt.Sprintf("scan:seen:%d", idx), string(chain.ChainType()), block).Result()
+	return err
+}
+
 type WorkHandler struct {
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -100,6 +115,15 @@ func (w *WorkHandler) Result() <-chan []*ContractTokenTran {
 	return w.scan.Result()
 }
 
+// Balances 对账使用 查询 addrs 在 block 高度的本币和 tokens 余额 (按 Contract.Decimals 换算)
+func (w *WorkHandler) Balances(chain ChainType, addrs []string, tokens []string, block int64) ([]*Balance, error) {
+	return w.scan.Balances(chain, addrs, tokens, block)
+}
+
+func (w *WorkHandler) Nonce(chain ChainType, addr string, block int64) (uint64, error) {
+	return w.scan.Nonce(chain, addr, block)
+}
+
 // NewWork maxGoNum 最大执行分组 cfg 链的配置
 func NewWork(maxGoNum int, cfgs ...ChainScanCfg) *WorkHandler {
 	scan := newScan(int64(maxGoNum), cfgs...)
@@ -0,0 +1,85 @@
+package scan
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+
+	"github.com/suiguo/hwlib/hdwallet"
+)
+
+// DepositGenerator 根据账户级扩展公钥 (m/purpose'/coin'/account') 按序号生成充值地址
+// 只需 xpub 即可生成，私钥不需要上线
+type DepositGenerator struct {
+	account *hdwallet.Key
+	encode  func(pub *ecdsa.PublicKey) (string, error)
+}
+
+// AccountPath 返回链对应的 BIP44 账户路径，用于离线导出 xpub
+func AccountPath(chain ChainType, account uint32) (string, error) {
+	coin, err := coinType(chain)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("m/44'/%d'/%d'", coin, account), nil
+}
+
+func coinType(chain ChainType) (uint32, error) {
+	switch chain {
+	case Eth, BSC, Arbitrum:
+		// bsc 和 arbitrum 与以太坊共用地址
+		return hdwallet.CoinTypeETH, nil
+	case Tron:
+		return hdwallet.CoinTypeTRON, nil
+	}
+	return 0, fmt.Errorf("not support chain %s", chain)
+}
+
+// NewDepositGenerator eth 系生成 EIP-55 地址，tron 生成 base58 地址
+func NewDepositGenerator(chain ChainType, accountKey string) (*DepositGenerator, error) {
+	key, err := hdwallet.ParseKey(accountKey)
+	if err != nil {
+		return nil, err
+	}
+	g := &DepositGenerator{account: key}
+	switch chain {
+	case Eth, BSC, Arbitrum:
+		g.encode = func(pub *ecdsa.PublicKey) (string, error) {
+			return hdwallet.EthAddress(pub), nil
+		}
+	case Tron:
+		g.encode = func(pub *ecdsa.PublicKey) (string, error) {
+			return hdwallet.TronAddress(pub), nil
+		}
+	default:
+		return nil, fmt.Errorf("not support chain %s", chain)
+	}
+	return g, nil
+}
+
+// NewBtcDepositGenerator 比特币充值地址 账户路径的 purpose 需与地址类型一致
+// (P2PKH 44', P2SH-P2WPKH 49', P2WPKH 84', P2TR 86')
+func NewBtcDepositGenerator(accountKey string, addrType hdwallet.BtcAddressType, net hdwallet.BtcNet) (*DepositGenerator, error) {
+	key, err := hdwallet.ParseKey(accountKey)
+	if err != nil {
+		return nil, err
+	}
+	return &DepositGenerator{
+		account: key,
+		encode: func(pub *ecdsa.PublicKey) (string, error) {
+			return hdwallet.BtcAddress(pub, addrType, net)
+		},
+	}, nil
+}
+
+// Address 生成外部链 (change=0) 上序号为 index 的地址
+func (g *DepositGenerator) Address(index uint32) (string, error) {
+	key, err := g.account.Derive(fmt.Sprintf("0/%d", index))
+	if err != nil {
+		return "", err
+	}
+	pub, err := key.ECDSAPublicKey()
+	if err != nil {
+		return "", err
+	}
+	return g.encode(pub)
+}
@@ -141,6 +141,7 @@ type ContractTokenTran struct {
 	TransferTimestamp int64               `json:"transferTimestamp"`
 	Transfers         []*CallbackTransfer `json:"transfers"`
 	TxId              string              `json:"txid"`
+	Status            TranStatus          `json:"status,omitempty"` //仅 TrackPending 模式下设置
 }
 
 type CallbackTransfer struct {
@@ -156,6 +157,7 @@ type ethTool struct {
 	// client     *ethclient.Client
 	url        string
 	monitorMap sync.Map // map[string]*Contract
+	watchMap   sync.Map // 交易池关注地址
 	requestId  atomic.Int64
 	chain_type ChainType
 }
@@ -163,7 +165,7 @@ type ethTool struct {
 func (t *ethTool) AddContract(c ...Contract) {
 	for idx := range c {
 		data := c[idx]
-		data.Addr = strings.ToLower(data.Addr)
+		data.Addr = normalizeAddress(t.chain_type, data.Addr)
 		t.monitorMap.Store(data.Addr, &data)
 	}
 }
@@ -201,7 +203,7 @@ func (t *ethTool) GetBlockNum() (int64, error) {
 }
 
 func (t *ethTool) GetContract(address string) (*Contract, bool) {
-	address = strings.ToLower(address)
+	address = normalizeAddress(t.chain_type, address)
 	info, ok := t.monitorMap.Load(address)
 	if !ok {
 		return nil, false
@@ -274,9 +276,9 @@ func (t *ethTool) getconractTransfer(blockNum int64, nowblock int64) ([]*Contrac
 			data := logdata.Data
 			data = strings.TrimPrefix(data, "0x")
 			from = from[26:]
-			from = fmt.Sprintf("0x%s", from)
+			from = normalizeAddress(t.chain_type, from)
 			to = to[26:]
-			to = fmt.Sprintf("0x%s", to)
+			to = normalizeAddress(t.chain_type, to)
 			val := new(big.Int)
 			tranVal, err := hex.DecodeString(data)
 			if err != nil {
@@ -295,7 +297,7 @@ func (t *ethTool) getconractTransfer(blockNum int64, nowblock int64) ([]*Contrac
 			transfertmp.Transfers = append(transfertmp.Transfers, &CallbackTransfer{
 				FromAddress: from,
 				ToAddress:   to,
-				Contract:    logdata.Address,
+				Contract:    contractInfo.Addr,
 				Symbol:      contractInfo.TokenName,
 				Amount:      tmp.String(),
 				LogIdx:      int(idx),
@@ -387,10 +389,10 @@ func (t *ethTool) getBlockByNum(blockNum int64, success map[string]bool, nowbloc
 		}
 		transfertmp.Transfers = append(transfertmp.Transfers,
 			&CallbackTransfer{
-				FromAddress: val.From,
+				FromAddress: normalizeAddress(t.chain_type, val.From),
 				Contract:    "",
 				Amount:      realamount.String(),
-				ToAddress:   val.To,
+				ToAddress:   normalizeAddress(t.chain_type, val.To),
 				Symbol:      string(t.ChainType()),
 			})
 		outtransfer = append(outtransfer, transfertmp)
@@ -399,6 +401,15 @@ func (t *ethTool) getBlockByNum(blockNum int64, success map[string]bool, nowbloc
 	return outtransfer, nil
 }
 
+// GetHeadBlockNum eth 系节点的最新块即扫描块
+func (t *ethTool) GetHeadBlockNum() (int64, error) {
+	return t.GetBlockNum()
+}
+
+func (t *ethTool) GetHeadLog(blockNum int64) ([]*ContractTokenTran, error) {
+	return t.GetLog(blockNum)
+}
+
 func (t *ethTool) ChainType() ChainType {
 	return t.chain_type
 }
@@ -28,6 +28,23 @@ type ScanTool interface {
 	AddContract(...Contract)
 }
 
+// BalanceTool 对账查询 与 ScanTool 分开 未实现的链工具不支持 Scan.Balances/Nonce
+type BalanceTool interface {
+	// Balances 查询本币及代币余额 block 为 LatestBlock 时查询最新状态
+	Balances(addrs []string, tokens []string, block int64) ([]*Balance, error)
+	Nonce(addr string, block int64) (uint64, error)
+}
+
+// HeadTool TrackPending 两阶段模式需要的能力 未实现的 ScanTool 只做确认扫描
+type HeadTool interface {
+	ScanTool
+	// GetHeadBlockNum/GetHeadLog 读取未确认的最新块
+	GetHeadBlockNum() (int64, error)
+	GetHeadLog(blockNum int64) ([]*ContractTokenTran, error)
+	// TxBlock 交易当前所在块高 未上链返回 0
+	TxBlock(txid string) (int64, error)
+}
+
 func newTool(chain ChainType, rpc []string, contracts ...Contract) ScanTool {
 	switch chain {
 	case Tron:
@@ -0,0 +1,431 @@
+package scan
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// StatusPending 交易仍在交易池中 未打包
+const StatusPending TranStatus = "pending"
+
+// ERC20/TRC20 transfer(address,uint256) 与 transferFrom(address,address,uint256) 的方法签名
+const (
+	TransferMethodId     = "a9059cbb"
+	TransferFromMethodId = "23b872dd"
+)
+
+const getPendingTxIds = "/wallet/gettransactionlistfrompending"
+const getPendingTx = "/wallet/gettransactionfrompending"
+
+// MempoolTool 交易池读取 返回转入关注地址且 seen 中没有记录的未打包交易
+type MempoolTool interface {
+	ChainType() ChainType
+	AddWatch(addrs ...string)
+	GetPending(seen PoolSeen) ([]*ContractTokenTran, error)
+}
+
+// PoolSeen 交易池去重记录
+type PoolSeen interface {
+	// Seen 交易已处理过时返回 true 并刷新其仍在交易池中的时间
+	Seen(txid string) bool
+	// Mark 记录已处理的交易 (包括与关注地址无关的交易 避免重复读取)
+	Mark(txid string)
+}
+
+type TxPoolContentResp struct {
+	Jsonrpc string        `json:"jsonrpc"`
+	ID      int64         `json:"id"`
+	Error   Error         `json:"error"`
+	Result  TxPoolContent `json:"result"`
+}
+
+// TxPoolContent txpool_content 返回 from -> nonce -> tx
+type TxPoolContent struct {
+	Pending map[string]map[string]BlockByNumberTransaction `json:"pending"`
+	Queued  map[string]map[string]BlockByNumberTransaction `json:"queued"`
+}
+
+type TronPendingTxIds struct {
+	TxId []string `json:"txId"`
+}
+
+// tokenCall 从 calldata 解析出的 ERC20/TRC20 转账
+type tokenCall struct {
+	from   string // 仅 transferFrom 有值 hex 不带前缀
+	to     string // hex 不带前缀
+	amount *big.Int
+}
+
+// decodeTokenCall 解析 transfer/transferFrom 的 calldata (hex 可带 0x)
+func decodeTokenCall(input string) (*tokenCall, bool) {
+	input = strings.TrimPrefix(input, "0x")
+	if len(input) < 8 {
+		return nil, false
+	}
+	method, args := input[:8], input[8:]
+	switch method {
+	case TransferMethodId:
+		if len(args) != 64*2 {
+			return nil, false
+		}
+		amount, ok := new(big.Int).SetString(args[64:], 16)
+		if !ok {
+			return nil, false
+		}
+		return &tokenCall{to: args[24:64], amount: amount}, true
+	case TransferFromMethodId:
+		if len(args) != 64*3 {
+			return nil, false
+		}
+		amount, ok := new(big.Int).SetString(args[128:], 16)
+		if !ok {
+			return nil, false
+		}
+		return &tokenCall{from: args[24:64], to: args[88:128], amount: amount}, true
+	}
+	return nil, false
+}
+
+func (t *ethTool) AddWatch(addrs ...string) {
+	for _, addr := range addrs {
+		t.watchMap.Store(normalizeAddress(t.chain_type, addr), struct{}{})
+	}
+}
+
+func (t *ethTool) isWatched(addr string) bool {
+	_, ok := t.watchMap.Load(normalizeAddress(t.chain_type, addr))
+	return ok
+}
+
+// GetPending 通过 txpool_content 读取交易池
+func (t *ethTool) GetPending(seen PoolSeen) ([]*ContractTokenTran, error) {
+	idx := t.requestId.Add(1)
+	out, code, err := Request(Post, t.url, nil, &JsonRpcParam{
+		Jsonrpc: "2.0",
+		Method:  "txpool_content",
+		ID:      idx,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if code != 200 {
+		return nil, fmt.Errorf("code not 200")
+	}
+	resp := &TxPoolContentResp{}
+	err = json.Unmarshal(out, resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error.Code != 0 {
+		return nil, errors.New(resp.Error.Message)
+	}
+	result := make([]*ContractTokenTran, 0)
+	for _, pool := range []map[string]map[string]BlockByNumberTransaction{resp.Result.Pending, resp.Result.Queued} {
+		for _, txs := range pool {
+			for _, tx := range txs {
+				if seen.Seen(tx.Hash) {
+					continue
+				}
+				seen.Mark(tx.Hash)
+				if tran := t.decodePending(tx); tran != nil {
+					result = append(result, tran)
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+func (t *ethTool) decodePending(tx BlockByNumberTransaction) *ContractTokenTran {
+	tran := &ContractTokenTran{
+		Chain:         string(t.ChainType()),
+		FeeAmountCoin: string(t.ChainType()),
+		TxId:          tx.Hash,
+		Status:        StatusPending,
+		Timestamp:     time.Now().Unix(),
+		Transfers:     make([]*CallbackTransfer, 0),
+	}
+	if tx.Input == "0x" || tx.Input == "" {
+		if !t.isWatched(tx.To) {
+			return nil
+		}
+		amount, ok := new(big.Int).SetString(strings.TrimPrefix(tx.Value, "0x"), 16)
+		if !ok || amount.Sign() <= 0 {
+			return nil
+		}
+		realamount, err := ChainValue(amount.String(), 18)
+		if err != nil {
+			return nil
+		}
+		tran.Transfers = append(tran.Transfers, &CallbackTransfer{
+			FromAddress: normalizeAddress(t.chain_type, tx.From),
+			ToAddress:   normalizeAddress(t.chain_type, tx.To),
+			Amount:      realamount.String(),
+			Symbol:      string(t.ChainType()),
+		})
+		return tran
+	}
+	contractInfo, ok := t.GetContract(tx.To)
+	if !ok {
+		return nil
+	}
+	call, ok := decodeTokenCall(tx.Input)
+	if !ok {
+		return nil
+	}
+	to := normalizeAddress(t.chain_type, call.to)
+	if !t.isWatched(to) {
+		return nil
+	}
+	from := normalizeAddress(t.chain_type, tx.From)
+	if call.from != "" {
+		from = normalizeAddress(t.chain_type, call.from)
+	}
+	amount, err := ChainValue(call.amount.String(), contractInfo.Decimals)
+	if err != nil {
+		return nil
+	}
+	tran.Transfers = append(tran.Transfers, &CallbackTransfer{
+		FromAddress: from,
+		ToAddress:   to,
+		Contract:    contractInfo.Addr,
+		Symbol:      contractInfo.TokenName,
+		Amount:      amount.String(),
+	})
+	return tran
+}
+
+func (t *tronTool) AddWatch(addrs ...string) {
+	for _, addr := range addrs {
+		t.watchMap.Store(normalizeAddress(Tron, addr), struct{}{})
+	}
+}
+
+func (t *tronTool) isWatched(addr string) bool {
+	_, ok := t.watchMap.Load(normalizeAddress(Tron, addr))
+	return ok
+}
+
+// GetPending 通过 gettransactionlistfrompending 读取交易池 只请求 seen 中没有记录的交易详情
+func (t *tronTool) GetPending(seen PoolSeen) ([]*ContractTokenTran, error) {
+	resp, code, err := Request(Get, t.url+getPendingTxIds, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if code != 200 {
+		return nil, fmt.Errorf("code not 200")
+	}
+	ids := &TronPendingTxIds{}
+	err = json.Unmarshal(resp, ids)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*ContractTokenTran, 0)
+	for _, txid := range ids.TxId {
+		if seen.Seen(txid) {
+			continue
+		}
+		//单笔失败不影响其他交易 未 Mark 的交易下次轮询重试
+		resp, code, err := Request(Post, t.url+getPendingTx, nil, map[string]any{"value": txid, "visible": true})
+		if err != nil || code != 200 {
+			Logger.Info("GetPending", "txid", txid, "code", code, "status", err)
+			continue
+		}
+		rawTran := &Transaction{}
+		err = json.Unmarshal(resp, rawTran)
+		if err != nil || rawTran.TxID == "" {
+			//交易可能刚被打包 移出交易池
+			continue
+		}
+		seen.Mark(txid)
+		if tran := t.decodePending(rawTran); tran != nil {
+			result = append(result, tran)
+		}
+	}
+	return result, nil
+}
+
+func (t *tronTool) decodePending(rawTran *Transaction) *ContractTokenTran {
+	tran := &ContractTokenTran{
+		Chain:             string(t.ChainType()),
+		TransferTimestamp: rawTran.RawData.Timestamp,
+		TxId:              rawTran.TxID,
+		Status:            StatusPending,
+		Timestamp:         time.Now().Unix(),
+		Transfers:         make([]*CallbackTransfer, 0),
+	}
+	for idx, contract := range rawTran.RawData.Contract {
+		value := contract.Parameter.Value
+		switch contract.Type {
+		case TransferContract:
+			if !t.isWatched(value.ToAddress) || value.Amount <= 0 {
+				continue
+			}
+			tran.Transfers = append(tran.Transfers, &CallbackTransfer{
+				FromAddress: normalizeAddress(Tron, value.OwnerAddress),
+				ToAddress:   normalizeAddress(Tron, value.ToAddress),
+				Contract:    "TRX",
+				Symbol:      "TRX",
+				Amount:      decimal.NewFromInt(value.Amount).Div(trxDecimal).String(),
+				LogIdx:      idx,
+			})
+		case TriggerSmartContract:
+			if value.Data == nil {
+				continue
+			}
+			contractInfo, ok := t.GetContract(value.ContractAddress)
+			if !ok {
+				continue
+			}
+			call, ok := decodeTokenCall(*value.Data)
+			if !ok {
+				continue
+			}
+			to := normalizeAddress(Tron, call.to)
+			if !t.isWatched(to) {
+				continue
+			}
+			from := normalizeAddress(Tron, value.OwnerAddress)
+			if call.from != "" {
+				from = normalizeAddress(Tron, call.from)
+			}
+			amount, err := ChainValue(call.amount.String(), contractInfo.Decimals)
+			if err != nil {
+				continue
+			}
+			tran.Transfers = append(tran.Transfers, &CallbackTransfer{
+				FromAddress: from,
+				ToAddress:   to,
+				Contract:    contractInfo.Addr,
+				Symbol:      contractInfo.TokenName,
+				Amount:      amount.String(),
+				LogIdx:      idx,
+			})
+		}
+	}
+	if len(tran.Transfers) == 0 {
+		return nil
+	}
+	return tran
+}
+
+// MempoolWatcher 轮询各链交易池 把转入关注地址的未打包交易推送到独立的 channel
+type MempoolWatcher struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	once     sync.Once
+	interval time.Duration
+	keep     time.Duration // 交易离开交易池后去重记录的保留时间
+	chain    sync.Map      // ChainType -> MempoolTool
+	seen     sync.Map      // txid -> time.Time 去重 记录交易最后一次出现在交易池的时间
+	popChan  chan []*ContractTokenTran
+}
+
+// NewMempoolWatcher interval 轮询间隔 cfg 链的配置 (复用扫块配置中的 Rpc 和 ContractList)
+func NewMempoolWatcher(interval time.Duration, cfgs ...ChainScanCfg) *MempoolWatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	if interval <= 0 {
+		interval = time.Second * 2
+	}
+	w := &MempoolWatcher{
+		ctx:      ctx,
+		cancel:   cancel,
+		interval: interval,
+		keep:     time.Hour,
+		popChan:  make(chan []*ContractTokenTran, 2000),
+	}
+	for _, cfg := range cfgs {
+		tool, ok := newTool(cfg.Chain, cfg.Rpc, cfg.ContractList...).(MempoolTool)
+		if !ok {
+			continue
+		}
+		w.chain.Store(cfg.Chain, tool)
+	}
+	return w
+}
+
+// AddWatch 添加需要关注的收款地址
+func (w *MempoolWatcher) AddWatch(chainType ChainType, addrs ...string) {
+	tool, ok := w.chain.Load(chainType)
+	if !ok {
+		return
+	}
+	tool.(MempoolTool).AddWatch(addrs...)
+}
+
+func (w *MempoolWatcher) Run() {
+	w.once.Do(func() {
+		go w.work()
+	})
+}
+
+func (w *MempoolWatcher) Stop() {
+	w.cancel()
+}
+
+func (w *MempoolWatcher) Result() <-chan []*ContractTokenTran {
+	return w.popChan
+}
+
+func (w *MempoolWatcher) work() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+			w.process()
+		}
+	}
+}
+
+// poolSeen 一轮轮询内的去重记录 仍在交易池中的交易会被刷新为本轮时间
+type poolSeen struct {
+	m   *sync.Map
+	now time.Time
+}
+
+func (s poolSeen) Seen(txid string) bool {
+	if _, ok := s.m.Load(txid); !ok {
+		return false
+	}
+	s.m.Store(txid, s.now)
+	return true
+}
+
+func (s poolSeen) Mark(txid string) {
+	s.m.Store(txid, s.now)
+}
+
+func (w *MempoolWatcher) process() {
+	now := time.Now()
+	seen := poolSeen{m: &w.seen, now: now}
+	w.chain.Range(func(key, value any) bool {
+		tool := value.(MempoolTool)
+		trans, err := tool.GetPending(seen)
+		if err != nil {
+			Logger.Info("MempoolWatcher", "chain", key, "status", err)
+			return true
+		}
+		if len(trans) > 0 {
+			w.popChan <- trans
+		}
+		return true
+	})
+	//轮询之后再清理 本轮仍在交易池中的交易已被刷新 不会过期重复推送
+	w.seen.Range(func(key, value any) bool {
+		if now.Sub(value.(time.Time)) > w.keep {
+			w.seen.Delete(key)
+		}
+		return true
+	})
+}
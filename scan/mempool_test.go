@@ -0,0 +1,174 @@
+package scan
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDecodeTokenCall(t *testing.T) {
+	to := "00000000000000000000000011223344556677889900aabbccddeeff00112233"
+	from := "000000000000000000000000ffeeddccbbaa0099887766554433221100ffeedd"
+	amount := "00000000000000000000000000000000000000000000000000000000000f4240"
+	cases := []struct {
+		input  string
+		ok     bool
+		from   string
+		to     string
+		amount int64
+	}{
+		{"0x" + TransferMethodId + to + amount, true, "", "11223344556677889900aabbccddeeff00112233", 1000000},
+		{TransferMethodId + to + amount, true, "", "11223344556677889900aabbccddeeff00112233", 1000000},
+		{TransferFromMethodId + from + to + amount, true, "ffeeddccbbaa0099887766554433221100ffeedd", "11223344556677889900aabbccddeeff00112233", 1000000},
+		// 参数长度不对
+		{TransferMethodId + to, false, "", "", 0},
+		{TransferMethodId + to + amount + "00", false, "", "", 0},
+		{TransferFromMethodId + to + amount, false, "", "", 0},
+		// approve(address,uint256)
+		{"095ea7b3" + to + amount, false, "", "", 0},
+		{"0xa905", false, "", "", 0},
+		{TransferMethodId + to + strings.Repeat("zz", 32), false, "", "", 0},
+	}
+	for i, c := range cases {
+		call, ok := decodeTokenCall(c.input)
+		if ok != c.ok {
+			t.Fatalf("case %d: ok %v", i, ok)
+		}
+		if !ok {
+			continue
+		}
+		if call.from != c.from || call.to != c.to || call.amount.Int64() != c.amount {
+			t.Fatalf("case %d: got %s %s %s", i, call.from, call.to, call.amount)
+		}
+	}
+}
+
+// fakePool 交易池中的交易 同一 txid 每轮都会被返回
+type fakePool struct {
+	txids []string
+}
+
+func (f *fakePool) ChainType() ChainType { return Eth }
+func (f *fakePool) AddWatch(...string)   {}
+func (f *fakePool) GetPending(seen PoolSeen) ([]*ContractTokenTran, error) {
+	out := make([]*ContractTokenTran, 0)
+	for _, txid := range f.txids {
+		if seen.Seen(txid) {
+			continue
+		}
+		seen.Mark(txid)
+		out = append(out, &ContractTokenTran{TxId: txid})
+	}
+	return out, nil
+}
+
+func popped(w *MempoolWatcher) []string {
+	out := make([]string, 0)
+	for {
+		select {
+		case trans := <-w.popChan:
+			for _, tran := range trans {
+				out = append(out, tran.TxId)
+			}
+		default:
+			return out
+		}
+	}
+}
+
+func TestMempoolWatcherDedup(t *testing.T) {
+	w := NewMempoolWatcher(time.Second)
+	w.keep = 0
+	pool := &fakePool{txids: []string{"a", "b"}}
+	w.chain.Store(Eth, pool)
+
+	w.process()
+	if got := popped(w); len(got) != 2 {
+		t.Fatalf("first poll %v", got)
+	}
+	// 仍在交易池中的交易即使超过 keep 也不会重复推送
+	time.Sleep(time.Millisecond)
+	w.process()
+	if got := popped(w); len(got) != 0 {
+		t.Fatalf("second poll %v", got)
+	}
+	// 离开交易池超过 keep 后记录被清理
+	pool.txids = []string{"b"}
+	time.Sleep(time.Millisecond)
+	w.process()
+	if _, ok := w.seen.Load("a"); ok {
+		t.Fatal("a not expired")
+	}
+	if _, ok := w.seen.Load("b"); !ok {
+		t.Fatal("b expired while in pool")
+	}
+}
+
+func TestTronGetPending(t *testing.T) {
+	watched, _ := TronHexToBase58("41" + strings.Repeat("11", 20))
+	other, _ := TronHexToBase58("41" + strings.Repeat("22", 20))
+	owner, _ := TronHexToBase58("41" + strings.Repeat("33", 20))
+	var lock sync.Mutex
+	fetched := map[string]int{}
+	failB := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == getPendingTxIds {
+			fmt.Fprint(w, `{"txId":["a","b","c"]}`)
+			return
+		}
+		param := map[string]any{}
+		json.NewDecoder(r.Body).Decode(&param)
+		txid := param["value"].(string)
+		lock.Lock()
+		fetched[txid]++
+		fail := txid == "b" && failB
+		lock.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		to := watched
+		if txid == "c" {
+			to = other
+		}
+		json.NewEncoder(w).Encode(&Transaction{
+			TxID: txid,
+			RawData: TransactionRawData{Contract: []SolidityContract{{
+				Type:      TransferContract,
+				Parameter: Parameter{Value: Value{Amount: 1_000_000, OwnerAddress: owner, ToAddress: to}},
+			}}},
+		})
+	}))
+	defer srv.Close()
+
+	tool := &tronTool{url: srv.URL}
+	tool.AddWatch(watched)
+	seen := poolSeen{m: &sync.Map{}, now: time.Now()}
+	trans, err := tool.GetPending(seen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// b 请求失败不影响 a c
+	if len(trans) != 1 || trans[0].TxId != "a" || trans[0].Transfers[0].Amount != "1" {
+		t.Fatalf("first poll %+v", trans)
+	}
+	lock.Lock()
+	failB = false
+	lock.Unlock()
+	trans, err = tool.GetPending(seen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trans) != 1 || trans[0].TxId != "b" {
+		t.Fatalf("second poll %+v", trans)
+	}
+	// 已记录的交易不再请求详情
+	if fetched["a"] != 1 || fetched["b"] != 2 || fetched["c"] != 1 {
+		t.Fatalf("fetched %v", fetched)
+	}
+}
@@ -0,0 +1,130 @@
+package scan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+type TranStatus string
+
+const (
+	StatusSeen      TranStatus = "seen"      //交易出现在最新块 尚未确认
+	StatusConfirmed TranStatus = "confirmed" //达到确认数
+	StatusDropped   TranStatus = "dropped"   //达到确认数时交易已不在链上 (回滚后未重新打包/被替换)
+)
+
+// pendingExpire 待确认记录的兜底过期时间 防止确认游标停滞时残留
+const pendingExpire = time.Hour * 24
+
+func pendingKey(chain ScanTool, block int64) string {
+	return fmt.Sprintf("scan:pending:%s:%d", chain.ChainType(), block)
+}
+
+// AddPending 记录 block 中已推送 seen 的交易 并把结果标记为 StatusSeen
+func AddPending(chain ScanTool, block int64, trans []*ContractTokenTran) error {
+	if len(trans) == 0 {
+		return nil
+	}
+	if Redis == nil {
+		return fmt.Errorf("redis nil")
+	}
+	values := make(map[string]any, len(trans))
+	for _, tran := range trans {
+		tran.Status = StatusSeen
+		data, err := json.Marshal(tran)
+		if err != nil {
+			return err
+		}
+		values[tran.TxId] = data
+	}
+	key := pendingKey(chain, block)
+	ctx := context.Background()
+	if err := Redis.HSet(ctx, key, values).Err(); err != nil {
+		return err
+	}
+	return Redis.Expire(ctx, key, pendingExpire).Err()
+}
+
+// ConfirmPending 在 block 达到确认数时调用 confirmed 为该块重新扫描的结果
+// confirmed 会被标记为 StatusConfirmed，原先 seen 但已不在该块中的交易通过 TxBlock 再确认一次
+// 已被打包进其他块的 (回滚后重新打包) 在那个块确认时推送 confirmed，只有不在链上的才返回 StatusDropped
+func ConfirmPending(chain HeadTool, block int64, confirmed []*ContractTokenTran) ([]*ContractTokenTran, error) {
+	if Redis == nil {
+		return nil, fmt.Errorf("redis nil")
+	}
+	key := pendingKey(chain, block)
+	ctx := context.Background()
+	pending, err := Redis.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	for _, tran := range confirmed {
+		tran.Status = StatusConfirmed
+		delete(pending, tran.TxId)
+	}
+	dropped := make([]*ContractTokenTran, 0, len(pending))
+	for txid, data := range pending {
+		tran := &ContractTokenTran{}
+		if err := json.Unmarshal([]byte(data), tran); err != nil {
+			Logger.Error("ConfirmPending", "txid", txid, "err", err)
+			continue
+		}
+		moved, err := chain.TxBlock(txid)
+		if err != nil {
+			return nil, err
+		}
+		if moved != 0 {
+			Logger.Info("ConfirmPending", "txid", txid, "block", block, "moved", moved)
+			continue
+		}
+		tran.Status = StatusDropped
+		dropped = append(dropped, tran)
+	}
+	if err := Redis.Del(ctx, key).Err(); err != nil {
+		return nil, err
+	}
+	return dropped, nil
+}
+
+// TxBlock 交易回执所在块高 未上链返回 0
+func (t *ethTool) TxBlock(txid string) (int64, error) {
+	results, err := t.batchCall([]*JsonRpcParam{{
+		Method: "eth_getTransactionReceipt",
+		Params: []any{txid},
+	}})
+	if err != nil {
+		return 0, err
+	}
+	var receipt *TxResult
+	err = json.Unmarshal(results[0], &receipt)
+	if err != nil {
+		return 0, err
+	}
+	if receipt == nil {
+		return 0, nil
+	}
+	return strconv.ParseInt(receipt.BlockNumber, 0, 64)
+}
+
+// TxBlock 全节点上交易所在块高 (包含未固化块) 未上链返回 0
+func (t *tronTool) TxBlock(txid string) (int64, error) {
+	resp, code, err := Request(Post, t.url+getTransactionInfoById, nil, map[string]any{"value": txid})
+	if err != nil {
+		return 0, err
+	}
+	if code != 200 {
+		return 0, fmt.Errorf("code not 200")
+	}
+	info := &TronTxInfo{}
+	err = json.Unmarshal(resp, info)
+	if err != nil {
+		return 0, err
+	}
+	if info.ID == "" {
+		return 0, nil
+	}
+	return info.BlockNumber, nil
+}
@@ -0,0 +1,176 @@
+package scan
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	_ HeadTool = (*ethTool)(nil)
+	_ HeadTool = (*tronTool)(nil)
+)
+
+func testRedis(t *testing.T) *miniredis.Miniredis {
+	mr := miniredis.RunT(t)
+	old := Redis
+	SetRedis(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	t.Cleanup(func() { SetRedis(old) })
+	return mr
+}
+
+// fakeHead 只实现 pending 用到的方法 txBlock 为交易当前所在块
+type fakeHead struct {
+	ScanTool
+	txBlock map[string]int64
+	err     error
+}
+
+func (f *fakeHead) ChainType() ChainType { return Eth }
+func (f *fakeHead) GetHeadBlockNum() (int64, error) {
+	return 0, nil
+}
+func (f *fakeHead) GetHeadLog(int64) ([]*ContractTokenTran, error) {
+	return nil, nil
+}
+func (f *fakeHead) TxBlock(txid string) (int64, error) {
+	return f.txBlock[txid], f.err
+}
+
+func trans(txids ...string) []*ContractTokenTran {
+	out := make([]*ContractTokenTran, 0, len(txids))
+	for _, txid := range txids {
+		out = append(out, &ContractTokenTran{TxId: txid})
+	}
+	return out
+}
+
+func TestPendingConfirmed(t *testing.T) {
+	mr := testRedis(t)
+	head := &fakeHead{}
+	seen := trans("0xa", "0xb")
+	if err := AddPending(head, 10, seen); err != nil {
+		t.Fatal(err)
+	}
+	for _, tran := range seen {
+		if tran.Status != StatusSeen {
+			t.Fatalf("status %s", tran.Status)
+		}
+	}
+	confirmed := trans("0xa", "0xb")
+	dropped, err := ConfirmPending(head, 10, confirmed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dropped) != 0 {
+		t.Fatalf("dropped %d", len(dropped))
+	}
+	for _, tran := range confirmed {
+		if tran.Status != StatusConfirmed {
+			t.Fatalf("status %s", tran.Status)
+		}
+	}
+	if mr.Exists(pendingKey(head, 10)) {
+		t.Fatal("pending key not deleted")
+	}
+}
+
+func TestPendingDropped(t *testing.T) {
+	testRedis(t)
+	head := &fakeHead{}
+	if err := AddPending(head, 10, trans("0xa", "0xb")); err != nil {
+		t.Fatal(err)
+	}
+	dropped, err := ConfirmPending(head, 10, trans("0xa"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dropped) != 1 || dropped[0].TxId != "0xb" || dropped[0].Status != StatusDropped {
+		t.Fatalf("dropped %+v", dropped)
+	}
+}
+
+// 回滚后交易被打包进下一个块 不能先推送 dropped 再推送 confirmed
+func TestPendingReorg(t *testing.T) {
+	testRedis(t)
+	head := &fakeHead{txBlock: map[string]int64{"0xa": 11}}
+	if err := AddPending(head, 10, trans("0xa")); err != nil {
+		t.Fatal(err)
+	}
+	dropped, err := ConfirmPending(head, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dropped) != 0 {
+		t.Fatalf("moved tx reported as dropped: %+v", dropped)
+	}
+	confirmed := trans("0xa")
+	dropped, err = ConfirmPending(head, 11, confirmed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dropped) != 0 || confirmed[0].Status != StatusConfirmed {
+		t.Fatalf("dropped %+v status %s", dropped, confirmed[0].Status)
+	}
+}
+
+func TestPendingLookupError(t *testing.T) {
+	mr := testRedis(t)
+	head := &fakeHead{err: errors.New("node down")}
+	if err := AddPending(head, 10, trans("0xa")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ConfirmPending(head, 10, nil); err == nil {
+		t.Fatal("expected error")
+	}
+	// 查询失败时保留记录 下次确认重试
+	if !mr.Exists(pendingKey(head, 10)) {
+		t.Fatal("pending key deleted")
+	}
+	head.err = nil
+	dropped, err := ConfirmPending(head, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dropped) != 1 {
+		t.Fatalf("dropped %d", len(dropped))
+	}
+}
+
+func TestHeadTool(t *testing.T) {
+	cases := []struct {
+		tool       ScanTool
+		track      bool
+		confirmNum int
+		want       bool
+	}{
+		{&fakeHead{}, true, 3, true},
+		{&fakeHead{}, false, 3, false},
+		// ConfirmNum 为 0 时 seen 和 confirmed 在同一轮同一块 只推送 confirmed
+		{&fakeHead{}, true, 0, false},
+		{&scanOnly{}, true, 3, false},
+	}
+	for i, c := range cases {
+		st := &storeTool{ScanTool: c.tool, cfg: ChainScanCfg{TrackPending: c.track, ConfirmNum: c.confirmNum}}
+		if _, ok := st.headTool(); ok != c.want {
+			t.Fatalf("case %d: got %v", i, ok)
+		}
+	}
+}
+
+// scanOnly 只实现 ScanTool 的外部工具
+type scanOnly struct {
+	ScanTool
+}
+
+func TestAddPendingEmpty(t *testing.T) {
+	mr := testRedis(t)
+	if err := AddPending(&fakeHead{}, 10, nil); err != nil {
+		t.Fatal(err)
+	}
+	if keys := mr.Keys(); len(keys) != 0 {
+		t.Fatalf("keys %v", keys)
+	}
+}
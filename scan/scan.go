@@ -1,6 +1,7 @@
 package scan
 
 import (
+	"fmt"
 	"sync"
 )
 
@@ -9,9 +10,13 @@ type ChainScanCfg struct {
 	ConfirmNum   int
 	ContractList []Contract
 	Rpc          []string
+	// TrackPending 两阶段模式 交易出现在最新块时先推送 seen，达到 ConfirmNum 后推送 confirmed 或 dropped
+	// 需要链工具实现 HeadTool 且 ConfirmNum 大于 0
+	TrackPending bool
 }
 type storeTool struct {
 	Working []chan struct{}
+	Seeing  []chan struct{}
 	GoNum   int64
 	ScanTool
 	cfg ChainScanCfg
@@ -28,9 +33,11 @@ func newScan(gonum int64, cfgs ...ChainScanCfg) *Scan {
 			cfg:      cfg,
 			GoNum:    gonum,
 			Working:  make([]chan struct{}, gonum),
+			Seeing:   make([]chan struct{}, gonum),
 		}
 		for idx := range t.Working {
 			t.Working[idx] = make(chan struct{}, 1)
+			t.Seeing[idx] = make(chan struct{}, 1)
 		}
 		s.chain.Store(cfg.Chain, t)
 	}
@@ -53,6 +60,41 @@ func (s *Scan) AddContract(chainType ChainType, contracts ...Contract) {
 	}
 	stool.AddContract(contracts...)
 }
+func (s *Scan) tool(chainType ChainType) (*storeTool, error) {
+	tool, ok := s.chain.Load(chainType)
+	if !ok {
+		return nil, fmt.Errorf("chain %s not config", chainType)
+	}
+	return tool.(*storeTool), nil
+}
+
+func (s *Scan) balanceTool(chainType ChainType) (BalanceTool, error) {
+	tool, err := s.tool(chainType)
+	if err != nil {
+		return nil, err
+	}
+	balance, ok := tool.ScanTool.(BalanceTool)
+	if !ok {
+		return nil, fmt.Errorf("chain %s not support balance", chainType)
+	}
+	return balance, nil
+}
+
+func (s *Scan) Balances(chainType ChainType, addrs []string, tokens []string, block int64) ([]*Balance, error) {
+	tool, err := s.balanceTool(chainType)
+	if err != nil {
+		return nil, err
+	}
+	return tool.Balances(addrs, tokens, block)
+}
+
+func (s *Scan) Nonce(chainType ChainType, addr string, block int64) (uint64, error) {
+	tool, err := s.balanceTool(chainType)
+	if err != nil {
+		return 0, err
+	}
+	return tool.Nonce(addr, block)
+}
 func (s *Scan) Process() {
 	s.chain.Range(func(key, value any) (next bool) {
 		next = true
@@ -68,6 +110,18 @@ func (s *Scan) Process() {
 			idx := i
 			go s.process(tool, idx, nowBlockNum)
 		}
+		head, ok := tool.headTool()
+		if !ok {
+			return
+		}
+		headBlockNum, err := head.GetHeadBlockNum()
+		if err != nil {
+			return
+		}
+		for i := 0; i < int(tool.GoNum); i++ {
+			idx := i
+			go s.processSeen(tool, head, idx, headBlockNum)
+		}
 		return
 	})
 }
@@ -108,6 +162,14 @@ func (s *Scan) process(t *storeTool, idx int, nowBlockNum int64) {
 				Logger.Info("Process", "idx", idx, "block", scanBlock, "status", err)
 				return
 			}
+			if head, ok := t.headTool(); ok {
+				dropped, err := ConfirmPending(head, scanBlock, results)
+				if err != nil {
+					Logger.Info("Process", "idx", idx, "block", scanBlock, "status", err)
+					return
+				}
+				results = append(results, dropped...)
+			}
 			err = SetLastWork(t, idx, scanBlock)
 			if err != nil {
 				Logger.Info("Process", "idx", idx, "block", scanBlock, "status", err)
@@ -125,3 +187,63 @@ func (s *Scan) process(t *storeTool, idx int, nowBlockNum int64) {
 		return
 	}
 }
+
+// headTool 两阶段模式是否生效 ConfirmNum 为 0 时 seen 与 confirmed 是同一块 不推送 seen
+func (t *storeTool) headTool() (HeadTool, bool) {
+	if !t.cfg.TrackPending || t.cfg.ConfirmNum == 0 {
+		return nil, false
+	}
+	head, ok := t.ScanTool.(HeadTool)
+	return head, ok
+}
+
+// processSeen 两阶段模式下扫描最新块 推送 seen 并记录待确认交易
+func (s *Scan) processSeen(t *storeTool, head HeadTool, idx int, headBlockNum int64) {
+	select {
+	case t.Seeing[idx] <- struct{}{}:
+		defer func() {
+			<-t.Seeing[idx]
+		}()
+		scanBlock, err := GetLastSeen(t, idx)
+		if err != nil {
+			return
+		}
+		if scanBlock == 0 {
+			scanBlock = headBlockNum
+			err = SetLastSeen(t, idx, scanBlock-1)
+			if err != nil {
+				return
+			}
+		}
+		for {
+			scanBlock++
+			if scanBlock%t.GoNum != int64(idx) {
+				continue
+			}
+			if scanBlock > headBlockNum {
+				return
+			}
+			results, err := head.GetHeadLog(scanBlock)
+			if err != nil {
+				Logger.Info("ProcessSeen", "idx", idx, "block", scanBlock, "status", err)
+				return
+			}
+			err = AddPending(t, scanBlock, results)
+			if err != nil {
+				Logger.Info("ProcessSeen", "idx", idx, "block", scanBlock, "status", err)
+				return
+			}
+			err = SetLastSeen(t, idx, scanBlock)
+			if err != nil {
+				Logger.Info("ProcessSeen", "idx", idx, "block", scanBlock, "status", err)
+				return
+			}
+			if len(results) > 0 {
+				s.popChan <- results
+				Logger.Info("ProcessSeen", "idx", idx, "block", scanBlock, "headblock", headBlockNum, "status", "success")
+			}
+		}
+	default:
+		return
+	}
+}
@@ -0,0 +1,204 @@
+package scan
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/fbsobreira/gotron-sdk/pkg/address"
+	"github.com/shopspring/decimal"
+)
+
+// Signer 可插拔签名 (本地私钥、KMS、HSM ...)
+// SignHash 对 32 字节哈希签名 返回 65 字节 r||s||v 其中 v 为 0/1
+type Signer interface {
+	PublicKey() *ecdsa.PublicKey
+	SignHash(hash []byte) ([]byte, error)
+}
+
+type keySigner struct {
+	key *ecdsa.PrivateKey
+}
+
+// NewKeySigner 使用 hex 格式的 secp256k1 私钥
+func NewKeySigner(hexKey string) (Signer, error) {
+	key, err := crypto.HexToECDSA(hexKey)
+	if err != nil {
+		return nil, err
+	}
+	return &keySigner{key: key}, nil
+}
+
+func (k *keySigner) PublicKey() *ecdsa.PublicKey {
+	return &k.key.PublicKey
+}
+
+func (k *keySigner) SignHash(hash []byte) ([]byte, error) {
+	return crypto.Sign(hash, k.key)
+}
+
+// SignerAddress 签名者在对应链上的地址
+func SignerAddress(chain ChainType, signer Signer) (string, error) {
+	switch chain {
+	case Tron:
+		return address.PubkeyToAddress(*signer.PublicKey()).String(), nil
+	case Eth, BSC, Arbitrum:
+		return NormalizeAddress(chain, crypto.PubkeyToAddress(*signer.PublicKey()).Hex())
+	}
+	return "", fmt.Errorf("not support chain %s", chain)
+}
+
+type SendStatus string
+
+const (
+	SendPending   SendStatus = "pending"   //已广播 未上链或确认数不足
+	SendConfirmed SendStatus = "confirmed" //达到确认数且执行成功
+	SendFailed    SendStatus = "failed"    //已上链但执行失败
+)
+
+type SendResult struct {
+	TxId          string     `json:"txid"`
+	BlockNum      int64      `json:"blockNum"`
+	Confirmations int64      `json:"confirmations"`
+	Status        SendStatus `json:"status"`
+	Fee           string     `json:"fee"`
+}
+
+type SenderCfg struct {
+	ChainScanCfg
+	// FeeLimit tron 调用合约的最大手续费 单位 sun 默认 100 TRX
+	FeeLimit int64
+	// TipCap eth 系 EIP-1559 小费 单位 wei 为空时使用 eth_maxPriorityFeePerGas
+	TipCap *big.Int
+	// GasMultiplier 预估 gas 的放大系数 默认 1.2
+	GasMultiplier float64
+}
+
+// Sender 构建、签名并广播提现交易
+// contract 为空时转本币 否则必须是 ContractList 中的合约 amount 为换算 Decimals 后的金额
+type Sender interface {
+	ChainType() ChainType
+	Address() string
+	Transfer(to string, contract string, amount decimal.Decimal) (txid string, err error)
+	// Status 查询交易上链及确认情况
+	Status(txid string) (*SendResult, error)
+	// WaitConfirmed 轮询直到交易达到 ConfirmNum 且回执所在块未变 或失败
+	WaitConfirmed(ctx context.Context, txid string) (*SendResult, error)
+}
+
+func NewSender(cfg SenderCfg, signer Signer) (Sender, error) {
+	if signer == nil {
+		return nil, fmt.Errorf("signer is nil")
+	}
+	if len(cfg.Rpc) == 0 {
+		return nil, fmt.Errorf("rpc is empty")
+	}
+	if cfg.GasMultiplier <= 0 {
+		cfg.GasMultiplier = 1.2
+	}
+	if cfg.FeeLimit <= 0 {
+		cfg.FeeLimit = 100_000_000
+	}
+	from, err := SignerAddress(cfg.Chain, signer)
+	if err != nil {
+		return nil, err
+	}
+	switch cfg.Chain {
+	case Tron:
+		t := &tronTool{url: cfg.Rpc[0]}
+		t.AddContract(cfg.ContractList...)
+		return &tronSender{tool: t, cfg: cfg, signer: signer, from: from}, nil
+	case Eth, BSC, Arbitrum:
+		t := &ethTool{chain_type: cfg.Chain, url: cfg.Rpc[0]}
+		t.AddContract(cfg.ContractList...)
+		return &ethSender{tool: t, cfg: cfg, signer: signer, from: from}, nil
+	}
+	return nil, fmt.Errorf("not support chain %s", cfg.Chain)
+}
+
+// receiptFunc 查询交易回执 未上链时 BlockNum 为 0 blockHash 可为空
+type receiptFunc func(txid string) (result *SendResult, blockHash string, err error)
+
+// confirmByReceipt 块高达到 ConfirmNum 后重新查询回执 块高和块哈希不变才算确认
+// 不依赖扫块结果 未登记的合约或多条日志的交易同样可以确认 回滚后保持 pending 等待新的回执
+func confirmByReceipt(tool ScanTool, confirmNum int, txid string, receipt receiptFunc) (*SendResult, error) {
+	result, hash, err := receipt(txid)
+	if err != nil {
+		return nil, err
+	}
+	if result.Status != SendPending || result.BlockNum == 0 {
+		return result, nil
+	}
+	nowBlock, err := tool.GetBlockNum()
+	if err != nil {
+		return nil, err
+	}
+	result.Confirmations = nowBlock - result.BlockNum
+	if result.Confirmations < int64(confirmNum) {
+		return result, nil
+	}
+	again, againHash, err := receipt(txid)
+	if err != nil {
+		return nil, err
+	}
+	if again.BlockNum != result.BlockNum || !strings.EqualFold(againHash, hash) {
+		Logger.Info("confirmByReceipt", "txid", txid, "moved", fmt.Sprintf("%d -> %d", result.BlockNum, again.BlockNum))
+		again.Status = SendPending
+		again.Confirmations = 0
+		return again, nil
+	}
+	again.Confirmations = result.Confirmations
+	if again.Status == SendPending {
+		again.Status = SendConfirmed
+	}
+	return again, nil
+}
+
+func waitConfirmed(ctx context.Context, s Sender, txid string) (*SendResult, error) {
+	ticker := time.NewTicker(time.Second * 3)
+	defer ticker.Stop()
+	for {
+		result, err := s.Status(txid)
+		if err != nil {
+			Logger.Info("WaitConfirmed", "txid", txid, "status", err)
+		} else if result.Status != SendPending {
+			return result, nil
+		}
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RawValue ChainValue 的逆运算 把换算后的金额还原为链上整数
+func RawValue(amount decimal.Decimal, decimals uint8) (*big.Int, error) {
+	raw := amount.Shift(int32(decimals))
+	if !raw.IsInteger() {
+		return nil, fmt.Errorf("amount %s exceeds %d decimals", amount, decimals)
+	}
+	if raw.Sign() <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+	return raw.BigInt(), nil
+}
+
+// encodeTokenTransfer transfer(address,uint256) 的 calldata (hex 不带 0x)
+func encodeTokenTransfer(to []byte, amount *big.Int) string {
+	return TransferMethodId + hex.EncodeToString(leftPad(to, 32)) + hex.EncodeToString(leftPad(amount.Bytes(), 32))
+}
+
+func leftPad(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	out := make([]byte, size)
+	copy(out[size-len(b):], b)
+	return out
+}
@@ -0,0 +1,281 @@
+package scan
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/shopspring/decimal"
+)
+
+type ethSender struct {
+	tool    *ethTool
+	cfg     SenderCfg
+	signer  Signer
+	from    string
+	lock    sync.Mutex
+	nonce   uint64
+	chainId *big.Int
+}
+
+type TxReceiptResp struct {
+	Jsonrpc string    `json:"jsonrpc"`
+	ID      int64     `json:"id"`
+	Error   Error     `json:"error"`
+	Result  *TxResult `json:"result"`
+}
+
+type TxResult struct {
+	BlockNumber       string `json:"blockNumber"`
+	BlockHash         string `json:"blockHash"`
+	GasUsed           string `json:"gasUsed"`
+	EffectiveGasPrice string `json:"effectiveGasPrice"`
+	Status            Status `json:"status"`
+	TransactionHash   string `json:"transactionHash"`
+}
+
+type BaseFeeBlock struct {
+	BaseFeePerGas string `json:"baseFeePerGas"`
+}
+
+func (s *ethSender) ChainType() ChainType {
+	return s.cfg.Chain
+}
+
+func (s *ethSender) Address() string {
+	return s.from
+}
+
+func (s *ethSender) call(method string, params ...any) (json.RawMessage, error) {
+	out, err := s.tool.batchCall([]*JsonRpcParam{{Method: method, Params: params}})
+	if err != nil {
+		return nil, err
+	}
+	return out[0], nil
+}
+
+func (s *ethSender) callBig(method string, params ...any) (*big.Int, error) {
+	raw, err := s.call(method, params...)
+	if err != nil {
+		return nil, err
+	}
+	var str string
+	err = json.Unmarshal(raw, &str)
+	if err != nil {
+		return nil, err
+	}
+	val, ok := new(big.Int).SetString(strings.TrimPrefix(str, "0x"), 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid hex value %s", str)
+	}
+	return val, nil
+}
+
+// getChainId 需持有 lock
+func (s *ethSender) getChainId() (*big.Int, error) {
+	if s.chainId != nil {
+		return s.chainId, nil
+	}
+	id, err := s.callBig("eth_chainId")
+	if err != nil {
+		return nil, err
+	}
+	s.chainId = id
+	return id, nil
+}
+
+// nextNonce 取链上 pending nonce 与本地已用 nonce 的较大值 需持有 lock
+func (s *ethSender) nextNonce() (uint64, error) {
+	raw, err := s.call("eth_getTransactionCount", s.from, "pending")
+	if err != nil {
+		return 0, err
+	}
+	var str string
+	err = json.Unmarshal(raw, &str)
+	if err != nil {
+		return 0, err
+	}
+	nonce, err := strconv.ParseUint(str, 0, 64)
+	if err != nil {
+		return 0, err
+	}
+	if nonce < s.nonce {
+		nonce = s.nonce
+	}
+	return nonce, nil
+}
+
+// fees EIP-1559 maxFeePerGas = 2*baseFee + tip
+func (s *ethSender) fees() (tip *big.Int, feeCap *big.Int, err error) {
+	tip = s.cfg.TipCap
+	if tip == nil {
+		tip, err = s.callBig("eth_maxPriorityFeePerGas")
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	raw, err := s.call("eth_getBlockByNumber", "latest", false)
+	if err != nil {
+		return nil, nil, err
+	}
+	block := &BaseFeeBlock{}
+	err = json.Unmarshal(raw, block)
+	if err != nil {
+		return nil, nil, err
+	}
+	baseFee, ok := new(big.Int).SetString(strings.TrimPrefix(block.BaseFeePerGas, "0x"), 16)
+	if !ok {
+		return nil, nil, fmt.Errorf("chain not support EIP-1559")
+	}
+	feeCap = new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), tip)
+	return tip, feeCap, nil
+}
+
+func (s *ethSender) estimateGas(to string, value *big.Int, data []byte) (uint64, error) {
+	gas, err := s.callBig("eth_estimateGas", map[string]string{
+		"from":  s.from,
+		"to":    to,
+		"value": fmt.Sprintf("0x%x", value),
+		"data":  "0x" + hex.EncodeToString(data),
+	})
+	if err != nil {
+		return 0, err
+	}
+	return uint64(float64(gas.Uint64()) * s.cfg.GasMultiplier), nil
+}
+
+func (s *ethSender) Transfer(to string, contract string, amount decimal.Decimal) (string, error) {
+	to, err := NormalizeAddress(s.cfg.Chain, to)
+	if err != nil {
+		return "", err
+	}
+	txTo := to
+	value := new(big.Int)
+	var data []byte
+	if contract == "" {
+		value, err = RawValue(amount, 18)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		info, ok := s.tool.GetContract(contract)
+		if !ok {
+			return "", fmt.Errorf("contract %s not added", contract)
+		}
+		raw, err := RawValue(amount, info.Decimals)
+		if err != nil {
+			return "", err
+		}
+		data, _ = hex.DecodeString(encodeTokenTransfer(common.HexToAddress(to).Bytes(), raw))
+		txTo = info.Addr
+	}
+	toAddr := common.HexToAddress(txTo)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chainId, err := s.getChainId()
+	if err != nil {
+		return "", err
+	}
+	tip, feeCap, err := s.fees()
+	if err != nil {
+		return "", err
+	}
+	gas, err := s.estimateGas(txTo, value, data)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := s.nextNonce()
+	if err != nil {
+		return "", err
+	}
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainId,
+		Nonce:     nonce,
+		GasTipCap: tip,
+		GasFeeCap: feeCap,
+		Gas:       gas,
+		To:        &toAddr,
+		Value:     value,
+		Data:      data,
+	})
+	txSigner := types.LatestSignerForChainID(chainId)
+	sig, err := s.signer.SignHash(txSigner.Hash(tx).Bytes())
+	if err != nil {
+		return "", err
+	}
+	tx, err = tx.WithSignature(txSigner, sig)
+	if err != nil {
+		return "", err
+	}
+	rawTx, err := tx.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	_, err = s.call("eth_sendRawTransaction", "0x"+hex.EncodeToString(rawTx))
+	if err != nil {
+		return "", err
+	}
+	s.nonce = nonce + 1
+	return tx.Hash().Hex(), nil
+}
+
+func (s *ethSender) Status(txid string) (*SendResult, error) {
+	return confirmByReceipt(s.tool, s.cfg.ConfirmNum, txid, s.receipt)
+}
+
+func (s *ethSender) receipt(txid string) (*SendResult, string, error) {
+	idx := s.tool.requestId.Add(1)
+	out, code, err := Request(Post, s.tool.url, nil, &JsonRpcParam{
+		Jsonrpc: "2.0",
+		Method:  "eth_getTransactionReceipt",
+		Params:  []any{txid},
+		ID:      idx,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if code != 200 {
+		return nil, "", fmt.Errorf("code not 200")
+	}
+	resp := &TxReceiptResp{}
+	err = json.Unmarshal(out, resp)
+	if err != nil {
+		return nil, "", err
+	}
+	if resp.Error.Code != 0 {
+		return nil, "", errors.New(resp.Error.Message)
+	}
+	result := &SendResult{TxId: txid, Status: SendPending}
+	if resp.Result == nil {
+		return result, "", nil
+	}
+	result.BlockNum, err = strconv.ParseInt(resp.Result.BlockNumber, 0, 64)
+	if err != nil {
+		return nil, "", err
+	}
+	gasUsed, _ := new(big.Int).SetString(strings.TrimPrefix(resp.Result.GasUsed, "0x"), 16)
+	gasPrice, _ := new(big.Int).SetString(strings.TrimPrefix(resp.Result.EffectiveGasPrice, "0x"), 16)
+	if gasUsed != nil && gasPrice != nil {
+		fee, err := ChainValue(new(big.Int).Mul(gasUsed, gasPrice).String(), 18)
+		if err == nil {
+			result.Fee = fee.String()
+		}
+	}
+	if resp.Result.Status != SuccessStatus {
+		result.Status = SendFailed
+	}
+	return result, resp.Result.BlockHash, nil
+}
+
+func (s *ethSender) WaitConfirmed(ctx context.Context, txid string) (*SendResult, error) {
+	return waitConfirmed(ctx, s, txid)
+}
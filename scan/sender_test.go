@@ -0,0 +1,481 @@
+package scan
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/fbsobreira/gotron-sdk/pkg/address"
+	"github.com/fbsobreira/gotron-sdk/pkg/proto/core"
+	"github.com/shopspring/decimal"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const testKey = "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+
+const usdtTron = "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+
+func TestEncodeTokenTransfer(t *testing.T) {
+	to, _ := hex.DecodeString("5aaeb6053f3e94c9b9a09f33669435e7ef1beaed")
+	cases := []struct {
+		amount *big.Int
+		want   string
+	}{
+		{big.NewInt(1_000_000), "a9059cbb" +
+			"0000000000000000000000005aaeb6053f3e94c9b9a09f33669435e7ef1beaed" +
+			"00000000000000000000000000000000000000000000000000000000000f4240"},
+		{new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1)), "a9059cbb" +
+			"0000000000000000000000005aaeb6053f3e94c9b9a09f33669435e7ef1beaed" +
+			strings.Repeat("f", 64)},
+	}
+	for _, c := range cases {
+		got := encodeTokenTransfer(to, c.amount)
+		if got != c.want {
+			t.Fatalf("got %s", got)
+		}
+		// 与交易池解析互为逆运算
+		call, ok := decodeTokenCall(got)
+		if !ok || call.to != hex.EncodeToString(to) || call.amount.Cmp(c.amount) != 0 {
+			t.Fatalf("decode %+v", call)
+		}
+	}
+}
+
+func TestRawValue(t *testing.T) {
+	v, err := RawValue(decimal.RequireFromString("1.5"), 18)
+	if err != nil || v.String() != "1500000000000000000" {
+		t.Fatalf("%v %v", v, err)
+	}
+	for _, amount := range []string{"0", "-1", "0.0000001"} {
+		if _, err := RawValue(decimal.RequireFromString(amount), 6); err == nil {
+			t.Fatalf("%s: expected error", amount)
+		}
+	}
+}
+
+// ethNode 记录广播的交易 baseFee 为空时模拟不支持 EIP-1559 的节点
+type ethNode struct {
+	lock    sync.Mutex
+	nonce   string
+	baseFee string
+	sent    []*types.Transaction
+}
+
+func (n *ethNode) handle(t *testing.T) func(method string, params []json.RawMessage) any {
+	return func(method string, params []json.RawMessage) any {
+		n.lock.Lock()
+		defer n.lock.Unlock()
+		switch method {
+		case "eth_chainId":
+			return "0x1"
+		case "eth_maxPriorityFeePerGas":
+			return "0x3"
+		case "eth_getBlockByNumber":
+			return map[string]any{"baseFeePerGas": n.baseFee}
+		case "eth_estimateGas":
+			return "0x5208"
+		case "eth_getTransactionCount":
+			return n.nonce
+		case "eth_sendRawTransaction":
+			var raw string
+			json.Unmarshal(params[0], &raw)
+			tx := &types.Transaction{}
+			data, _ := hex.DecodeString(strings.TrimPrefix(raw, "0x"))
+			if err := tx.UnmarshalBinary(data); err != nil {
+				t.Error(err)
+				return err
+			}
+			n.sent = append(n.sent, tx)
+			return tx.Hash().Hex()
+		}
+		return errors.New("unexpected method " + method)
+	}
+}
+
+func TestEthSender(t *testing.T) {
+	node := &ethNode{nonce: "0x5", baseFee: "0x64"}
+	srv := newRPCServer(t, node.handle(t))
+	signer, _ := NewKeySigner(testKey)
+	const token = "0xdac17f958d2ee523a2206206994597c13d831ec7"
+	sender, err := NewSender(SenderCfg{
+		ChainScanCfg: ChainScanCfg{Chain: Eth, Rpc: []string{srv.URL}, ContractList: []Contract{{Addr: token, TokenName: "USDT", Decimals: 6}}},
+		TipCap:       big.NewInt(2),
+	}, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	txid, err := sender.Transfer(to, "", decimal.RequireFromString("1.5"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 节点 pending nonce 未更新时使用本地 nonce
+	if _, err := sender.Transfer(to, token, decimal.RequireFromString("2.5")); err != nil {
+		t.Fatal(err)
+	}
+	if len(node.sent) != 2 {
+		t.Fatalf("sent %d", len(node.sent))
+	}
+	native, usdt := node.sent[0], node.sent[1]
+	if native.Hash().Hex() != txid {
+		t.Fatalf("txid %s", txid)
+	}
+	if native.Nonce() != 5 || usdt.Nonce() != 6 {
+		t.Fatalf("nonce %d %d", native.Nonce(), usdt.Nonce())
+	}
+	for _, tx := range node.sent {
+		// feeCap = 2*baseFee + tip
+		if tx.Type() != types.DynamicFeeTxType || tx.GasTipCap().Int64() != 2 || tx.GasFeeCap().Int64() != 202 || tx.Gas() != 25200 || tx.ChainId().Int64() != 1 {
+			t.Fatalf("fees %d %d %d", tx.GasTipCap(), tx.GasFeeCap(), tx.Gas())
+		}
+		from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+		if err != nil || !strings.EqualFold(from.Hex(), sender.Address()) {
+			t.Fatalf("from %s %v", from.Hex(), err)
+		}
+	}
+	if *native.To() != common.HexToAddress(to) || native.Value().String() != "1500000000000000000" || len(native.Data()) != 0 {
+		t.Fatalf("native %s %s", native.To(), native.Value())
+	}
+	wantData := encodeTokenTransfer(common.HexToAddress(to).Bytes(), big.NewInt(2_500_000))
+	if *usdt.To() != common.HexToAddress(token) || usdt.Value().Sign() != 0 || hex.EncodeToString(usdt.Data()) != wantData {
+		t.Fatalf("usdt %s %x", usdt.To(), usdt.Data())
+	}
+	// 节点 nonce 领先时使用节点 nonce
+	node.nonce = "0x9"
+	if _, err := sender.Transfer(to, "", decimal.RequireFromString("1")); err != nil {
+		t.Fatal(err)
+	}
+	if node.sent[2].Nonce() != 9 {
+		t.Fatalf("nonce %d", node.sent[2].Nonce())
+	}
+}
+
+func TestEthSenderRejects(t *testing.T) {
+	node := &ethNode{nonce: "0x0"}
+	srv := newRPCServer(t, node.handle(t))
+	signer, _ := NewKeySigner(testKey)
+	sender, err := NewSender(SenderCfg{ChainScanCfg: ChainScanCfg{Chain: BSC, Rpc: []string{srv.URL}}}, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	// 节点不返回 baseFee
+	if _, err := sender.Transfer(to, "", decimal.RequireFromString("1")); err == nil {
+		t.Fatal("expected EIP-1559 error")
+	}
+	node.baseFee = "0x64"
+	if _, err := sender.Transfer("0x1234", "", decimal.RequireFromString("1")); err == nil {
+		t.Fatal("expected address error")
+	}
+	if _, err := sender.Transfer(to, "0xdac17f958d2ee523a2206206994597c13d831ec7", decimal.RequireFromString("1")); err == nil {
+		t.Fatal("expected contract error")
+	}
+	if len(node.sent) != 0 {
+		t.Fatalf("sent %d", len(node.sent))
+	}
+}
+
+// tronNode 按请求构建交易 tamper 模拟被篡改的节点
+type tronNode struct {
+	lock      sync.Mutex
+	tamper    func(raw *core.TransactionRaw)
+	badTxID   bool
+	broadcast []string
+}
+
+func (n *tronNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	req := map[string]any{}
+	json.NewDecoder(r.Body).Decode(&req)
+	addr := func(key string) []byte {
+		a, _ := address.Base58ToAddress(req[key].(string))
+		return a
+	}
+	var contract *core.Transaction_Contract
+	var feeLimit int64
+	switch r.URL.Path {
+	case createTransaction:
+		param, _ := anypb.New(&core.TransferContract{OwnerAddress: addr("owner_address"), ToAddress: addr("to_address"), Amount: int64(req["amount"].(float64))})
+		contract = &core.Transaction_Contract{Type: core.Transaction_Contract_TransferContract, Parameter: param}
+	case triggerSmartContract:
+		data, _ := hex.DecodeString(TransferMethodId + req["parameter"].(string))
+		param, _ := anypb.New(&core.TriggerSmartContract{OwnerAddress: addr("owner_address"), ContractAddress: addr("contract_address"), Data: data})
+		contract = &core.Transaction_Contract{Type: core.Transaction_Contract_TriggerSmartContract, Parameter: param}
+		feeLimit = int64(req["fee_limit"].(float64))
+	case broadcastHex:
+		n.broadcast = append(n.broadcast, req["transaction"].(string))
+		json.NewEncoder(w).Encode(map[string]any{"result": true})
+		return
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	raw := &core.TransactionRaw{
+		RefBlockBytes: []byte{0x12, 0x34},
+		RefBlockHash:  []byte{1, 2, 3, 4, 5, 6, 7, 8},
+		Expiration:    1700000060000,
+		Timestamp:     1700000000000,
+		FeeLimit:      feeLimit,
+		Contract:      []*core.Transaction_Contract{contract},
+	}
+	if n.tamper != nil {
+		n.tamper(raw)
+	}
+	rawBytes, _ := proto.Marshal(raw)
+	hash := sha256.Sum256(rawBytes)
+	if n.badTxID {
+		hash[0] ^= 1
+	}
+	tx := map[string]any{"txID": hex.EncodeToString(hash[:]), "raw_data_hex": hex.EncodeToString(rawBytes)}
+	if r.URL.Path == triggerSmartContract {
+		json.NewEncoder(w).Encode(map[string]any{"result": map[string]any{"result": true}, "transaction": tx})
+		return
+	}
+	json.NewEncoder(w).Encode(tx)
+}
+
+func newTronSender(t *testing.T, node *tronNode) Sender {
+	srv := httptest.NewServer(node)
+	t.Cleanup(srv.Close)
+	signer, _ := NewKeySigner(testKey)
+	sender, err := NewSender(SenderCfg{
+		ChainScanCfg: ChainScanCfg{Chain: Tron, Rpc: []string{srv.URL}, ContractList: []Contract{{Addr: usdtTron, TokenName: "USDT", Decimals: 6}}},
+		FeeLimit:     30_000_000,
+	}, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sender
+}
+
+func TestTronSender(t *testing.T) {
+	node := &tronNode{}
+	sender := newTronSender(t, node)
+	to, _ := TronHexToBase58("41" + strings.Repeat("11", 20))
+	signer, _ := NewKeySigner(testKey)
+	for _, contract := range []string{"", usdtTron} {
+		txid, err := sender.Transfer(to, contract, decimal.RequireFromString("1.25"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		signed, _ := hex.DecodeString(node.broadcast[len(node.broadcast)-1])
+		tx := &core.Transaction{}
+		if err := proto.Unmarshal(signed, tx); err != nil {
+			t.Fatal(err)
+		}
+		raw, _ := proto.Marshal(tx.RawData)
+		hash := sha256.Sum256(raw)
+		if hex.EncodeToString(hash[:]) != txid || len(tx.Signature) != 1 {
+			t.Fatalf("txid %s", txid)
+		}
+		pub, err := crypto.SigToPub(hash[:], tx.Signature[0])
+		if err != nil || !pub.Equal(signer.PublicKey()) {
+			t.Fatalf("signature %v", err)
+		}
+	}
+	if len(node.broadcast) != 2 {
+		t.Fatalf("broadcast %d", len(node.broadcast))
+	}
+}
+
+func TestTronSenderTampered(t *testing.T) {
+	other, _ := address.Base58ToAddress("T9yD14Nj9j7xAB4dbGeiX9h8unkKHxuWwb")
+	setParam := func(raw *core.TransactionRaw, f func(m proto.Message)) {
+		var m proto.Message = &core.TransferContract{}
+		if raw.Contract[0].Type == core.Transaction_Contract_TriggerSmartContract {
+			m = &core.TriggerSmartContract{}
+		}
+		raw.Contract[0].Parameter.UnmarshalTo(m)
+		f(m)
+		raw.Contract[0].Parameter, _ = anypb.New(m)
+	}
+	cases := []struct {
+		name     string
+		contract string
+		tamper   func(raw *core.TransactionRaw)
+	}{
+		{"to", "", func(raw *core.TransactionRaw) {
+			setParam(raw, func(m proto.Message) { m.(*core.TransferContract).ToAddress = other })
+		}},
+		{"amount", "", func(raw *core.TransactionRaw) {
+			setParam(raw, func(m proto.Message) { m.(*core.TransferContract).Amount *= 10 })
+		}},
+		{"owner", "", func(raw *core.TransactionRaw) {
+			setParam(raw, func(m proto.Message) { m.(*core.TransferContract).OwnerAddress = other })
+		}},
+		{"type", "", func(raw *core.TransactionRaw) {
+			raw.Contract[0].Type = core.Transaction_Contract_TransferAssetContract
+		}},
+		{"extra contract", "", func(raw *core.TransactionRaw) {
+			raw.Contract = append(raw.Contract, raw.Contract[0])
+		}},
+		{"memo", "", func(raw *core.TransactionRaw) {
+			raw.Data = []byte("memo")
+		}},
+		{"permission", "", func(raw *core.TransactionRaw) {
+			raw.Contract[0].PermissionId = 2
+		}},
+		{"token recipient", usdtTron, func(raw *core.TransactionRaw) {
+			setParam(raw, func(m proto.Message) {
+				p := m.(*core.TriggerSmartContract)
+				p.Data = []byte(encodeTokenTransfer(other[1:], big.NewInt(1_250_000)))
+			})
+		}},
+		{"token contract", usdtTron, func(raw *core.TransactionRaw) {
+			setParam(raw, func(m proto.Message) { m.(*core.TriggerSmartContract).ContractAddress = other })
+		}},
+		{"call value", usdtTron, func(raw *core.TransactionRaw) {
+			setParam(raw, func(m proto.Message) { m.(*core.TriggerSmartContract).CallValue = 1 })
+		}},
+		{"fee limit", usdtTron, func(raw *core.TransactionRaw) {
+			raw.FeeLimit = 1_000_000_000
+		}},
+	}
+	to, _ := TronHexToBase58("41" + strings.Repeat("11", 20))
+	for _, c := range cases {
+		node := &tronNode{tamper: c.tamper}
+		sender := newTronSender(t, node)
+		if _, err := sender.Transfer(to, c.contract, decimal.RequireFromString("1.25")); !errors.Is(err, ErrTxMismatch) {
+			t.Fatalf("%s: err %v", c.name, err)
+		}
+		if len(node.broadcast) != 0 {
+			t.Fatalf("%s: broadcast", c.name)
+		}
+	}
+	// txID 与 raw_data 不一致
+	node := &tronNode{badTxID: true}
+	sender := newTronSender(t, node)
+	if _, err := sender.Transfer(to, "", decimal.RequireFromString("1")); !errors.Is(err, ErrTxMismatch) || len(node.broadcast) != 0 {
+		t.Fatalf("txid: err %v", err)
+	}
+}
+
+// fakeHeight 只提供最新块高
+type fakeHeight struct {
+	ScanTool
+	now int64
+}
+
+func (f *fakeHeight) GetBlockNum() (int64, error) { return f.now, nil }
+
+// receiptSeq 依次返回的回执和块哈希 用完后重复最后一个
+type receiptSeq struct {
+	results []SendResult
+	hashes  []string
+	calls   int
+}
+
+func (r *receiptSeq) receipt(txid string) (*SendResult, string, error) {
+	last := func(n int) int {
+		if r.calls < n {
+			return r.calls
+		}
+		return n - 1
+	}
+	result := r.results[last(len(r.results))]
+	hash := r.hashes[last(len(r.hashes))]
+	r.calls++
+	result.TxId = txid
+	return &result, hash, nil
+}
+
+func TestConfirmByReceipt(t *testing.T) {
+	mined := SendResult{BlockNum: 100, Status: SendPending}
+	moved := SendResult{BlockNum: 102, Status: SendPending}
+	cases := []struct {
+		name       string
+		seq        *receiptSeq
+		confirmNum int
+		want       SendStatus
+		conf       int64
+		calls      int
+	}{
+		{"confirmed", &receiptSeq{results: []SendResult{mined}, hashes: []string{"0xAA", "0xaa"}}, 5, SendConfirmed, 5, 2},
+		{"not enough confirmations", &receiptSeq{results: []SendResult{mined}, hashes: []string{"0xaa"}}, 6, SendPending, 5, 1},
+		{"not mined", &receiptSeq{results: []SendResult{{Status: SendPending}}, hashes: []string{""}}, 5, SendPending, 0, 1},
+		{"failed", &receiptSeq{results: []SendResult{{BlockNum: 100, Status: SendFailed}}, hashes: []string{"0xaa"}}, 5, SendFailed, 0, 1},
+		// 达到确认数后回执已到其他块 (回滚)
+		{"moved block", &receiptSeq{results: []SendResult{mined, moved}, hashes: []string{"0xaa", "0xbb"}}, 5, SendPending, 0, 2},
+		{"same height new hash", &receiptSeq{results: []SendResult{mined}, hashes: []string{"0xaa", "0xbb"}}, 5, SendPending, 0, 2},
+		{"dropped", &receiptSeq{results: []SendResult{mined, {Status: SendPending}}, hashes: []string{"0xaa", ""}}, 5, SendPending, 0, 2},
+	}
+	tool := &fakeHeight{now: 105}
+	for _, c := range cases {
+		result, err := confirmByReceipt(tool, c.confirmNum, "0xab", c.seq.receipt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Status != c.want || result.Confirmations != c.conf || c.seq.calls != c.calls {
+			t.Fatalf("%s: %s %d calls %d", c.name, result.Status, result.Confirmations, c.seq.calls)
+		}
+	}
+}
+
+func TestEthSenderStatus(t *testing.T) {
+	const registered = "0xdac17f958d2ee523a2206206994597c13d831ec7"
+	const unregistered = "0x1111111111111111111111111111111111111111"
+	logs := func(contract string, n int) []map[string]any {
+		out := make([]map[string]any, n)
+		for i := range out {
+			out[i] = map[string]any{"address": contract, "topics": []string{TransferTopic}, "data": "0x"}
+		}
+		return out
+	}
+	receipts := map[string]map[string]any{
+		// 手续费代币 转账 + 扣费 + 销毁 三条日志
+		"0x01": {"blockNumber": "0x64", "blockHash": "0xaa", "status": "0x1", "to": registered, "logs": logs(registered, 3)},
+		"0x02": {"blockNumber": "0x64", "blockHash": "0xaa", "status": "0x1", "to": unregistered, "logs": logs(unregistered, 1)},
+		"0x03": {"blockNumber": "0x64", "blockHash": "0xaa", "status": "0x0", "to": registered, "logs": logs(registered, 0)},
+	}
+	srv := newRPCServer(t, func(method string, params []json.RawMessage) any {
+		switch method {
+		case "eth_blockNumber":
+			return "0x69"
+		case "eth_getTransactionReceipt":
+			var txid string
+			json.Unmarshal(params[0], &txid)
+			if r, ok := receipts[txid]; ok {
+				return r
+			}
+			return nil
+		}
+		return errors.New("unexpected method " + method)
+	})
+	signer, _ := NewKeySigner(testKey)
+	sender, err := NewSender(SenderCfg{ChainScanCfg: ChainScanCfg{
+		Chain: Eth, Rpc: []string{srv.URL}, ConfirmNum: 5,
+		ContractList: []Contract{{Addr: registered, TokenName: "FOT", Decimals: 6}},
+	}}, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for txid, want := range map[string]SendStatus{"0x01": SendConfirmed, "0x02": SendConfirmed, "0x03": SendFailed} {
+		result, err := sender.WaitConfirmed(ctx, txid)
+		if err != nil {
+			t.Fatalf("%s: %v", txid, err)
+		}
+		if result.Status != want || result.BlockNum != 100 {
+			t.Fatalf("%s: %+v", txid, result)
+		}
+	}
+	result, err := sender.Status("0x04")
+	if err != nil || result.Status != SendPending || result.BlockNum != 0 {
+		t.Fatalf("unknown tx: %+v %v", result, err)
+	}
+}
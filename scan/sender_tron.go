@@ -0,0 +1,297 @@
+package scan
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/fbsobreira/gotron-sdk/pkg/address"
+	"github.com/fbsobreira/gotron-sdk/pkg/proto/core"
+	"github.com/shopspring/decimal"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+const createTransaction = "/wallet/createtransaction"
+const triggerSmartContract = "/wallet/triggersmartcontract"
+const broadcastHex = "/wallet/broadcasthex"
+const getTransactionInfoById = "/wallet/gettransactioninfobyid"
+
+// ErrTxMismatch 节点构建的交易与提现请求不一致
+var ErrTxMismatch = fmt.Errorf("transaction from node does not match request")
+
+type tronSender struct {
+	tool   *tronTool
+	cfg    SenderCfg
+	signer Signer
+	from   string
+}
+
+type TronTriggerResult struct {
+	Result      TronReturn      `json:"result"`
+	Transaction json.RawMessage `json:"transaction"`
+}
+
+type TronBroadcastResult struct {
+	Result  bool   `json:"result"`
+	TxId    string `json:"txid"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type TronTxInfo struct {
+	ID          string  `json:"id"`
+	Fee         int64   `json:"fee"`
+	BlockNumber int64   `json:"blockNumber"`
+	Result      string  `json:"result"`
+	Receipt     Receipt `json:"receipt"`
+}
+
+func (s *tronSender) ChainType() ChainType {
+	return Tron
+}
+
+func (s *tronSender) Address() string {
+	return s.from
+}
+
+func (s *tronSender) post(path string, param any) ([]byte, error) {
+	resp, code, err := Request(Post, s.tool.url+path, nil, param)
+	if err != nil {
+		return nil, err
+	}
+	if code != 200 {
+		return nil, fmt.Errorf("code not 200")
+	}
+	return resp, nil
+}
+
+// tronTransfer 一笔提现的预期内容 用于核对节点构建的交易
+type tronTransfer struct {
+	to       string    // base58
+	contract *Contract // 本币为空
+	amount   *big.Int
+	owner    []byte // 21 字节地址
+	toAddr   []byte // 21 字节地址
+	feeLimit int64
+}
+
+// TronBuildResult createtransaction/triggersmartcontract 返回的未签名交易
+type TronBuildResult struct {
+	TxID       string `json:"txID"`
+	RawDataHex string `json:"raw_data_hex"`
+	Error      string `json:"Error"`
+}
+
+func (s *tronSender) newTransfer(to string, contract string, amount decimal.Decimal) (*tronTransfer, error) {
+	req := &tronTransfer{to: to}
+	decimals := uint8(6)
+	if contract != "" {
+		info, ok := s.tool.GetContract(contract)
+		if !ok {
+			return nil, fmt.Errorf("contract %s not added", contract)
+		}
+		req.contract = info
+		req.feeLimit = s.cfg.FeeLimit
+		decimals = info.Decimals
+	}
+	value, err := RawValue(amount, decimals)
+	if err != nil {
+		return nil, err
+	}
+	if !value.IsInt64() {
+		return nil, fmt.Errorf("amount %s overflow", amount)
+	}
+	req.amount = value
+	req.owner, err = address.Base58ToAddress(s.from)
+	if err != nil {
+		return nil, err
+	}
+	req.toAddr, err = address.Base58ToAddress(to)
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// buildTransaction 由节点构建未签名交易 返回 raw_data 的 protobuf 编码
+func (s *tronSender) buildTransaction(req *tronTransfer) (*TronBuildResult, error) {
+	var rawTx []byte
+	var err error
+	if req.contract == nil {
+		rawTx, err = s.post(createTransaction, map[string]any{
+			"owner_address": s.from,
+			"to_address":    req.to,
+			"amount":        req.amount.Int64(),
+			"visible":       true,
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		resp, err := s.post(triggerSmartContract, map[string]any{
+			"owner_address":     s.from,
+			"contract_address":  req.contract.Addr,
+			"function_selector": "transfer(address,uint256)",
+			"parameter":         encodeTokenTransfer(req.toAddr[1:], req.amount)[len(TransferMethodId):],
+			"fee_limit":         req.feeLimit,
+			"call_value":        0,
+			"visible":           true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		result := &TronTriggerResult{}
+		err = json.Unmarshal(resp, result)
+		if err != nil {
+			return nil, err
+		}
+		if !result.Result.Result {
+			return nil, fmt.Errorf("trigger fail %s %s", result.Result.Code, decodeTronMessage(result.Result.Message))
+		}
+		rawTx = result.Transaction
+	}
+	tx := &TronBuildResult{}
+	err = json.Unmarshal(rawTx, tx)
+	if err != nil {
+		return nil, err
+	}
+	if tx.Error != "" {
+		return nil, fmt.Errorf("create transaction fail %s", tx.Error)
+	}
+	return tx, nil
+}
+
+// verifyTransaction 解码节点返回的 raw_data 逐项核对 防止节点替换收款地址、金额、合约或手续费上限
+func verifyTransaction(raw []byte, req *tronTransfer) error {
+	txRaw := &core.TransactionRaw{}
+	if err := proto.Unmarshal(raw, txRaw); err != nil {
+		return err
+	}
+	if len(txRaw.Contract) != 1 || len(txRaw.Data) != 0 || len(txRaw.Scripts) != 0 || len(txRaw.Auths) != 0 {
+		return ErrTxMismatch
+	}
+	contract := txRaw.Contract[0]
+	if contract.PermissionId != 0 || contract.Parameter == nil {
+		return ErrTxMismatch
+	}
+	if req.contract == nil {
+		param := &core.TransferContract{}
+		if contract.Type != core.Transaction_Contract_TransferContract || contract.Parameter.UnmarshalTo(param) != nil {
+			return ErrTxMismatch
+		}
+		if !bytes.Equal(param.OwnerAddress, req.owner) || !bytes.Equal(param.ToAddress, req.toAddr) ||
+			param.Amount != req.amount.Int64() || txRaw.FeeLimit != 0 {
+			return ErrTxMismatch
+		}
+		return nil
+	}
+	param := &core.TriggerSmartContract{}
+	if contract.Type != core.Transaction_Contract_TriggerSmartContract || contract.Parameter.UnmarshalTo(param) != nil {
+		return ErrTxMismatch
+	}
+	contractAddr, err := address.Base58ToAddress(req.contract.Addr)
+	if err != nil {
+		return err
+	}
+	data, _ := hex.DecodeString(encodeTokenTransfer(req.toAddr[1:], req.amount))
+	if !bytes.Equal(param.OwnerAddress, req.owner) || !bytes.Equal(param.ContractAddress, contractAddr) ||
+		!bytes.Equal(param.Data, data) || param.CallValue != 0 || param.CallTokenValue != 0 || param.TokenId != 0 ||
+		txRaw.FeeLimit != req.feeLimit {
+		return ErrTxMismatch
+	}
+	return nil
+}
+
+// decodeTronMessage 节点返回的错误信息通常是 hex 编码
+func decodeTronMessage(msg string) string {
+	if out, err := hex.DecodeString(msg); err == nil {
+		return string(out)
+	}
+	return msg
+}
+
+func (s *tronSender) Transfer(to string, contract string, amount decimal.Decimal) (string, error) {
+	to, err := NormalizeAddress(Tron, to)
+	if err != nil {
+		return "", err
+	}
+	req, err := s.newTransfer(to, contract, amount)
+	if err != nil {
+		return "", err
+	}
+	tx, err := s.buildTransaction(req)
+	if err != nil {
+		return "", err
+	}
+	raw, err := hex.DecodeString(tx.RawDataHex)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(raw)
+	if !strings.EqualFold(hex.EncodeToString(hash[:]), tx.TxID) {
+		return "", ErrTxMismatch
+	}
+	err = verifyTransaction(raw, req)
+	if err != nil {
+		return "", err
+	}
+	sig, err := s.signer.SignHash(hash[:])
+	if err != nil {
+		return "", err
+	}
+	//广播签名时的 raw_data 原文 不经节点重新序列化
+	signed := protowire.AppendTag(nil, 1, protowire.BytesType)
+	signed = protowire.AppendBytes(signed, raw)
+	signed = protowire.AppendTag(signed, 2, protowire.BytesType)
+	signed = protowire.AppendBytes(signed, sig)
+	resp, err := s.post(broadcastHex, map[string]any{"transaction": hex.EncodeToString(signed)})
+	if err != nil {
+		return "", err
+	}
+	result := &TronBroadcastResult{}
+	err = json.Unmarshal(resp, result)
+	if err != nil {
+		return "", err
+	}
+	if !result.Result {
+		return "", fmt.Errorf("broadcast fail %s %s", result.Code, decodeTronMessage(result.Message))
+	}
+	return hex.EncodeToString(hash[:]), nil
+}
+
+func (s *tronSender) Status(txid string) (*SendResult, error) {
+	return confirmByReceipt(s.tool, s.cfg.ConfirmNum, txid, s.receipt)
+}
+
+// receipt tron 回执没有块哈希 只比较块高
+func (s *tronSender) receipt(txid string) (*SendResult, string, error) {
+	resp, err := s.post(getTransactionInfoById, map[string]any{"value": txid})
+	if err != nil {
+		return nil, "", err
+	}
+	info := &TronTxInfo{}
+	err = json.Unmarshal(resp, info)
+	if err != nil {
+		return nil, "", err
+	}
+	result := &SendResult{TxId: txid, Status: SendPending}
+	if info.ID == "" {
+		return result, "", nil
+	}
+	result.BlockNum = info.BlockNumber
+	result.Fee = decimal.NewFromInt(info.Fee).Div(trxDecimal).String()
+	if info.Result == "FAILED" || (info.Receipt.Result != "" && info.Receipt.Result != Success) {
+		result.Status = SendFailed
+	}
+	return result, "", nil
+}
+
+func (s *tronSender) WaitConfirmed(ctx context.Context, txid string) (*SendResult, error) {
+	return waitConfirmed(ctx, s, txid)
+}
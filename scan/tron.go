@@ -138,6 +138,7 @@ const getLastBlock = "/wallet/getblock"
 
 const getTranByNum = "/wallet/gettransactioninfobyblocknum"
 const getTrxTranByNum = "/walletsolidity/getblockbynum"
+const getHeadTrxTranByNum = "/wallet/getblockbynum"
 
 type TronBlockInfo struct {
 	BlockID     string      `json:"blockID"`
@@ -161,6 +162,7 @@ type tronTool struct {
 	url string
 	// solidUrl   string
 	monitorMap sync.Map // map[string]*Contract
+	watchMap   sync.Map // 交易池关注地址
 }
 
 func (t *tronTool) GetBlockNum() (int64, error) {
@@ -217,12 +219,34 @@ func (t *tronTool) GetLog(blockNum int64) ([]*ContractTokenTran, error) {
 	return out, err
 }
 
+// GetHeadBlockNum 全节点最新块 (未固化)
+func (t *tronTool) GetHeadBlockNum() (int64, error) {
+	return t.getLastBlockNum()
+}
+
+// GetHeadLog 从全节点读取未固化块的交易
+func (t *tronTool) GetHeadLog(blockNum int64) ([]*ContractTokenTran, error) {
+	lastBlockNum, err := t.getLastBlockNum()
+	if err != nil {
+		return nil, err
+	}
+	trx, err := t.trxTransfer(getHeadTrxTranByNum, blockNum, lastBlockNum)
+	if err != nil {
+		return nil, err
+	}
+	return t.Trc20Transfer(blockNum, lastBlockNum, trx)
+}
+
 // TrxTransfer 爬块 trx 交易
 func (t *tronTool) TrxTransfer(blockNum int64, lastBlock int64) (map[string]*ContractTokenTran, error) {
+	return t.trxTransfer(getTrxTranByNum, blockNum, lastBlock)
+}
+
+func (t *tronTool) trxTransfer(path string, blockNum int64, lastBlock int64) (map[string]*ContractTokenTran, error) {
 	param := make(map[string]any)
 	param["num"] = blockNum
 	param["visible"] = true
-	resp, code, err := Request(Post, t.url+getTrxTranByNum, nil, param)
+	resp, code, err := Request(Post, t.url+path, nil, param)
 	if err != nil {
 		return nil, err
 	}
@@ -262,8 +286,8 @@ func (t *tronTool) TrxTransfer(blockNum int64, lastBlock int64) (map[string]*Con
 				continue
 			}
 			tmp.Transfers = append(tmp.Transfers, &CallbackTransfer{
-				FromAddress: value.OwnerAddress,
-				ToAddress:   value.ToAddress,
+				FromAddress: normalizeAddress(Tron, value.OwnerAddress),
+				ToAddress:   normalizeAddress(Tron, value.ToAddress),
 				Contract:    "TRX",
 				Symbol:      "TRX",
 				Amount:      amount.Div(trxDecimal).String(),
@@ -394,12 +418,13 @@ func (t *tronTool) GetTrc20Decimal(addr string) {
 func (t *tronTool) AddContract(c ...Contract) {
 	for idx := range c {
 		data := c[idx]
+		data.Addr = normalizeAddress(Tron, data.Addr)
 		t.monitorMap.Store(data.Addr, &data)
 	}
 }
 
 func (t *tronTool) GetContract(address string) (*Contract, bool) {
-	info, ok := t.monitorMap.Load(address)
+	info, ok := t.monitorMap.Load(normalizeAddress(Tron, address))
 	if !ok {
 		return nil, false
 	}
@@ -0,0 +1,350 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/suiguo/hwlib/gjwt"
+	"github.com/suiguo/hwlib/redis"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrRevoked   = errors.New("session: token revoked")
+	ErrReused    = errors.New("session: refresh token reused, family revoked")
+	ErrTokenType = errors.New("session: wrong token type")
+	ErrNoSubject = errors.New("session: token has no subject")
+)
+
+const (
+	TypeAccess  = "access"
+	TypeRefresh = "refresh"
+)
+
+// Claims access和refresh令牌共用的claims
+type Claims struct {
+	Data    interface{} `json:"data,omitempty"`
+	Type    string      `json:"typ"`
+	Version int64       `json:"ver"`           // 签发时用户的版本号 LogoutAll后旧版本全部失效
+	Family  string      `json:"fam,omitempty"` // refresh令牌家族 同一次登录轮换出的令牌共用
+	gojwt.RegisteredClaims
+}
+
+// Pair 登录或刷新后返回给客户端的令牌
+type Pair struct {
+	AccessToken      string    `json:"access_token"`
+	RefreshToken     string    `json:"refresh_token"`
+	AccessExpiresAt  time.Time `json:"access_expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+type Config struct {
+	Method gjwt.MethodSigning `json:"method"`
+	// 不为空时用kid密钥签名
+	Kid        string        `json:"kid"`
+	AccessTTL  time.Duration `json:"access_ttl"`  // 默认15分钟
+	RefreshTTL time.Duration `json:"refresh_ttl"` // 默认7天 每次刷新重新计算
+	Prefix     string        `json:"prefix"`      // redis key前缀 默认session:
+	// 签发时附加iss/aud等 验证时附加对应的Require
+	ClaimOptions  []gjwt.ClaimOption  `json:"-"`
+	VerifyOptions []gjwt.VerifyOption `json:"-"`
+}
+
+// Manager 基于redis的会话管理
+// redis中保存: jti黑名单(过期时间为令牌剩余有效期) 用户版本号 refresh家族当前的jti
+type Manager struct {
+	jwt gjwt.ClaimsUtils
+	kid gjwt.KeySetUtils
+	rds *redis.Client
+	cfg Config
+}
+
+// New 配置了Kid时j还需要实现gjwt.KeySetUtils 例如gjwt.NewJwtTool()
+func New(j gjwt.ClaimsUtils, rds *redis.Client, cfg *Config) (*Manager, error) {
+	if j == nil || rds == nil || rds.Cc == nil {
+		return nil, fmt.Errorf("jwt or redis not init")
+	}
+	if cfg == nil {
+		return nil, fmt.Errorf("cfg is nil")
+	}
+	c := *cfg
+	if c.Method == "" && c.Kid == "" {
+		return nil, fmt.Errorf("method or kid is required")
+	}
+	if c.AccessTTL <= 0 {
+		c.AccessTTL = 15 * time.Minute
+	}
+	if c.RefreshTTL <= 0 {
+		c.RefreshTTL = 7 * 24 * time.Hour
+	}
+	if c.Prefix == "" {
+		c.Prefix = "session:"
+	}
+	m := &Manager{jwt: j, rds: rds, cfg: c}
+	if c.Kid != "" {
+		kid, ok := j.(gjwt.KeySetUtils)
+		if !ok {
+			return nil, fmt.Errorf("jwt not support kid")
+		}
+		m.kid = kid
+	}
+	return m, nil
+}
+
+func (m *Manager) denyKey(jti string) string {
+	return m.cfg.Prefix + "deny:" + jti
+}
+
+func (m *Manager) versionKey(user string) string {
+	return m.cfg.Prefix + "ver:" + user
+}
+
+func (m *Manager) familyKey(family string) string {
+	return m.cfg.Prefix + "fam:" + family
+}
+
+// Issue 登录成功后签发一对新令牌 开始一个新的refresh家族
+func (m *Manager) Issue(ctx context.Context, user string, data interface{}) (*Pair, error) {
+	if user == "" {
+		return nil, ErrNoSubject
+	}
+	ver, err := m.version(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	family := uuid.NewString()
+	pair, refreshID, err := m.sign(user, data, ver, family)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.rds.Cc.Set(ctx, m.familyKey(family), refreshID, m.cfg.RefreshTTL).Err(); err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+func (m *Manager) sign(user string, data interface{}, ver int64, family string) (*Pair, string, error) {
+	now := time.Now()
+	access := &Claims{Data: data, Type: TypeAccess, Version: ver, Family: family}
+	refresh := &Claims{Data: data, Type: TypeRefresh, Version: ver, Family: family}
+	access.RegisteredClaims = m.registered(user, now, m.cfg.AccessTTL)
+	refresh.RegisteredClaims = m.registered(user, now, m.cfg.RefreshTTL)
+
+	at, err := m.signClaims(access)
+	if err != nil {
+		return nil, "", err
+	}
+	rt, err := m.signClaims(refresh)
+	if err != nil {
+		return nil, "", err
+	}
+	return &Pair{
+		AccessToken:      at,
+		RefreshToken:     rt,
+		AccessExpiresAt:  access.ExpiresAt.Time,
+		RefreshExpiresAt: refresh.ExpiresAt.Time,
+	}, refresh.ID, nil
+}
+
+func (m *Manager) registered(user string, now time.Time, ttl time.Duration) gojwt.RegisteredClaims {
+	r := gojwt.RegisteredClaims{}
+	for _, opt := range m.cfg.ClaimOptions {
+		opt(&r)
+	}
+	r.Subject = user
+	r.ID = uuid.NewString()
+	r.IssuedAt = gojwt.NewNumericDate(now)
+	r.ExpiresAt = gojwt.NewNumericDate(now.Add(ttl))
+	return r
+}
+
+func (m *Manager) signClaims(c *Claims) (string, error) {
+	if m.cfg.Kid != "" {
+		return m.kid.SignClaimsWithKid(m.cfg.Kid, c)
+	}
+	return m.jwt.SignClaims(m.cfg.Method, c)
+}
+
+// parse 只校验签名和标准字段 不查redis
+func (m *Manager) parse(token string, typ string, opts []gjwt.VerifyOption) (*Claims, error) {
+	all := append([]gjwt.VerifyOption{gjwt.RequireID(), gjwt.RequireExpiration()}, m.cfg.VerifyOptions...)
+	c := &Claims{}
+	if err := m.jwt.ParseClaims(token, c, append(all, opts...)...); err != nil {
+		return nil, err
+	}
+	if typ != "" && c.Type != typ {
+		return nil, ErrTokenType
+	}
+	if c.Subject == "" {
+		return nil, ErrNoSubject
+	}
+	return c, nil
+}
+
+// Verify 验证access令牌 检查jti黑名单 用户版本号和所属家族是否已撤销
+func (m *Manager) Verify(ctx context.Context, token string, opts ...gjwt.VerifyOption) (*Claims, error) {
+	c, err := m.parse(token, TypeAccess, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.check(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (m *Manager) check(ctx context.Context, c *Claims) error {
+	var deny *goredis.IntCmd
+	var ver *goredis.StringCmd
+	var fam *goredis.IntCmd
+	// 集群下三个key不在同一个slot 用pipeline而不是mget
+	_, err := m.rds.Cc.Pipelined(ctx, func(p goredis.Pipeliner) error {
+		deny = p.Exists(ctx, m.denyKey(c.ID))
+		ver = p.Get(ctx, m.versionKey(c.Subject))
+		if c.Family != "" {
+			fam = p.Exists(ctx, m.familyKey(c.Family))
+		}
+		return nil
+	})
+	if err != nil && err != redis.ResultNil {
+		return err
+	}
+	if deny.Val() > 0 {
+		return ErrRevoked
+	}
+	if cur, err := ver.Int64(); err == nil && c.Version < cur {
+		return ErrRevoked
+	}
+	if fam != nil && fam.Val() == 0 {
+		return ErrRevoked
+	}
+	return nil
+}
+
+// rotateScript 家族当前jti等于ARGV[1]时替换为ARGV[2]
+// 返回1成功 0家族不存在(已撤销或过期) -1旧令牌被重复使用 此时删除整个家族
+var rotateScript = goredis.NewScript(`
+local cur = redis.call('GET', KEYS[1])
+if not cur then
+	return 0
+end
+if cur ~= ARGV[1] then
+	redis.call('DEL', KEYS[1])
+	return -1
+end
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+return 1
+`)
+
+// Refresh 用refresh令牌换一对新令牌 旧的refresh令牌随即失效
+// 已轮换过的refresh令牌再次使用说明可能被盗 整个家族连同其access令牌一起撤销
+func (m *Manager) Refresh(ctx context.Context, refreshToken string, opts ...gjwt.VerifyOption) (*Pair, error) {
+	c, err := m.parse(refreshToken, TypeRefresh, opts)
+	if err != nil {
+		return nil, err
+	}
+	if c.Family == "" {
+		return nil, ErrRevoked
+	}
+	ver, err := m.version(ctx, c.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if c.Version < ver {
+		return nil, ErrRevoked
+	}
+	deny, err := m.rds.Cc.Exists(ctx, m.denyKey(c.ID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if deny > 0 {
+		return nil, ErrRevoked
+	}
+	pair, refreshID, err := m.sign(c.Subject, c.Data, ver, c.Family)
+	if err != nil {
+		return nil, err
+	}
+	rs, err := rotateScript.Run(ctx, m.rds.Cc, []string{m.familyKey(c.Family)},
+		c.ID, refreshID, m.cfg.RefreshTTL.Milliseconds()).Int()
+	if err != nil {
+		return nil, err
+	}
+	switch rs {
+	case 1:
+		return pair, nil
+	case -1:
+		return nil, ErrReused
+	}
+	return nil, ErrRevoked
+}
+
+// Revoke 撤销一个令牌 refresh令牌会撤销整个家族 即退出当前登录
+// 已过期的令牌不需要撤销 直接返回nil
+func (m *Manager) Revoke(ctx context.Context, token string) error {
+	c, err := m.parse(token, "", nil)
+	if errors.Is(err, gojwt.ErrTokenExpired) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := m.RevokeID(ctx, c.ID, c.ExpiresAt.Time); err != nil {
+		return err
+	}
+	if c.Type == TypeRefresh && c.Family != "" {
+		return m.RevokeFamily(ctx, c.Family)
+	}
+	return nil
+}
+
+// RevokeID 把jti加入黑名单 保留到令牌的过期时间
+func (m *Manager) RevokeID(ctx context.Context, jti string, exp time.Time) error {
+	ttl := time.Until(exp)
+	if ttl <= 0 {
+		return nil
+	}
+	return m.rds.Cc.Set(ctx, m.denyKey(jti), 1, ttl).Err()
+}
+
+// RevokeFamily 撤销一次登录 该家族的refresh和access令牌全部失效
+func (m *Manager) RevokeFamily(ctx context.Context, family string) error {
+	return m.rds.Cc.Del(ctx, m.familyKey(family)).Err()
+}
+
+// LogoutAll 用户在所有设备退出 之前签发的令牌全部失效
+func (m *Manager) LogoutAll(ctx context.Context, user string) error {
+	return m.rds.Cc.Incr(ctx, m.versionKey(user)).Err()
+}
+
+func (m *Manager) version(ctx context.Context, user string) (int64, error) {
+	s, err := m.rds.Cc.Get(ctx, m.versionKey(user)).Result()
+	if err == redis.ResultNil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// IDCheck 给直接使用gjwt验证的地方加上jti黑名单检查
+//
+//	tool.Verify(token, mgr.IDCheck(ctx))
+func (m *Manager) IDCheck(ctx context.Context) gjwt.VerifyOption {
+	return gjwt.WithIDCheck(func(jti string) error {
+		n, err := m.rds.Cc.Exists(ctx, m.denyKey(jti)).Result()
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return ErrRevoked
+		}
+		return nil
+	})
+}
@@ -0,0 +1,180 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/suiguo/hwlib/gjwt"
+	"github.com/suiguo/hwlib/redis"
+)
+
+func newManager(t *testing.T, cfg *Config) (*Manager, *gjwt.JwtTool, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	cli := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { cli.Close() })
+	tool := gjwt.NewJwtTool()
+	tool.SetSecretPub(gjwt.MethodHMAC256, []byte("secret"))
+	tool.SetSecretPriv(gjwt.MethodHMAC256, []byte("secret"))
+	if cfg == nil {
+		cfg = &Config{Method: gjwt.MethodHMAC256}
+	}
+	m, err := New(tool, &redis.Client{Cc: cli}, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m, tool, mr
+}
+
+func TestRefreshReuse(t *testing.T) {
+	ctx := context.Background()
+	m, _, _ := newManager(t, nil)
+	first, err := m.Issue(ctx, "42", map[string]string{"name": "bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := m.Verify(ctx, first.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Subject != "42" || c.Type != TypeAccess || c.Family == "" {
+		t.Fatalf("claims %+v", c)
+	}
+	second, err := m.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Verify(ctx, second.AccessToken); err != nil {
+		t.Fatal(err)
+	}
+
+	// 已轮换的refresh令牌再次使用 整个家族撤销
+	if _, err := m.Refresh(ctx, first.RefreshToken); !errors.Is(err, ErrReused) {
+		t.Fatalf("reuse: %v", err)
+	}
+	for _, token := range []string{first.AccessToken, second.AccessToken} {
+		if _, err := m.Verify(ctx, token); !errors.Is(err, ErrRevoked) {
+			t.Fatalf("access after reuse: %v", err)
+		}
+	}
+	if _, err := m.Refresh(ctx, second.RefreshToken); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("refresh after reuse: %v", err)
+	}
+
+	// 其他登录不受影响
+	other, _ := m.Issue(ctx, "42", nil)
+	if _, err := m.Verify(ctx, other.AccessToken); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLogoutAll(t *testing.T) {
+	ctx := context.Background()
+	m, _, _ := newManager(t, nil)
+	old, _ := m.Issue(ctx, "42", nil)
+	bystander, _ := m.Issue(ctx, "7", nil)
+	if err := m.LogoutAll(ctx, "42"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Verify(ctx, old.AccessToken); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("access: %v", err)
+	}
+	if _, err := m.Refresh(ctx, old.RefreshToken); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("refresh: %v", err)
+	}
+	if _, err := m.Verify(ctx, bystander.AccessToken); err != nil {
+		t.Fatalf("other user: %v", err)
+	}
+	fresh, _ := m.Issue(ctx, "42", nil)
+	if _, err := m.Verify(ctx, fresh.AccessToken); err != nil {
+		t.Fatalf("new login: %v", err)
+	}
+	if _, err := m.Refresh(ctx, fresh.RefreshToken); err != nil {
+		t.Fatalf("new refresh: %v", err)
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	ctx := context.Background()
+	m, tool, mr := newManager(t, nil)
+	revoked, _ := m.Issue(ctx, "42", nil)
+	kept, _ := m.Issue(ctx, "42", nil)
+	if err := m.Revoke(ctx, revoked.AccessToken); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Verify(ctx, revoked.AccessToken); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("verify: %v", err)
+	}
+	// 直接使用gjwt验证时 IDCheck拒绝黑名单中的jti
+	if ok, _, err := tool.Verify(revoked.AccessToken, m.IDCheck(ctx)); ok || !errors.Is(err, ErrRevoked) {
+		t.Fatalf("IDCheck: %v %v", ok, err)
+	}
+	if ok, _, err := tool.Verify(kept.AccessToken, m.IDCheck(ctx)); !ok || err != nil {
+		t.Fatalf("IDCheck kept: %v", err)
+	}
+	if _, err := m.Verify(ctx, kept.AccessToken); err != nil {
+		t.Fatal(err)
+	}
+	// 黑名单保留到令牌过期
+	c, _ := m.parse(revoked.AccessToken, TypeAccess, nil)
+	if ttl := mr.TTL(m.denyKey(c.ID)); ttl <= 0 || ttl > m.cfg.AccessTTL {
+		t.Fatalf("deny ttl %s", ttl)
+	}
+
+	// 撤销refresh令牌即退出该次登录
+	if err := m.Revoke(ctx, kept.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Verify(ctx, kept.AccessToken); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("access after logout: %v", err)
+	}
+	if _, err := m.Refresh(ctx, kept.RefreshToken); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("refresh after logout: %v", err)
+	}
+}
+
+func TestTokenType(t *testing.T) {
+	ctx := context.Background()
+	m, _, _ := newManager(t, nil)
+	pair, _ := m.Issue(ctx, "42", nil)
+	if _, err := m.Verify(ctx, pair.RefreshToken); !errors.Is(err, ErrTokenType) {
+		t.Fatalf("refresh as access: %v", err)
+	}
+	if _, err := m.Refresh(ctx, pair.AccessToken); !errors.Is(err, ErrTokenType) {
+		t.Fatalf("access as refresh: %v", err)
+	}
+	if _, err := m.Issue(ctx, "", nil); !errors.Is(err, ErrNoSubject) {
+		t.Fatalf("empty user: %v", err)
+	}
+}
+
+// claimsOnly 只实现gjwt.ClaimsUtils
+type claimsOnly struct {
+	gjwt.ClaimsUtils
+}
+
+func TestKid(t *testing.T) {
+	ctx := context.Background()
+	m, tool, _ := newManager(t, &Config{Kid: "k1"})
+	if err := tool.AddKey("k1", gjwt.MethodHMAC512, []byte("kid-secret"), nil); err != nil {
+		t.Fatal(err)
+	}
+	pair, err := m.Issue(ctx, "42", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Verify(ctx, pair.AccessToken); err != nil {
+		t.Fatal(err)
+	}
+	cli := goredis.NewClient(&goredis.Options{Addr: "127.0.0.1:0"})
+	defer cli.Close()
+	if _, err := New(claimsOnly{tool}, &redis.Client{Cc: cli}, &Config{Kid: "k1"}); err == nil {
+		t.Fatal("kid without KeySetUtils")
+	}
+	if _, err := New(claimsOnly{tool}, &redis.Client{Cc: cli}, &Config{Method: gjwt.MethodHMAC256, AccessTTL: time.Minute}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,278 @@
+// Package signing signs and verifies wallet signatures: raw secp256k1
+// digests with a recovery id, EIP-191 personal_sign messages, EIP-712
+// typed data and TRON TIP-191 messages.
+//
+// Signatures are 65 bytes r || s || v. Sign functions return v as 27 or 28,
+// the form wallets produce; recovery also accepts v as 0 or 1.
+package signing
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/fbsobreira/gotron-sdk/pkg/address"
+	"github.com/suiguo/hwlib/ecies"
+)
+
+var (
+	ErrCurve     = errors.New("signing: key is not secp256k1")
+	ErrHash      = errors.New("signing: hash must be 32 bytes")
+	ErrSignature = errors.New("signing: invalid signature")
+	ErrAddress   = errors.New("signing: invalid address")
+	ErrMismatch  = errors.New("signing: signature does not match address")
+)
+
+const (
+	personalPrefix = "\x19Ethereum Signed Message:\n"
+	tronPrefix     = "\x19TRON Signed Message:\n"
+)
+
+// TypedData is an EIP-712 typed data document in the eth_signTypedData_v4
+// JSON form.
+type TypedData = apitypes.TypedData
+
+// ParseTypedData decodes an eth_signTypedData_v4 JSON document.
+func ParseTypedData(data []byte) (*TypedData, error) {
+	td := &TypedData{}
+	if err := json.Unmarshal(data, td); err != nil {
+		return nil, err
+	}
+	return td, nil
+}
+
+// Signer holds a secp256k1 private key.
+type Signer struct {
+	key *ecdsa.PrivateKey
+}
+
+// NewSigner wraps key, which must be on secp256k1.
+func NewSigner(key *ecdsa.PrivateKey) (*Signer, error) {
+	if key == nil || key.Curve != crypto.S256() {
+		return nil, ErrCurve
+	}
+	return &Signer{key: key}, nil
+}
+
+// HexToSigner parses a hex encoded secp256k1 private key, with or without
+// 0x prefix.
+func HexToSigner(hexKey string) (*Signer, error) {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(hexKey, "0x"))
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{key: key}, nil
+}
+
+// FromECIES uses an ecies key generated on secp256k1, for example with
+// ecies.GenerateKey(rand.Reader, crypto.S256(), nil).
+func FromECIES(key *ecies.PrivateKey) (*Signer, error) {
+	if key == nil {
+		return nil, ErrCurve
+	}
+	return NewSigner(key.ExportECDSA())
+}
+
+func (s *Signer) PublicKey() *ecdsa.PublicKey {
+	return &s.key.PublicKey
+}
+
+// Address is the Ethereum address of the key.
+func (s *Signer) Address() string {
+	return crypto.PubkeyToAddress(s.key.PublicKey).Hex()
+}
+
+// TronAddress is the base58check TRON address of the key.
+func (s *Signer) TronAddress() string {
+	return address.PubkeyToAddress(s.key.PublicKey).String()
+}
+
+// SignHash signs a 32 byte digest. v is 27 or 28.
+func (s *Signer) SignHash(hash []byte) ([]byte, error) {
+	if len(hash) != 32 {
+		return nil, ErrHash
+	}
+	sig, err := crypto.Sign(hash, s.key)
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+// SignPersonal signs msg as personal_sign does.
+func (s *Signer) SignPersonal(msg []byte) ([]byte, error) {
+	return s.SignHash(PersonalHash(msg))
+}
+
+// SignTypedData signs td as eth_signTypedData_v4 does.
+func (s *Signer) SignTypedData(td *TypedData) ([]byte, error) {
+	hash, err := TypedDataHash(td)
+	if err != nil {
+		return nil, err
+	}
+	return s.SignHash(hash)
+}
+
+// SignTron signs msg as TronWeb's signMessageV2 does.
+func (s *Signer) SignTron(msg []byte) ([]byte, error) {
+	return s.SignHash(TronHash(msg))
+}
+
+// PersonalHash is the EIP-191 version 0x45 hash of msg:
+// keccak256("\x19Ethereum Signed Message:\n" + len(msg) + msg).
+func PersonalHash(msg []byte) []byte {
+	return crypto.Keccak256([]byte(personalPrefix+strconv.Itoa(len(msg))), msg)
+}
+
+// TronHash is the TIP-191 hash of msg:
+// keccak256("\x19TRON Signed Message:\n" + len(msg) + msg).
+func TronHash(msg []byte) []byte {
+	return crypto.Keccak256([]byte(tronPrefix+strconv.Itoa(len(msg))), msg)
+}
+
+// TypedDataHash is the EIP-712 hash of td:
+// keccak256("\x19\x01" + domainSeparator + hashStruct(message)).
+func TypedDataHash(td *TypedData) ([]byte, error) {
+	if td == nil {
+		return nil, ErrSignature
+	}
+	hash, _, err := apitypes.TypedDataAndHash(*td)
+	return hash, err
+}
+
+// normalize checks sig and returns a copy with v as 0 or 1. Signatures with
+// s in the upper half of the curve order are rejected as malleable.
+func normalize(sig []byte) ([]byte, error) {
+	if len(sig) != crypto.SignatureLength {
+		return nil, ErrSignature
+	}
+	out := append([]byte{}, sig...)
+	if out[64] >= 27 {
+		out[64] -= 27
+	}
+	if !crypto.ValidateSignatureValues(out[64], common.BytesToHash(out[:32]).Big(), common.BytesToHash(out[32:64]).Big(), true) {
+		return nil, ErrSignature
+	}
+	return out, nil
+}
+
+// RecoverPublicKey returns the key that signed the 32 byte digest hash.
+func RecoverPublicKey(hash, sig []byte) (*ecdsa.PublicKey, error) {
+	if len(hash) != 32 {
+		return nil, ErrHash
+	}
+	norm, err := normalize(sig)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := crypto.SigToPub(hash, norm)
+	if err != nil {
+		return nil, ErrSignature
+	}
+	return pub, nil
+}
+
+// RecoverAddress returns the checksummed Ethereum address that signed hash.
+func RecoverAddress(hash, sig []byte) (string, error) {
+	pub, err := RecoverPublicKey(hash, sig)
+	if err != nil {
+		return "", err
+	}
+	return crypto.PubkeyToAddress(*pub).Hex(), nil
+}
+
+// RecoverTronAddress returns the TRON address that signed hash.
+func RecoverTronAddress(hash, sig []byte) (string, error) {
+	pub, err := RecoverPublicKey(hash, sig)
+	if err != nil {
+		return "", err
+	}
+	return address.PubkeyToAddress(*pub).String(), nil
+}
+
+// RecoverPersonal returns the address that personal_signed msg.
+func RecoverPersonal(msg, sig []byte) (string, error) {
+	return RecoverAddress(PersonalHash(msg), sig)
+}
+
+// RecoverTypedData returns the address that signed td.
+func RecoverTypedData(td *TypedData, sig []byte) (string, error) {
+	hash, err := TypedDataHash(td)
+	if err != nil {
+		return "", err
+	}
+	return RecoverAddress(hash, sig)
+}
+
+// RecoverTron returns the TRON address that signed msg with signMessageV2.
+func RecoverTron(msg, sig []byte) (string, error) {
+	return RecoverTronAddress(TronHash(msg), sig)
+}
+
+// VerifyPersonal checks that addr personal_signed msg. addr is compared
+// without regard to checksum case.
+func VerifyPersonal(addr string, msg, sig []byte) error {
+	return verifyEth(addr, PersonalHash(msg), sig)
+}
+
+// VerifyTypedData checks that addr signed td.
+func VerifyTypedData(addr string, td *TypedData, sig []byte) error {
+	hash, err := TypedDataHash(td)
+	if err != nil {
+		return err
+	}
+	return verifyEth(addr, hash, sig)
+}
+
+// VerifyTron checks that the TRON address addr, base58 or 41 prefixed hex,
+// signed msg with signMessageV2.
+func VerifyTron(addr string, msg, sig []byte) error {
+	want, err := parseTron(addr)
+	if err != nil {
+		return err
+	}
+	pub, err := RecoverPublicKey(TronHash(msg), sig)
+	if err != nil {
+		return err
+	}
+	if address.PubkeyToAddress(*pub).Hex() != want.Hex() {
+		return ErrMismatch
+	}
+	return nil
+}
+
+func verifyEth(addr string, hash, sig []byte) error {
+	if !common.IsHexAddress(addr) {
+		return ErrAddress
+	}
+	pub, err := RecoverPublicKey(hash, sig)
+	if err != nil {
+		return err
+	}
+	if crypto.PubkeyToAddress(*pub) != common.HexToAddress(addr) {
+		return ErrMismatch
+	}
+	return nil
+}
+
+func parseTron(addr string) (address.Address, error) {
+	if strings.HasPrefix(addr, "T") {
+		a, err := address.Base58ToAddress(addr)
+		if err != nil {
+			return nil, ErrAddress
+		}
+		return a, nil
+	}
+	b, err := hex.DecodeString(strings.TrimPrefix(addr, "0x"))
+	if err != nil || len(b) != address.AddressLength || b[0] != address.TronBytePrefix {
+		return nil, ErrAddress
+	}
+	return address.Address(b), nil
+}
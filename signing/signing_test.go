@@ -0,0 +1,156 @@
+package signing
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/suiguo/hwlib/ecies"
+)
+
+// EIP-712 example, signed by keccak256("cow")
+const mailTypedData = `{
+	"types": {
+		"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],
+		"Person": [
+			{"name": "name", "type": "string"},
+			{"name": "wallet", "type": "address"}
+		],
+		"Mail": [
+			{"name": "from", "type": "Person"},
+			{"name": "to", "type": "Person"},
+			{"name": "contents", "type": "string"}
+		]
+	},
+	"primaryType": "Mail",
+	"domain": {
+		"name": "Ether Mail",
+		"version": "1",
+		"chainId": 1,
+		"verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+	},
+	"message": {
+		"from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+		"to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+		"contents": "Hello, Bob!"
+	}
+}`
+
+func TestTypedData(t *testing.T) {
+	td, err := ParseTypedData([]byte(mailTypedData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := TypedDataHash(td)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(hash) != "be609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2" {
+		t.Fatalf("hash %x", hash)
+	}
+	signer, err := NewSigner(mustKey(crypto.Keccak256([]byte("cow"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := signer.SignTypedData(td)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d" +
+		"07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b91562" + "1c"
+	if hex.EncodeToString(sig) != want {
+		t.Fatalf("sig %x", sig)
+	}
+	if err := VerifyTypedData("0xcd2a3d9f938e13cd947ec05abc7fe734df8dd826", td, sig); err != nil {
+		t.Fatal(err)
+	}
+	td.Message["contents"] = "Hello, Alice!"
+	if err := VerifyTypedData(signer.Address(), td, sig); !errors.Is(err, ErrMismatch) {
+		t.Fatalf("modified message accepted: %v", err)
+	}
+}
+
+func mustKey(d []byte) *ecdsa.PrivateKey {
+	key, err := crypto.ToECDSA(d)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func TestPersonalAndTron(t *testing.T) {
+	eciesKey, err := ecies.GenerateKey(rand.Reader, crypto.S256(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := FromECIES(eciesKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("login nonce 8f2c")
+	if !bytes.Equal(PersonalHash(msg), accounts.TextHash(msg)) {
+		t.Fatal("personal hash differs from geth")
+	}
+
+	sig, err := signer.SignPersonal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig[64] != 27 && sig[64] != 28 {
+		t.Fatalf("v = %d", sig[64])
+	}
+	if addr, err := RecoverPersonal(msg, sig); err != nil || addr != signer.Address() {
+		t.Fatalf("recover %s %v", addr, err)
+	}
+	if err := VerifyPersonal(signer.Address(), msg, sig); err != nil {
+		t.Fatal(err)
+	}
+	raw := append([]byte{}, sig...)
+	raw[64] -= 27
+	if err := VerifyPersonal(signer.Address(), msg, raw); err != nil {
+		t.Fatalf("v 0/1 rejected: %v", err)
+	}
+	if err := VerifyPersonal(signer.Address(), []byte("other"), sig); !errors.Is(err, ErrMismatch) {
+		t.Fatalf("wrong message accepted: %v", err)
+	}
+
+	// (r, n-s) with the flipped recovery id is also valid and must be rejected.
+	high := append([]byte{}, sig...)
+	s := new(big.Int).SetBytes(high[32:64])
+	new(big.Int).Sub(crypto.S256().Params().N, s).FillBytes(high[32:64])
+	high[64] ^= 1
+	if _, err := RecoverPersonal(msg, high); !errors.Is(err, ErrSignature) {
+		t.Fatalf("malleable signature accepted: %v", err)
+	}
+
+	tsig, err := signer.SignTron(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(TronHash(msg), PersonalHash(msg)) {
+		t.Fatal("tron hash equals personal hash")
+	}
+	if addr, err := RecoverTron(msg, tsig); err != nil || addr != signer.TronAddress() {
+		t.Fatalf("recover tron %s %v", addr, err)
+	}
+	if err := VerifyTron(signer.TronAddress(), msg, tsig); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyTron(signer.TronAddress(), msg, sig); !errors.Is(err, ErrMismatch) {
+		t.Fatalf("eth signature accepted for tron: %v", err)
+	}
+	if err := VerifyTron("TXYZ", msg, tsig); !errors.Is(err, ErrAddress) {
+		t.Fatalf("bad address: %v", err)
+	}
+}
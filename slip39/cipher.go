@@ -0,0 +1,51 @@
+package slip39
+
+import (
+	"crypto/sha256"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// baseIterationCount is the total PBKDF2 iteration count at exponent 0,
+	// split over the Feistel rounds.
+	baseIterationCount = 10000
+	roundCount         = 4
+)
+
+// encrypt turns the master secret into the encrypted master secret with a
+// four round Feistel network keyed by the passphrase.
+func encrypt(masterSecret, passphrase []byte, exponent int, identifier uint16, extendable bool) []byte {
+	return feistel(masterSecret, passphrase, exponent, identifier, extendable, []int{0, 1, 2, 3})
+}
+
+// decrypt is the inverse of encrypt.
+func decrypt(encrypted, passphrase []byte, exponent int, identifier uint16, extendable bool) []byte {
+	return feistel(encrypted, passphrase, exponent, identifier, extendable, []int{3, 2, 1, 0})
+}
+
+func feistel(in, passphrase []byte, exponent int, identifier uint16, extendable bool, rounds []int) []byte {
+	half := len(in) / 2
+	l := append([]byte{}, in[:half]...)
+	r := append([]byte{}, in[half:]...)
+	salt := cipherSalt(identifier, extendable)
+	iterations := (baseIterationCount / roundCount) << uint(exponent)
+	for _, i := range rounds {
+		password := append([]byte{byte(i)}, passphrase...)
+		f := pbkdf2.Key(password, append(append([]byte{}, salt...), r...), iterations, half, sha256.New)
+		for k := range l {
+			l[k] ^= f[k]
+		}
+		l, r = r, l
+	}
+	return append(r, l...)
+}
+
+// cipherSalt binds non-extendable shares to their identifier; extendable
+// shares use an empty salt so more groups can be added later.
+func cipherSalt(identifier uint16, extendable bool) []byte {
+	if extendable {
+		return nil
+	}
+	return append([]byte(customizationString), byte(identifier>>8), byte(identifier))
+}
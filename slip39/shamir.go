@@ -0,0 +1,157 @@
+package slip39
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+)
+
+const (
+	// secretIndex and digestIndex are the x coordinates holding the shared
+	// secret and its digest.
+	secretIndex = 255
+	digestIndex = 254
+
+	digestLen = 4
+)
+
+var (
+	// exp and log tables of GF(256) with the Rijndael polynomial
+	// x^8 + x^4 + x^3 + x + 1 and generator x + 1.
+	gfExp [255]byte
+	gfLog [256]int
+)
+
+func init() {
+	poly := 1
+	for i := 0; i < 255; i++ {
+		gfExp[i] = byte(poly)
+		gfLog[poly] = i
+		// Multiply poly by the generator x + 1.
+		poly = (poly << 1) ^ poly
+		if poly&0x100 != 0 {
+			poly ^= 0x11b
+		}
+	}
+}
+
+type rawShare struct {
+	x    byte
+	data []byte
+}
+
+// interpolate evaluates at x the polynomial through the given points.
+func interpolate(shares []rawShare, x byte) ([]byte, error) {
+	seen := map[byte]bool{}
+	for _, s := range shares {
+		if seen[s.x] {
+			return nil, ErrDuplicateMemberIndex
+		}
+		seen[s.x] = true
+		if s.x == x {
+			return append([]byte{}, s.data...), nil
+		}
+	}
+	n := len(shares[0].data)
+	for _, s := range shares {
+		if len(s.data) != n {
+			return nil, ErrShareMismatch
+		}
+	}
+
+	logProd := 0
+	for _, s := range shares {
+		logProd += gfLog[s.x^x]
+	}
+	out := make([]byte, n)
+	for i, si := range shares {
+		// log of the Lagrange basis polynomial of si evaluated at x.
+		logBasis := logProd - gfLog[si.x^x]
+		for j, sj := range shares {
+			if i != j {
+				logBasis -= gfLog[si.x^sj.x]
+			}
+		}
+		logBasis = ((logBasis % 255) + 255) % 255
+		for k, v := range si.data {
+			if v != 0 {
+				out[k] ^= gfExp[(gfLog[v]+logBasis)%255]
+			}
+		}
+	}
+	return out, nil
+}
+
+func digest(randomPart, secret []byte) []byte {
+	mac := hmac.New(sha256.New, randomPart)
+	_, _ = mac.Write(secret)
+	return mac.Sum(nil)[:digestLen]
+}
+
+// splitSecret splits secret into count shares of which threshold recover it.
+func splitSecret(threshold, count int, secret []byte) ([]rawShare, error) {
+	if threshold < 1 || threshold > count || count > maxShareCount {
+		return nil, ErrInvalidThreshold
+	}
+	if threshold == 1 {
+		shares := make([]rawShare, count)
+		for i := range shares {
+			shares[i] = rawShare{x: byte(i), data: append([]byte{}, secret...)}
+		}
+		return shares, nil
+	}
+
+	randomCount := threshold - 2
+	shares := make([]rawShare, 0, count)
+	for i := 0; i < randomCount; i++ {
+		data, err := randomBytes(len(secret))
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, rawShare{x: byte(i), data: data})
+	}
+	randomPart, err := randomBytes(len(secret) - digestLen)
+	if err != nil {
+		return nil, err
+	}
+	base := append([]rawShare{}, shares...)
+	base = append(base,
+		rawShare{x: digestIndex, data: append(digest(randomPart, secret), randomPart...)},
+		rawShare{x: secretIndex, data: secret},
+	)
+	for i := randomCount; i < count; i++ {
+		data, err := interpolate(base, byte(i))
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, rawShare{x: byte(i), data: data})
+	}
+	return shares, nil
+}
+
+// recoverSecret recovers the secret from threshold shares and checks its digest.
+func recoverSecret(threshold int, shares []rawShare) ([]byte, error) {
+	if threshold == 1 {
+		return shares[0].data, nil
+	}
+	secret, err := interpolate(shares, secretIndex)
+	if err != nil {
+		return nil, err
+	}
+	d, err := interpolate(shares, digestIndex)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(d[:digestLen], digest(d[digestLen:], secret)) {
+		return nil, ErrDigestMismatch
+	}
+	return secret, nil
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
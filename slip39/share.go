@@ -0,0 +1,173 @@
+package slip39
+
+import (
+	"math/big"
+	"strings"
+)
+
+const (
+	radixBits = 10
+
+	checksumWords = 3
+	// metadataWords is the share prefix (identifier, flags and indexes)
+	// plus the checksum.
+	metadataWords = 4 + checksumWords
+	// minMnemonicWords holds a 128 bit share value.
+	minMnemonicWords = metadataWords + (minSecretBytes*8+radixBits-1)/radixBits
+
+	customizationString           = "shamir"
+	customizationStringExtendable = "shamir_extendable"
+)
+
+// wordMap is a reverse lookup map for WordList.
+var wordMap = func() map[string]int {
+	m := make(map[string]int, len(WordList))
+	for i, w := range WordList {
+		m[w] = i
+	}
+	return m
+}()
+
+// Share is a single decoded SLIP-39 mnemonic.
+type Share struct {
+	Identifier        uint16
+	Extendable        bool
+	IterationExponent int
+	GroupIndex        int
+	GroupThreshold    int
+	GroupCount        int
+	MemberIndex       int
+	MemberThreshold   int
+	Value             []byte
+}
+
+// ParseShare decodes and checks a share mnemonic.
+func ParseShare(mnemonic string) (*Share, error) {
+	fields := strings.Fields(strings.ToLower(mnemonic))
+	if len(fields) < minMnemonicWords {
+		return nil, ErrInvalidMnemonic
+	}
+	words := make([]int, len(fields))
+	for i, f := range fields {
+		idx, ok := wordMap[f]
+		if !ok {
+			return nil, ErrInvalidMnemonic
+		}
+		words[i] = idx
+	}
+
+	prefix := words[0]<<10 | words[1]
+	identifier := uint16(prefix >> 5)
+	extendable := prefix>>4&1 == 1
+	if !rs1024Verify(words, customization(extendable)) {
+		return nil, ErrChecksumIncorrect
+	}
+
+	params := words[2]<<10 | words[3]
+	s := &Share{
+		Identifier:        identifier,
+		Extendable:        extendable,
+		IterationExponent: prefix & 0xf,
+		GroupIndex:        params >> 16,
+		GroupThreshold:    params>>12&0xf + 1,
+		GroupCount:        params>>8&0xf + 1,
+		MemberIndex:       params >> 4 & 0xf,
+		MemberThreshold:   params&0xf + 1,
+	}
+	if s.GroupThreshold > s.GroupCount {
+		return nil, ErrInvalidThreshold
+	}
+
+	valueWords := words[4 : len(words)-checksumWords]
+	padding := len(valueWords) * radixBits % 16
+	if padding > 8 {
+		return nil, ErrInvalidMnemonic
+	}
+	value := big.NewInt(0)
+	for _, w := range valueWords {
+		value.Lsh(value, radixBits)
+		value.Or(value, big.NewInt(int64(w)))
+	}
+	valueBytes := (len(valueWords)*radixBits - padding) / 8
+	if value.BitLen() > valueBytes*8 {
+		// The padding bits must be zero.
+		return nil, ErrInvalidPadding
+	}
+	s.Value = value.FillBytes(make([]byte, valueBytes))
+	return s, nil
+}
+
+// Mnemonic encodes the share as words from WordList.
+func (s *Share) Mnemonic() string {
+	prefix := int(s.Identifier)<<5 | s.IterationExponent
+	if s.Extendable {
+		prefix |= 1 << 4
+	}
+	params := s.GroupIndex<<16 | (s.GroupThreshold-1)<<12 | (s.GroupCount-1)<<8 |
+		s.MemberIndex<<4 | (s.MemberThreshold - 1)
+	words := []int{prefix >> 10, prefix & 1023, params >> 10, params & 1023}
+
+	valueWordCount := (len(s.Value)*8 + radixBits - 1) / radixBits
+	value := new(big.Int).SetBytes(s.Value)
+	valueWords := make([]int, valueWordCount)
+	mask := big.NewInt(1023)
+	for i := valueWordCount - 1; i >= 0; i-- {
+		valueWords[i] = int(new(big.Int).And(value, mask).Int64())
+		value.Rsh(value, radixBits)
+	}
+	words = append(words, valueWords...)
+	words = append(words, rs1024Checksum(words, customization(s.Extendable))...)
+
+	out := make([]string, len(words))
+	for i, w := range words {
+		out[i] = WordList[w]
+	}
+	return strings.Join(out, " ")
+}
+
+func customization(extendable bool) string {
+	if extendable {
+		return customizationStringExtendable
+	}
+	return customizationString
+}
+
+func rs1024Polymod(values []int) int {
+	gen := [10]int{
+		0xe0e040, 0x1c1c080, 0x3838100, 0x7070200, 0xe0e0009,
+		0x1c0c2412, 0x38086c24, 0x3090fc48, 0x21b1f890, 0x3f3f120,
+	}
+	chk := 1
+	for _, v := range values {
+		b := chk >> 20
+		chk = (chk&0xfffff)<<10 ^ v
+		for i := 0; i < 10; i++ {
+			if (b>>uint(i))&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+func customizationValues(cs string, data []int) []int {
+	values := make([]int, 0, len(cs)+len(data)+checksumWords)
+	for i := 0; i < len(cs); i++ {
+		values = append(values, int(cs[i]))
+	}
+	return append(values, data...)
+}
+
+func rs1024Checksum(data []int, cs string) []int {
+	values := append(customizationValues(cs, data), 0, 0, 0)
+	polymod := rs1024Polymod(values) ^ 1
+	out := make([]int, checksumWords)
+	for i := 0; i < checksumWords; i++ {
+		out[i] = polymod >> uint(radixBits*(checksumWords-1-i)) & 1023
+	}
+	return out
+}
+
+func rs1024Verify(data []int, cs string) bool {
+	return rs1024Polymod(customizationValues(cs, data)) == 1
+}
@@ -0,0 +1,220 @@
+// Package slip39 is the Golang implementation of SLIP-0039 Shamir's secret
+// sharing of master secrets, the counterpart of the bip39 package for M-of-N
+// backups.
+//
+// The official SLIP-0039 spec can be found at
+// https://github.com/satoshilabs/slips/blob/master/slip-0039.md
+package slip39
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	minSecretBytes = 16
+	maxShareCount  = 16
+	maxExponent    = 15
+)
+
+var (
+	// ErrInvalidMnemonic is returned for mnemonics with unknown words or an
+	// invalid length.
+	ErrInvalidMnemonic = errors.New("Invalid mnemonic")
+
+	// ErrChecksumIncorrect is returned when the RS1024 checksum fails.
+	ErrChecksumIncorrect = errors.New("Checksum incorrect")
+
+	// ErrInvalidPadding is returned when the share value padding is not zero.
+	ErrInvalidPadding = errors.New("Invalid mnemonic padding")
+
+	// ErrInvalidSecret is returned for master secrets shorter than 128 bits
+	// or of odd length.
+	ErrInvalidSecret = errors.New("Master secret must be at least 128 bits and a multiple of 16 bits")
+
+	// ErrInvalidPassphrase is returned for passphrases with non printable
+	// ASCII characters.
+	ErrInvalidPassphrase = errors.New("Passphrase must contain only printable ASCII characters")
+
+	// ErrInvalidThreshold is returned for impossible threshold and count
+	// combinations.
+	ErrInvalidThreshold = errors.New("Invalid threshold or share count")
+
+	// ErrInvalidExponent is returned for iteration exponents above 15.
+	ErrInvalidExponent = errors.New("Iteration exponent must be between 0 and 15")
+
+	// ErrShareMismatch is returned when shares of different secrets or with
+	// conflicting parameters are combined.
+	ErrShareMismatch = errors.New("Shares do not belong to the same secret")
+
+	// ErrDuplicateMemberIndex is returned when a member index is used twice.
+	ErrDuplicateMemberIndex = errors.New("Duplicate member index")
+
+	// ErrInsufficientShares is returned when fewer groups or members than the
+	// thresholds are given.
+	ErrInsufficientShares = errors.New("Insufficient number of shares")
+
+	// ErrDigestMismatch is returned when the recovered secret fails its
+	// digest check.
+	ErrDigestMismatch = errors.New("Invalid digest of the shared secret")
+)
+
+// Group is the member threshold and member count of one share group.
+type Group struct {
+	Threshold int
+	Count     int
+}
+
+// GenerateMnemonics splits masterSecret into groups of share mnemonics.
+// groupThreshold groups are needed to recover the secret, and within group i
+// groups[i].Threshold of its groups[i].Count members. The secret is encrypted
+// with passphrase, which may be empty, using 10000 << exponent PBKDF2 rounds.
+// The result is indexed like groups.
+func GenerateMnemonics(groupThreshold int, groups []Group, masterSecret, passphrase []byte, extendable bool, exponent int) ([][]string, error) {
+	if len(masterSecret) < minSecretBytes || len(masterSecret)%2 != 0 {
+		return nil, ErrInvalidSecret
+	}
+	if err := validatePassphrase(passphrase); err != nil {
+		return nil, err
+	}
+	if exponent < 0 || exponent > maxExponent {
+		return nil, ErrInvalidExponent
+	}
+	if groupThreshold < 1 || groupThreshold > len(groups) || len(groups) > maxShareCount {
+		return nil, ErrInvalidThreshold
+	}
+	for _, g := range groups {
+		// A 1-of-N group would just repeat the same share; use 1-of-1.
+		if g.Threshold == 1 && g.Count > 1 {
+			return nil, ErrInvalidThreshold
+		}
+	}
+
+	var idBytes [2]byte
+	if _, err := rand.Read(idBytes[:]); err != nil {
+		return nil, err
+	}
+	identifier := binary.BigEndian.Uint16(idBytes[:]) & 0x7fff
+
+	encrypted := encrypt(masterSecret, passphrase, exponent, identifier, extendable)
+	groupShares, err := splitSecret(groupThreshold, len(groups), encrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([][]string, len(groups))
+	for gi, g := range groups {
+		memberShares, err := splitSecret(g.Threshold, g.Count, groupShares[gi].data)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range memberShares {
+			s := &Share{
+				Identifier:        identifier,
+				Extendable:        extendable,
+				IterationExponent: exponent,
+				GroupIndex:        gi,
+				GroupThreshold:    groupThreshold,
+				GroupCount:        len(groups),
+				MemberIndex:       int(m.x),
+				MemberThreshold:   g.Threshold,
+				Value:             m.data,
+			}
+			out[gi] = append(out[gi], s.Mnemonic())
+		}
+	}
+	return out, nil
+}
+
+// CombineMnemonics recovers the master secret from share mnemonics of
+// enough groups and members, and decrypts it with passphrase.
+func CombineMnemonics(mnemonics []string, passphrase []byte) ([]byte, error) {
+	if len(mnemonics) == 0 {
+		return nil, ErrInsufficientShares
+	}
+	if err := validatePassphrase(passphrase); err != nil {
+		return nil, err
+	}
+	shares := make([]*Share, len(mnemonics))
+	for i, m := range mnemonics {
+		s, err := ParseShare(m)
+		if err != nil {
+			return nil, err
+		}
+		shares[i] = s
+	}
+	return CombineShares(shares, passphrase)
+}
+
+// CombineShares is CombineMnemonics on already parsed shares.
+func CombineShares(shares []*Share, passphrase []byte) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, ErrInsufficientShares
+	}
+	first := shares[0]
+	groups := map[int][]*Share{}
+	var order []int
+	for _, s := range shares {
+		if s.Identifier != first.Identifier || s.Extendable != first.Extendable ||
+			s.IterationExponent != first.IterationExponent ||
+			s.GroupThreshold != first.GroupThreshold || s.GroupCount != first.GroupCount ||
+			len(s.Value) != len(first.Value) {
+			return nil, ErrShareMismatch
+		}
+		if _, ok := groups[s.GroupIndex]; !ok {
+			order = append(order, s.GroupIndex)
+		}
+		groups[s.GroupIndex] = append(groups[s.GroupIndex], s)
+	}
+	if len(groups) < first.GroupThreshold {
+		return nil, ErrInsufficientShares
+	}
+	if len(groups) > first.GroupThreshold {
+		return nil, ErrShareMismatch
+	}
+
+	groupShares := make([]rawShare, 0, len(groups))
+	for _, gi := range order {
+		members := groups[gi]
+		threshold := members[0].MemberThreshold
+		seen := map[int]bool{}
+		raw := make([]rawShare, 0, len(members))
+		for _, m := range members {
+			if m.MemberThreshold != threshold {
+				return nil, ErrShareMismatch
+			}
+			if seen[m.MemberIndex] {
+				return nil, ErrDuplicateMemberIndex
+			}
+			seen[m.MemberIndex] = true
+			raw = append(raw, rawShare{x: byte(m.MemberIndex), data: m.Value})
+		}
+		if len(raw) < threshold {
+			return nil, ErrInsufficientShares
+		}
+		if len(raw) > threshold {
+			return nil, ErrShareMismatch
+		}
+		value, err := recoverSecret(threshold, raw)
+		if err != nil {
+			return nil, err
+		}
+		groupShares = append(groupShares, rawShare{x: byte(gi), data: value})
+	}
+
+	encrypted, err := recoverSecret(first.GroupThreshold, groupShares)
+	if err != nil {
+		return nil, err
+	}
+	return decrypt(encrypted, passphrase, first.IterationExponent, first.Identifier, first.Extendable), nil
+}
+
+func validatePassphrase(passphrase []byte) error {
+	for _, c := range passphrase {
+		if c < 32 || c > 126 {
+			return ErrInvalidPassphrase
+		}
+	}
+	return nil
+}
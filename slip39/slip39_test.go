@@ -0,0 +1,80 @@
+package slip39
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/suiguo/hwlib/hdwallet"
+)
+
+// testdata/vectors.json is the official test vector file of the spec,
+// generated with the passphrase "TREZOR".
+func TestVectors(t *testing.T) {
+	raw, err := os.ReadFile("testdata/vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors [][]interface{}
+	if err := json.Unmarshal(raw, &vectors); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range vectors {
+		name := v[0].(string)
+		var mnemonics []string
+		for _, m := range v[1].([]interface{}) {
+			mnemonics = append(mnemonics, m.(string))
+		}
+		secret, err := CombineMnemonics(mnemonics, []byte("TREZOR"))
+		want := v[2].(string)
+		if want == "" {
+			if err == nil {
+				t.Fatalf("%s: expected an error", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if hex.EncodeToString(secret) != want {
+			t.Fatalf("%s: secret %x != %s", name, secret, want)
+		}
+		master, err := hdwallet.NewMasterKey(secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if master.String() != v[3].(string) {
+			t.Fatalf("%s: xprv mismatch", name)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	secret := bytes.Repeat([]byte{0x5a}, 32)
+	groups := []Group{{1, 1}, {2, 3}, {3, 5}}
+	mnemonics, err := GenerateMnemonics(2, groups, secret, []byte("pass"), true, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	picks := [][]string{
+		{mnemonics[0][0], mnemonics[1][2], mnemonics[1][0]},
+		{mnemonics[2][4], mnemonics[1][1], mnemonics[2][0], mnemonics[1][2], mnemonics[2][2]},
+	}
+	for _, pick := range picks {
+		got, err := CombineMnemonics(pick, []byte("pass"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, secret) {
+			t.Fatalf("recovered %x", got)
+		}
+	}
+	if _, err := CombineMnemonics(mnemonics[1][:1], []byte("pass")); err != ErrInsufficientShares {
+		t.Fatalf("expected insufficient shares, got %v", err)
+	}
+	if _, err := GenerateMnemonics(1, []Group{{1, 2}}, secret, nil, false, 0); err != ErrInvalidThreshold {
+		t.Fatal("1-of-2 group should be rejected")
+	}
+}